@@ -0,0 +1,409 @@
+/*
+Package breaker provides a circuit breaker, which stops calling an operation that is failing so that
+a struggling remote system is given time to recover and callers fail fast instead of waiting on it.
+
+A Breaker starts Closed, where every call is allowed. When calls fail according to the trip policy, it
+opens and every call fails with an error wrapping ErrOpen. After the open timeout it is HalfOpen, where a
+limited number of probe calls are allowed. If the probes succeed it closes, if any fails it opens again.
+
+Two trip policies are provided and may be combined. WithConsecutiveFailures() opens the Breaker after a
+number of failures in a row. WithFailureRate() opens it when the failure rate over a sliding window is
+too high. If neither is set, the Breaker opens after 5 consecutive failures.
+
+Example:
+
+	b, err := breaker.New(
+		breaker.WithFailureRate(0.5, 20, time.Minute),
+		breaker.WithOpenTimeout(10*time.Second),
+	)
+	if err != nil {
+		// Handle error
+	}
+
+	quote, err := breaker.Do(ctx, b, func(ctx context.Context) (Quote, error) {
+		return client.Quote(ctx, author)
+	})
+	if errors.Is(err, breaker.ErrOpen) {
+		// Fail fast or use a fallback.
+	}
+
+A Breaker is used with retries from the exponential package by passing Breaker.ErrTransformer() with
+exponential.WithErrTransformer(), so a retry waits for the Breaker to allow probes instead of failing
+against an open Breaker.
+*/
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+var (
+	// ErrOpen is wrapped by the error returned when a Breaker does not allow a call, because it is open
+	// or it is half-open and all its probes are in use.
+	ErrOpen = errors.New("circuit breaker is open")
+
+	// errProbes is returned when a half-open Breaker has no probes left.
+	errProbes = fmt.Errorf("%w: half-open with no probes available", ErrOpen)
+)
+
+// State is the state of a Breaker.
+type State uint8
+
+const (
+	// Closed indicates calls are allowed.
+	Closed State = 1
+	// Open indicates calls are not allowed.
+	Open State = 2
+	// HalfOpen indicates a limited number of probe calls are allowed to test if the operation recovered.
+	HalfOpen State = 3
+)
+
+// String implements fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "Closed"
+	case Open:
+		return "Open"
+	case HalfOpen:
+		return "HalfOpen"
+	}
+	return "Unknown"
+}
+
+// Clock provides the current time to a Breaker. exponential.Clock implements Clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// Breaker is a circuit breaker. Create with New(). A Breaker is safe for concurrent use.
+type Breaker struct {
+	// consecutive is the number of consecutive failures that opens the Breaker. 0 is disabled.
+	consecutive int
+	// rate is the failure rate that opens the Breaker. 0 is disabled.
+	rate float64
+	// minRequests is the number of calls in the window before rate is checked.
+	minRequests int
+	// window is the sliding window rate is checked over.
+	window *window
+	// openTimeout is how long the Breaker stays open before it is half-open.
+	openTimeout time.Duration
+	// probes is the number of concurrent calls allowed when half-open, which must all succeed to close.
+	probes int
+	// isFailure reports if an error returned by a call is a failure.
+	isFailure func(error) bool
+	// onChange is called when the state changes.
+	onChange func(from, to State)
+	// clock is the Clock set with WithClock(). If nil, the time package is used.
+	clock Clock
+
+	mu    sync.Mutex
+	state State
+	// generation is incremented on every state change, so results of calls allowed in an earlier
+	// state are ignored.
+	generation uint64
+	// openedAt is when the Breaker last opened.
+	openedAt time.Time
+	// failures is the number of consecutive failures when closed.
+	failures int
+	// inFlight is the number of probes executing when half-open.
+	inFlight int
+	// successes is the number of probes that succeeded when half-open.
+	successes int
+}
+
+// Option is an option for New().
+type Option func(*Breaker) error
+
+// WithConsecutiveFailures opens the Breaker after n consecutive failures.
+func WithConsecutiveFailures(n int) Option {
+	return func(b *Breaker) error {
+		if n < 1 {
+			return fmt.Errorf("WithConsecutiveFailures(%d) must be > 0", n)
+		}
+		b.consecutive = n
+		return nil
+	}
+}
+
+// WithFailureRate opens the Breaker when the fraction of calls that failed in the last window is at least
+// rate, where rate is > 0 and <= 1. The rate is only checked once there were at least minRequests calls in
+// the window, so a few failures when traffic is low do not open the Breaker.
+func WithFailureRate(rate float64, minRequests int, window time.Duration) Option {
+	return func(b *Breaker) error {
+		switch {
+		case rate <= 0 || rate > 1:
+			return fmt.Errorf("WithFailureRate() rate %v must be > 0 and <= 1", rate)
+		case minRequests < 1:
+			return fmt.Errorf("WithFailureRate() minRequests %d must be > 0", minRequests)
+		case window < windowBuckets:
+			return fmt.Errorf("WithFailureRate() window %v must be at least %v", window, time.Duration(windowBuckets))
+		}
+		b.rate, b.minRequests, b.window = rate, minRequests, newWindow(window)
+		return nil
+	}
+}
+
+// WithOpenTimeout sets how long the Breaker stays open before it is half-open and allows probes.
+// The default is 30 seconds.
+func WithOpenTimeout(d time.Duration) Option {
+	return func(b *Breaker) error {
+		if d <= 0 {
+			return fmt.Errorf("WithOpenTimeout(%v) must be > 0", d)
+		}
+		b.openTimeout = d
+		return nil
+	}
+}
+
+// WithProbes sets the number of probe calls allowed at the same time when the Breaker is half-open.
+// The Breaker closes when n probes succeed and opens if any fails. The default is 1.
+func WithProbes(n int) Option {
+	return func(b *Breaker) error {
+		if n < 1 {
+			return fmt.Errorf("WithProbes(%d) must be > 0", n)
+		}
+		b.probes = n
+		return nil
+	}
+}
+
+// WithIsFailure sets f to decide if an error returned by a call is a failure. Errors that are not failures
+// count as successes, such as a not found error that shows the remote system is healthy. The default
+// counts every error as a failure except context.Canceled, which is caused by the caller.
+func WithIsFailure(f func(err error) bool) Option {
+	return func(b *Breaker) error {
+		if f == nil {
+			return errors.New("WithIsFailure() cannot be passed a nil func")
+		}
+		b.isFailure = f
+		return nil
+	}
+}
+
+// WithOnStateChange sets f to be called when the Breaker changes state, such as to log or record a metric.
+// f is called with the Breaker locked, so it must return quickly and must not call the Breaker.
+func WithOnStateChange(f func(from, to State)) Option {
+	return func(b *Breaker) error {
+		if f == nil {
+			return errors.New("WithOnStateChange() cannot be passed a nil func")
+		}
+		b.onChange = f
+		return nil
+	}
+}
+
+// WithClock sets the Clock used by the Breaker. This is used in tests to inject a fake Clock.
+// If not specified, the time package is used.
+func WithClock(c Clock) Option {
+	return func(b *Breaker) error {
+		if c == nil {
+			return errors.New("WithClock() cannot be passed a nil Clock")
+		}
+		b.clock = c
+		return nil
+	}
+}
+
+// New creates a new Breaker with the given options.
+func New(options ...Option) (*Breaker, error) {
+	b := &Breaker{
+		openTimeout: 30 * time.Second,
+		probes:      1,
+		isFailure:   defaultIsFailure,
+		state:       Closed,
+	}
+	for _, o := range options {
+		if err := o(b); err != nil {
+			return nil, err
+		}
+	}
+	if b.consecutive == 0 && b.rate == 0 {
+		b.consecutive = 5
+	}
+	return b, nil
+}
+
+// defaultIsFailure counts every error as a failure except context.Canceled.
+func defaultIsFailure(err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled)
+}
+
+// now returns the current time from the Clock.
+func (b *Breaker) now() time.Time {
+	if b.clock == nil {
+		return time.Now()
+	}
+	return b.clock.Now()
+}
+
+// State returns the state of the Breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkTimeout(b.now())
+	return b.state
+}
+
+/*
+Allow reports if a call is allowed. If it is, done must be called with the error the call returned,
+or nil if it succeeded. If it is not, an error wrapping ErrOpen is returned. This is used when a call
+can't be wrapped in a function, such as in an http.RoundTripper:
+
+	done, err := b.Allow()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.RoundTrip(req)
+	done(err)
+*/
+func (b *Breaker) Allow() (done func(err error), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkTimeout(b.now())
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.inFlight >= b.probes {
+			return nil, errProbes
+		}
+		b.inFlight++
+	}
+
+	gen := b.generation
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.done(gen, err) })
+	}, nil
+}
+
+// Execute calls op if the Breaker allows it and records the result. If the Breaker does not allow the call,
+// an error wrapping ErrOpen is returned. If op panics, the panic counts as a failure and is re-raised.
+func (b *Breaker) Execute(ctx context.Context, op func(ctx context.Context) error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			done(errors.New("panic"))
+		}
+	}()
+	err = op(ctx)
+	panicked = false
+	done(err)
+	return err
+}
+
+// Do calls op with b like Breaker.Execute(), returning the value op returns.
+func Do[T any](ctx context.Context, b *Breaker, op func(ctx context.Context) (T, error)) (T, error) {
+	var v T
+	err := b.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		v, err = op(ctx)
+		return err
+	})
+	return v, err
+}
+
+// ErrTransformer is an exponential.ErrTransformer that converts an error from an open Breaker into an
+// exponential.ErrRetryAfter for when the Breaker allows probes, so exponential.Backoff.Retry() waits
+// for the Breaker instead of failing against it. Other errors are returned unchanged.
+func (b *Breaker) ErrTransformer(err error) error {
+	if !errors.Is(err, ErrOpen) {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != Open {
+		return err
+	}
+	return exponential.ErrRetryAfter{Time: b.openedAt.Add(b.openTimeout), Err: err}
+}
+
+// done records the result of a call allowed in generation gen.
+func (b *Breaker) done(gen uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if gen != b.generation {
+		return
+	}
+	now := b.now()
+	failed := b.isFailure(err)
+
+	switch b.state {
+	case Closed:
+		if b.window != nil {
+			b.window.add(now, failed)
+		}
+		if !failed {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.shouldTrip(now) {
+			b.setState(Open, now)
+		}
+	case HalfOpen:
+		b.inFlight--
+		if failed {
+			b.setState(Open, now)
+			return
+		}
+		b.successes++
+		if b.successes >= b.probes {
+			b.setState(Closed, now)
+		}
+	}
+}
+
+// shouldTrip reports if the Breaker should open. b.mu must be held.
+func (b *Breaker) shouldTrip(now time.Time) bool {
+	if b.consecutive > 0 && b.failures >= b.consecutive {
+		return true
+	}
+	if b.rate > 0 {
+		total, failed := b.window.counts(now)
+		return total >= b.minRequests && float64(failed)/float64(total) >= b.rate
+	}
+	return false
+}
+
+// checkTimeout moves an open Breaker to half-open if the open timeout has passed. b.mu must be held.
+func (b *Breaker) checkTimeout(now time.Time) {
+	if b.state == Open && now.Sub(b.openedAt) >= b.openTimeout {
+		b.setState(HalfOpen, now)
+	}
+}
+
+// setState changes the state to s and resets the counts. b.mu must be held.
+func (b *Breaker) setState(s State, now time.Time) {
+	from := b.state
+	b.state = s
+	b.generation++
+	b.failures, b.inFlight, b.successes = 0, 0, 0
+	if b.window != nil {
+		b.window.reset()
+	}
+	if s == Open {
+		b.openedAt = now
+	}
+	if b.onChange != nil {
+		b.onChange(from, s)
+	}
+}
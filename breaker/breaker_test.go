@@ -0,0 +1,280 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var errFail = errors.New("fail")
+
+// fakeClock is a Clock whose time only changes when advanced.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// call makes a call with b that returns err and returns the error from the Breaker.
+func call(b *Breaker, err error) error {
+	return b.Execute(context.Background(), func(context.Context) error { return err })
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []Option
+		wantErr bool
+	}{
+		{name: "Defaults"},
+		{name: "Valid", options: []Option{WithConsecutiveFailures(1), WithFailureRate(1, 1, time.Second), WithOpenTimeout(time.Second), WithProbes(1)}},
+		{name: "Bad consecutive", options: []Option{WithConsecutiveFailures(0)}, wantErr: true},
+		{name: "Bad rate", options: []Option{WithFailureRate(1.1, 1, time.Second)}, wantErr: true},
+		{name: "Bad minRequests", options: []Option{WithFailureRate(0.5, 0, time.Second)}, wantErr: true},
+		{name: "Bad window", options: []Option{WithFailureRate(0.5, 1, 0)}, wantErr: true},
+		{name: "Bad open timeout", options: []Option{WithOpenTimeout(0)}, wantErr: true},
+		{name: "Bad probes", options: []Option{WithProbes(0)}, wantErr: true},
+		{name: "Nil IsFailure", options: []Option{WithIsFailure(nil)}, wantErr: true},
+		{name: "Nil OnStateChange", options: []Option{WithOnStateChange(nil)}, wantErr: true},
+		{name: "Nil Clock", options: []Option{WithClock(nil)}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		_, err := New(test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNew(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNew(%s): got err == %s, want err == nil", test.name, err)
+		}
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var changes []string
+	b, err := New(
+		WithConsecutiveFailures(3),
+		WithOpenTimeout(time.Minute),
+		WithClock(clock),
+		WithOnStateChange(func(from, to State) { changes = append(changes, from.String()+"->"+to.String()) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A success resets the consecutive failures.
+	for _, err := range []error{errFail, errFail, nil, errFail, errFail} {
+		call(b, err)
+	}
+	if got := b.State(); got != Closed {
+		t.Fatalf("TestConsecutiveFailures: after 2 consecutive failures: got %v, want Closed", got)
+	}
+	call(b, errFail)
+	if got := b.State(); got != Open {
+		t.Fatalf("TestConsecutiveFailures: after 3 consecutive failures: got %v, want Open", got)
+	}
+
+	called := false
+	err = b.Execute(context.Background(), func(context.Context) error { called = true; return nil })
+	if !errors.Is(err, ErrOpen) || called {
+		t.Errorf("TestConsecutiveFailures: open: got err == %v, called == %v, want ErrOpen and not called", err, called)
+	}
+
+	clock.advance(time.Minute)
+	if got := b.State(); got != HalfOpen {
+		t.Fatalf("TestConsecutiveFailures: after open timeout: got %v, want HalfOpen", got)
+	}
+	call(b, errFail)
+	if got := b.State(); got != Open {
+		t.Fatalf("TestConsecutiveFailures: after failed probe: got %v, want Open", got)
+	}
+
+	clock.advance(time.Minute)
+	if err := call(b, nil); err != nil {
+		t.Fatalf("TestConsecutiveFailures: probe: got err == %s, want err == nil", err)
+	}
+	if got := b.State(); got != Closed {
+		t.Fatalf("TestConsecutiveFailures: after probe succeeded: got %v, want Closed", got)
+	}
+
+	want := []string{"Closed->Open", "Open->HalfOpen", "HalfOpen->Open", "Open->HalfOpen", "HalfOpen->Closed"}
+	if diff := pretty.Compare(want, changes); diff != "" {
+		t.Errorf("TestConsecutiveFailures: state changes -want/+got:\n%s", diff)
+	}
+}
+
+func TestFailureRate(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b, err := New(WithFailureRate(0.5, 4, 10*time.Second), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2 failures of 3 calls is below minRequests.
+	for _, err := range []error{errFail, nil, errFail} {
+		call(b, err)
+	}
+	if got := b.State(); got != Closed {
+		t.Fatalf("TestFailureRate: below minRequests: got %v, want Closed", got)
+	}
+
+	// The calls slide out of the window.
+	clock.advance(10 * time.Second)
+	for _, err := range []error{nil, nil, errFail} {
+		call(b, err)
+	}
+	if got := b.State(); got != Closed {
+		t.Fatalf("TestFailureRate: after window slid: got %v, want Closed", got)
+	}
+
+	call(b, errFail)
+	if got := b.State(); got != Open {
+		t.Fatalf("TestFailureRate: 2 of 4 calls failed: got %v, want Open", got)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b, err := New(WithConsecutiveFailures(1), WithProbes(2), WithOpenTimeout(time.Second), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A call allowed before the Breaker opened does not count after it changed state.
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	call(b, errFail)
+	clock.advance(time.Second)
+
+	done1, err := b.Allow()
+	if err != nil {
+		t.Fatalf("TestProbes: first probe: got err == %s, want err == nil", err)
+	}
+	done2, err := b.Allow()
+	if err != nil {
+		t.Fatalf("TestProbes: second probe: got err == %s, want err == nil", err)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("TestProbes: third probe: got err == %v, want ErrOpen", err)
+	}
+
+	stale(errFail)
+	done1(nil)
+	done1(errFail) // Only the first call to done counts.
+	if got := b.State(); got != HalfOpen {
+		t.Fatalf("TestProbes: after 1 of 2 probes: got %v, want HalfOpen", got)
+	}
+	done2(nil)
+	if got := b.State(); got != Closed {
+		t.Fatalf("TestProbes: after 2 of 2 probes: got %v, want Closed", got)
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := errors.New("not found")
+	b, err := New(WithConsecutiveFailures(1), WithIsFailure(func(err error) bool { return err != nil && !errors.Is(err, errNotFound) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call(b, errNotFound); err != errNotFound {
+		t.Errorf("TestIsFailure: got err == %v, want %v", err, errNotFound)
+	}
+	if got := b.State(); got != Closed {
+		t.Errorf("TestIsFailure: got %v, want Closed", got)
+	}
+
+	// By default, context.Canceled is not a failure.
+	b, _ = New(WithConsecutiveFailures(1))
+	call(b, context.Canceled)
+	if got := b.State(); got != Closed {
+		t.Errorf("TestIsFailure: context.Canceled: got %v, want Closed", got)
+	}
+}
+
+func TestExecutePanic(t *testing.T) {
+	t.Parallel()
+
+	b, _ := New(WithConsecutiveFailures(1))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("TestExecutePanic: panic was not re-raised")
+			}
+		}()
+		b.Execute(context.Background(), func(context.Context) error { panic("boom") })
+	}()
+	if got := b.State(); got != Open {
+		t.Errorf("TestExecutePanic: got %v, want Open", got)
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	b, _ := New()
+	got, err := Do(context.Background(), b, func(context.Context) (string, error) { return "quote", nil })
+	if err != nil || got != "quote" {
+		t.Errorf("TestDo: got %q, %v, want \"quote\", nil", got, err)
+	}
+}
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	b, _ := New(WithConsecutiveFailures(1), WithOpenTimeout(time.Minute), WithClock(clock))
+
+	if got := b.ErrTransformer(errFail); got != errFail {
+		t.Errorf("TestErrTransformer: other error: got %v, want %v", got, errFail)
+	}
+
+	call(b, errFail)
+	err := b.ErrTransformer(call(b, nil))
+	var ra exponential.ErrRetryAfter
+	if !errors.As(err, &ra) || !ra.Time.Equal(time.Unix(60, 0)) || !errors.Is(err, ErrOpen) {
+		t.Errorf("TestErrTransformer: got %v, want ErrRetryAfter at %v wrapping ErrOpen", err, time.Unix(60, 0))
+	}
+}
+
+func TestStateString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		state State
+		want  string
+	}{
+		{Closed, "Closed"},
+		{Open, "Open"},
+		{HalfOpen, "HalfOpen"},
+		{0, "Unknown"},
+	}
+
+	for _, test := range tests {
+		if got := test.state.String(); got != test.want {
+			t.Errorf("TestStateString(%d): got %q, want %q", test.state, got, test.want)
+		}
+	}
+}
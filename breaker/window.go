@@ -0,0 +1,60 @@
+package breaker
+
+import "time"
+
+// windowBuckets is the number of buckets a window is divided into.
+const windowBuckets = 10
+
+// bucket counts the calls in a slice of a window.
+type bucket struct {
+	// slot identifies the slice of time the bucket counts. Calls in other slots are not counted.
+	slot   int64
+	total  int
+	failed int
+}
+
+// window counts calls over a sliding window of time, divided into buckets. The window slides one
+// bucket at a time. A window is not safe for concurrent use.
+type window struct {
+	size    time.Duration
+	buckets [windowBuckets]bucket
+}
+
+func newWindow(size time.Duration) *window {
+	return &window{size: size / windowBuckets}
+}
+
+// slot returns the slot of t.
+func (w *window) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(w.size)
+}
+
+// add records a call at now.
+func (w *window) add(now time.Time, failed bool) {
+	slot := w.slot(now)
+	b := &w.buckets[slot%windowBuckets]
+	if b.slot != slot {
+		*b = bucket{slot: slot}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+}
+
+// counts returns the number of calls and failed calls in the window ending at now.
+func (w *window) counts(now time.Time) (total, failed int) {
+	slot := w.slot(now)
+	for _, b := range w.buckets {
+		if b.slot > slot-windowBuckets && b.slot <= slot {
+			total += b.total
+			failed += b.failed
+		}
+	}
+	return total, failed
+}
+
+// reset removes all counts.
+func (w *window) reset() {
+	w.buckets = [windowBuckets]bucket{}
+}
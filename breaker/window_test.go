@@ -0,0 +1,46 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(100, 0)
+	w := newWindow(10 * time.Second)
+
+	w.add(start, true)
+	w.add(start.Add(5*time.Second), false)
+	w.add(start.Add(9*time.Second), true)
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantTotal  int
+		wantFailed int
+	}{
+		{name: "All in window", at: start.Add(9 * time.Second), wantTotal: 3, wantFailed: 2},
+		{name: "First slid out", at: start.Add(10 * time.Second), wantTotal: 2, wantFailed: 1},
+		{name: "All slid out", at: start.Add(time.Minute)},
+	}
+
+	for _, test := range tests {
+		total, failed := w.counts(test.at)
+		if total != test.wantTotal || failed != test.wantFailed {
+			t.Errorf("TestWindow(%s): got %d total, %d failed, want %d, %d", test.name, total, failed, test.wantTotal, test.wantFailed)
+		}
+	}
+
+	// A bucket reused for a later slot starts empty.
+	w.add(start.Add(10*time.Second), false)
+	if total, failed := w.counts(start.Add(10 * time.Second)); total != 3 || failed != 1 {
+		t.Errorf("TestWindow(reused bucket): got %d total, %d failed, want 3, 1", total, failed)
+	}
+
+	w.reset()
+	if total, _ := w.counts(start.Add(10 * time.Second)); total != 0 {
+		t.Errorf("TestWindow(reset): got %d total, want 0", total)
+	}
+}
@@ -0,0 +1,164 @@
+package exponential
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Class is the classification of an error by a Classifier.
+type Class uint8
+
+const (
+	// ClassUnknown is the zero value and is not valid in a Rule.
+	ClassUnknown Class = 0
+	// ClassRetriable indicates the error should be retried. No later Rule is checked.
+	ClassRetriable Class = 1
+	// ClassPermanent indicates the error should not be retried. The error is wrapped with ErrPermanent.
+	ClassPermanent Class = 2
+	// ClassDelay indicates the error should be retried, but not before Rule.Delay has passed.
+	// The error is wrapped with ErrRetryAfter.
+	ClassDelay Class = 3
+)
+
+// String implements fmt.Stringer.
+func (c Class) String() string {
+	switch c {
+	case ClassRetriable:
+		return "Retriable"
+	case ClassPermanent:
+		return "Permanent"
+	case ClassDelay:
+		return "Delay"
+	}
+	return "Unknown"
+}
+
+// Matcher reports if an error matches a Rule.
+type Matcher func(err error) bool
+
+// MatchIs returns a Matcher that matches errors where errors.Is(err, target) is true.
+// This is used to match sentinel errors.
+func MatchIs(target error) Matcher {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
+
+// MatchAs returns a Matcher that matches errors where errors.As() finds an error of type T.
+func MatchAs[T error]() Matcher {
+	return func(err error) bool {
+		var t T
+		return errors.As(err, &t)
+	}
+}
+
+// MatchFunc returns a Matcher that matches errors where f returns true. This is used for anything
+// MatchIs() and MatchAs() cannot do, such as checking a field or the error text.
+func MatchFunc(f func(err error) bool) Matcher {
+	return Matcher(f)
+}
+
+// Rule classifies errors that match.
+type Rule struct {
+	// Name is an optional name for the Rule. This is useful in tests and logging to see which Rule matched.
+	Name string
+	// Match determines if the Rule applies to an error. Required.
+	Match Matcher
+	// Class is what the matched error is classified as. Required.
+	Class Class
+	// Delay is the minimum time to wait before the next attempt. Only valid and required if Class is ClassDelay.
+	Delay time.Duration
+}
+
+// validate validates the Rule.
+func (r Rule) validate() error {
+	if r.Match == nil {
+		return errors.New("Rule.Match must not be nil")
+	}
+	switch r.Class {
+	case ClassRetriable, ClassPermanent:
+		if r.Delay != 0 {
+			return fmt.Errorf("Rule.Delay can only be set if Rule.Class is ClassDelay, was %v", r.Class)
+		}
+	case ClassDelay:
+		if r.Delay <= 0 {
+			return errors.New("Rule.Delay must be greater than 0 when Rule.Class is ClassDelay")
+		}
+	default:
+		return fmt.Errorf("Rule.Class(%d) is not valid", r.Class)
+	}
+	return nil
+}
+
+// Classifier classifies errors with a list of Rules and provides an ErrTransformer based on them.
+// This allows a central list of rules for what errors are retried instead of checks spread across
+// multiple ErrTransformers. Rules are checked in the order they were provided and the first Rule
+// that matches is used. Errors that do not match any Rule are returned unchanged, which means they
+// will be retried. Create with NewClassifier(). A Classifier is safe for concurrent use.
+type Classifier struct {
+	rules []Rule
+}
+
+// NewClassifier creates a new Classifier from the Rules. Rules are checked in the order provided.
+func NewClassifier(rules ...Rule) (*Classifier, error) {
+	for i, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d(%s): %w", i, r.Name, err)
+		}
+	}
+
+	c := &Classifier{rules: make([]Rule, len(rules))}
+	copy(c.rules, rules)
+	return c, nil
+}
+
+// Classify returns the first Rule that matches err. If no Rule matches, this returns false.
+func (c *Classifier) Classify(err error) (Rule, bool) {
+	if err == nil {
+		return Rule{}, false
+	}
+	for _, r := range c.rules {
+		if r.Match(err) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// ErrTransformer implements ErrTransformer. Errors matching a ClassPermanent Rule are wrapped with
+// ErrPermanent, errors matching a ClassDelay Rule are wrapped with ErrRetryAfter and all others are
+// returned unchanged. The time of an ErrRetryAfter is from time.Now(), so a Clock set with WithClock()
+// is ignored. Use WithClassifier() to use the Clock of the Backoff.
+func (c *Classifier) ErrTransformer(err error) error {
+	return c.transform(err, time.Now)
+}
+
+// WithClassifier adds c.ErrTransformer() to the ErrTransformers of the Backoff, with the time of an
+// ErrRetryAfter for a ClassDelay Rule taken from the Clock of the Backoff. This is the same as
+// WithErrTransformer(c.ErrTransformer) unless WithClock() is used.
+func WithClassifier(c *Classifier) Option {
+	return func(b *Backoff) error {
+		if c == nil {
+			return errors.New("WithClassifier() cannot be passed a nil *Classifier")
+		}
+		b.transformers = append(b.transformers, func(err error) error { return c.transform(err, b.now) })
+		return nil
+	}
+}
+
+// transform implements ErrTransformer(). now returns the time an ErrRetryAfter is relative to.
+func (c *Classifier) transform(err error, now func() time.Time) error {
+	r, ok := c.Classify(err)
+	if !ok {
+		return err
+	}
+
+	switch r.Class {
+	case ClassPermanent:
+		return fmt.Errorf("%w: %w", err, ErrPermanent)
+	case ClassDelay:
+		return ErrRetryAfter{Time: now().Add(r.Delay), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package exponential
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewClassifier(t *testing.T) {
+	t.Parallel()
+
+	match := MatchIs(errors.New("error"))
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "Valid retriable", rule: Rule{Match: match, Class: ClassRetriable}},
+		{name: "Valid permanent", rule: Rule{Match: match, Class: ClassPermanent}},
+		{name: "Valid delay", rule: Rule{Match: match, Class: ClassDelay, Delay: time.Second}},
+		{name: "nil Match", rule: Rule{Class: ClassPermanent}, wantErr: true},
+		{name: "ClassUnknown", rule: Rule{Match: match}, wantErr: true},
+		{name: "ClassDelay without Delay", rule: Rule{Match: match, Class: ClassDelay}, wantErr: true},
+		{name: "Delay without ClassDelay", rule: Rule{Match: match, Class: ClassPermanent, Delay: time.Second}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		_, err := NewClassifier(test.rule)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNewClassifier(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNewClassifier(%s): got err == %s, want err == nil", test.name, err)
+		}
+	}
+}
+
+func TestClassifier(t *testing.T) {
+	t.Parallel()
+
+	errNotFound := errors.New("not found")
+	errThrottled := errors.New("throttled")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c, err := NewClassifier(
+		Rule{Name: "notFound", Match: MatchIs(errNotFound), Class: ClassPermanent},
+		Rule{Name: "throttled", Match: MatchIs(errThrottled), Class: ClassDelay, Delay: 5 * time.Second},
+		Rule{Name: "pathError", Match: MatchAs[*fs.PathError](), Class: ClassPermanent},
+		Rule{
+			Name:  "busy",
+			Match: MatchFunc(func(err error) bool { return strings.Contains(err.Error(), "busy") }),
+			Class: ClassRetriable,
+		},
+		// This never matches because "busy" comes first.
+		Rule{Name: "busyPermanent", Match: MatchFunc(func(err error) bool { return strings.Contains(err.Error(), "busy") }), Class: ClassPermanent},
+	)
+	if err != nil {
+		panic(err)
+	}
+	b, err := New(WithClock(&testClock{now: now}), WithClassifier(c))
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantRule  string
+		wantPerm  bool
+		wantAfter time.Time
+	}{
+		{name: "No match", err: errors.New("other")},
+		{name: "Sentinel", err: fmt.Errorf("get: %w", errNotFound), wantRule: "notFound", wantPerm: true},
+		{name: "Delay", err: errThrottled, wantRule: "throttled", wantAfter: now.Add(5 * time.Second)},
+		{name: "Type", err: fmt.Errorf("open: %w", &fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}), wantRule: "pathError", wantPerm: true},
+		{name: "Predicate first match wins", err: errors.New("server busy"), wantRule: "busy"},
+	}
+
+	for _, test := range tests {
+		r, ok := c.Classify(test.err)
+		if ok != (test.wantRule != "") || r.Name != test.wantRule {
+			t.Errorf("TestClassifier(%s): got rule %q(%v), want %q", test.name, r.Name, ok, test.wantRule)
+		}
+
+		got := b.applyTransformers(test.err)
+		if errors.Is(got, ErrPermanent) != test.wantPerm {
+			t.Errorf("TestClassifier(%s): got permanent == %v, want %v", test.name, errors.Is(got, ErrPermanent), test.wantPerm)
+		}
+		after := ErrRetryAfter{}
+		if errors.As(got, &after) {
+			if !after.Time.Equal(test.wantAfter) {
+				t.Errorf("TestClassifier(%s): got ErrRetryAfter.Time == %v, want %v", test.name, after.Time, test.wantAfter)
+			}
+		} else if !test.wantAfter.IsZero() {
+			t.Errorf("TestClassifier(%s): got no ErrRetryAfter, want one", test.name)
+		}
+		if !errors.Is(got, test.err) {
+			t.Errorf("TestClassifier(%s): returned error does not wrap the original error", test.name)
+		}
+	}
+
+	if c.ErrTransformer(nil) != nil {
+		t.Errorf("TestClassifier(nil): got err != nil")
+	}
+	start := time.Now()
+	after := ErrRetryAfter{}
+	if !errors.As(c.ErrTransformer(errThrottled), &after) || after.Time.Before(start.Add(5*time.Second)) {
+		t.Errorf("TestClassifier: ErrTransformer(): got ErrRetryAfter.Time == %v, want at least 5s from now", after.Time)
+	}
+	if _, err := New(WithClassifier(nil)); err == nil {
+		t.Errorf("TestClassifier: WithClassifier(nil): got err == nil, want err != nil")
+	}
+}
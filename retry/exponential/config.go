@@ -0,0 +1,163 @@
+package exponential
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tailscale/hujson"
+)
+
+// Duration is a time.Duration that can be decoded from a number of nanoseconds, such as 100000000,
+// or a string accepted by time.ParseDuration(), such as "100ms". It is used in PolicyConfig.
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return d.parse(s)
+	}
+
+	i, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return fmt.Errorf("duration must be a string such as \"100ms\" or an integer number of nanoseconds, got %s", b)
+	}
+	*d = Duration(i)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The Duration is encoded as a string such as "100ms".
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalYAML implements the Unmarshaler interface used by gopkg.in/yaml.v2, which is also
+// supported by gopkg.in/yaml.v3 and sigs.k8s.io/yaml.
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		return d.parse(s)
+	}
+	var i int64
+	if err := unmarshal(&i); err != nil {
+		return fmt.Errorf("duration must be a string such as \"100ms\" or an integer number of nanoseconds")
+	}
+	*d = Duration(i)
+	return nil
+}
+
+// parse parses s with time.ParseDuration() into d.
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// PolicyConfig is the shape of a Policy in a configuration file. It has json and yaml struct tags
+// so it can be embedded in a larger configuration struct and decoded by most encoding packages.
+// Fields that are not set use the value from the default policy. Use Policy() to convert it to a
+// validated Policy.
+type PolicyConfig struct {
+	// InitialInterval is Policy.InitialInterval.
+	InitialInterval *Duration `json:"initialInterval,omitempty" yaml:"initialInterval,omitempty"`
+	// Multiplier is Policy.Multiplier.
+	Multiplier *float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	// RandomizationFactor is Policy.RandomizationFactor.
+	RandomizationFactor *float64 `json:"randomizationFactor,omitempty" yaml:"randomizationFactor,omitempty"`
+	// MaxInterval is Policy.MaxInterval.
+	MaxInterval *Duration `json:"maxInterval,omitempty" yaml:"maxInterval,omitempty"`
+	// MinInterval is Policy.MinInterval.
+	MinInterval *Duration `json:"minInterval,omitempty" yaml:"minInterval,omitempty"`
+	// MaxCumulativeInterval is Policy.MaxCumulativeInterval.
+	MaxCumulativeInterval *Duration `json:"maxCumulativeInterval,omitempty" yaml:"maxCumulativeInterval,omitempty"`
+}
+
+// Policy converts the PolicyConfig to a Policy and validates it.
+func (c PolicyConfig) Policy() (Policy, error) {
+	p := defaults()
+	if c.InitialInterval != nil {
+		p.InitialInterval = time.Duration(*c.InitialInterval)
+	}
+	if c.Multiplier != nil {
+		p.Multiplier = *c.Multiplier
+	}
+	if c.RandomizationFactor != nil {
+		p.RandomizationFactor = *c.RandomizationFactor
+	}
+	if c.MaxInterval != nil {
+		p.MaxInterval = time.Duration(*c.MaxInterval)
+	}
+	if c.MinInterval != nil {
+		p.MinInterval = time.Duration(*c.MinInterval)
+	}
+	if c.MaxCumulativeInterval != nil {
+		p.MaxCumulativeInterval = time.Duration(*c.MaxCumulativeInterval)
+	}
+
+	if err := p.validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// PolicyFromJSON decodes a JSON encoded PolicyConfig and returns the validated Policy. Field names
+// are matched case insensitively, so both "initialInterval" and "InitialInterval" work. Unknown
+// fields are an error. Errors name the field that caused them.
+func PolicyFromJSON(b []byte) (Policy, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return Policy{}, fmt.Errorf("could not decode Policy: %w", err)
+	}
+
+	var c PolicyConfig
+	for k, v := range fields {
+		var err error
+		switch strings.ToLower(k) {
+		case "initialinterval":
+			c.InitialInterval = new(Duration)
+			err = json.Unmarshal(v, c.InitialInterval)
+		case "multiplier":
+			c.Multiplier = new(float64)
+			err = json.Unmarshal(v, c.Multiplier)
+		case "randomizationfactor":
+			c.RandomizationFactor = new(float64)
+			err = json.Unmarshal(v, c.RandomizationFactor)
+		case "maxinterval":
+			c.MaxInterval = new(Duration)
+			err = json.Unmarshal(v, c.MaxInterval)
+		case "mininterval":
+			c.MinInterval = new(Duration)
+			err = json.Unmarshal(v, c.MinInterval)
+		case "maxcumulativeinterval":
+			c.MaxCumulativeInterval = new(Duration)
+			err = json.Unmarshal(v, c.MaxCumulativeInterval)
+		default:
+			return Policy{}, fmt.Errorf("Policy.%s: unknown field", k)
+		}
+		if err != nil {
+			return Policy{}, fmt.Errorf("Policy.%s: %w", k, err)
+		}
+	}
+	return c.Policy()
+}
+
+// PolicyFromHuJSON decodes a HuJSON (JSON with comments and trailing commas) encoded PolicyConfig
+// and returns the validated Policy. It follows the same rules as PolicyFromJSON().
+func PolicyFromHuJSON(b []byte) (Policy, error) {
+	std, err := hujson.Standardize(b)
+	if err != nil {
+		return Policy{}, fmt.Errorf("could not standardize HuJSON: %w", err)
+	}
+	return PolicyFromJSON(std)
+}
@@ -0,0 +1,141 @@
+package exponential
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestPolicyFromJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      string
+		huJSON    bool
+		want      Policy
+		wantField string
+		wantErr   bool
+	}{
+		{
+			name: "Empty object uses defaults",
+			data: `{}`,
+			want: defaults(),
+		},
+		{
+			name: "Duration strings",
+			data: `{"initialInterval": "1s", "multiplier": 3, "randomizationFactor": 0, "maxInterval": "1m"}`,
+			want: Policy{InitialInterval: time.Second, Multiplier: 3, RandomizationFactor: 0, MaxInterval: time.Minute},
+		},
+		{
+			name: "Go field names and nanoseconds",
+			data: `{"InitialInterval": 1000000000, "MaxInterval": 60000000000}`,
+			want: Policy{InitialInterval: time.Second, Multiplier: 2, RandomizationFactor: 0.5, MaxInterval: time.Minute},
+		},
+		{
+			name:   "HuJSON with comments",
+			huJSON: true,
+			data: `
+// A comment
+{
+	"initialInterval": "200ms", // trailing comment
+	"maxInterval": "10s",
+}`,
+			want: Policy{InitialInterval: 200 * time.Millisecond, Multiplier: 2, RandomizationFactor: 0.5, MaxInterval: 10 * time.Second},
+		},
+		{
+			name:      "Bad duration",
+			data:      `{"initialInterval": "soon"}`,
+			wantField: "Policy.initialInterval",
+			wantErr:   true,
+		},
+		{
+			name:      "Bad type",
+			data:      `{"multiplier": "two"}`,
+			wantField: "Policy.multiplier",
+			wantErr:   true,
+		},
+		{
+			name:      "Unknown field",
+			data:      `{"maxAttempts": 3}`,
+			wantField: "Policy.maxAttempts",
+			wantErr:   true,
+		},
+		{
+			name:      "Fails validation",
+			data:      `{"multiplier": 0.5}`,
+			wantField: "Policy.Multiplier",
+			wantErr:   true,
+		},
+		{
+			name:    "Not JSON",
+			data:    `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		var got Policy
+		var err error
+		if test.huJSON {
+			got, err = PolicyFromHuJSON([]byte(test.data))
+		} else {
+			got, err = PolicyFromJSON([]byte(test.data))
+		}
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestPolicyFromJSON(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestPolicyFromJSON(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			if !strings.Contains(err.Error(), test.wantField) {
+				t.Errorf("TestPolicyFromJSON(%s): got err == %s, want it to name %s", test.name, err, test.wantField)
+			}
+			continue
+		}
+
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestPolicyFromJSON(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestDurationYAML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   any
+		want    Duration
+		wantErr bool
+	}{
+		{name: "String", value: "1m30s", want: Duration(90 * time.Second)},
+		{name: "Integer", value: int64(100), want: Duration(100)},
+		{name: "Bad string", value: "later", wantErr: true},
+		{name: "Bad type", value: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		// unmarshal mimics what a YAML package does by converting the decoded value into v.
+		unmarshal := func(v any) error {
+			b, _ := json.Marshal(test.value)
+			return json.Unmarshal(b, v)
+		}
+
+		var d Duration
+		err := d.UnmarshalYAML(unmarshal)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestDurationYAML(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestDurationYAML(%s): got err == %s, want err == nil", test.name, err)
+		case err == nil && d != test.want:
+			t.Errorf("TestDurationYAML(%s): got %v, want %v", test.name, time.Duration(d), time.Duration(test.want))
+		}
+	}
+}
@@ -9,7 +9,9 @@ via a Context timeout. Note that the Context timeout is some point in the future
 will not be retried. But setting 30 * seconds does not mean that the Retry() will return after 30 seconds.
 It means that after Retry() is called, no attempt will be made after 30 seconds from that point. If the first
 call takes 30 seconds and then fails, no retries will happen. If the first call takes 29 seconds and then fails,
-the second call may or may not happen depending on policy settings.
+the second call may or may not happen depending on policy settings. If you want to cap the total time spent
+waiting between attempts regardless of the Context, set Policy.MaxCumulativeInterval. When that budget would be
+exceeded, Retry() returns an error wrapping ErrRetryExhausted.
 
 And error returned will be the last error returned by the Op.
 It will not be a context.Canceled or context.DeadlineExceeded error if the retry timer was cancelled. However it may still yield
@@ -18,7 +20,7 @@ Error.IsCancelled() tells you if the last error returned by the Op was a context
 
 To understand the consequences of using any specific policy, we provide a tool to generate a time table
 for a given policy. This can be used to understand the consequences of a policy.
-It is located in the timetable sub-package. Here is sample output giving the progression to the
+It is located in the timetable/cmd/timetable directory and the logic behind it is in the timetable sub-package. Here is sample output giving the progression to the
 maximum interval for a policy with the default settings:
 
 	Generating TimeTable for -1 attempts and the following settings:
@@ -210,5 +212,21 @@ Example: Test a function around a gRPC call that fails on certain status codes u
 		return err
 	})
 	cancel() // Always cancel the context when done to avoid lingering goroutines.
+
+Example: Combine a custom business error transformer with the gRPC transformer
+
+	backoff, _ := exponential.New(
+		exponential.WithErrTransformer(
+			exponential.ChainTransformers(
+				func(err error) error {
+					if errors.Is(err, ErrAccountClosed) {
+						return fmt.Errorf("%w: %w", err, exponential.ErrPermanent)
+					}
+					return err
+				},
+				grpcTransform.ErrTransformer, // Not called if the account was closed.
+			),
+		),
+	)
 */
 package exponential
@@ -18,6 +18,11 @@ var (
 	// wrapped in another error. You can determine if you have a permanent error with
 	// Is(err, ErrPermanent).
 	ErrPermanent = errspkg.ErrPermanent // This is a type alias.
+
+	// ErrRetryExhausted is an error that is returned when waiting for another attempt would exceed
+	// Policy.MaxCumulativeInterval. It wraps the last error from the Op and can be detected with
+	// Is(err, ErrRetryExhausted).
+	ErrRetryExhausted = errspkg.ErrRetryExhausted // This is a type alias.
 )
 
 // ErrRetryAfter can be used to wrap an error to indicate that the error can be retried after a certain time.
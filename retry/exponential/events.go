@@ -0,0 +1,77 @@
+package exponential
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventType is the type of Event emitted on the channel passed to WithEvents().
+type EventType uint8
+
+const (
+	// ETUnknown indicates the EventType was not set. This indicates a bug.
+	ETUnknown EventType = 0
+	// ETAttemptStart is emitted just before the Op is called.
+	ETAttemptStart EventType = 1
+	// ETAttemptEnd is emitted just after the Op returns. Event.Err holds the error the Op returned.
+	ETAttemptEnd EventType = 2
+	// ETSleeping is emitted before Retry() waits for the next attempt. Event.Interval holds
+	// how long we will wait.
+	ETSleeping EventType = 3
+	// ETFinished is emitted when Retry() is about to return. Event.Err holds the error that
+	// Retry() will return.
+	ETFinished EventType = 4
+)
+
+// String implements fmt.Stringer.
+func (e EventType) String() string {
+	switch e {
+	case ETAttemptStart:
+		return "AttemptStart"
+	case ETAttemptEnd:
+		return "AttemptEnd"
+	case ETSleeping:
+		return "Sleeping"
+	case ETFinished:
+		return "Finished"
+	}
+	return fmt.Sprintf("EventType(%d)", uint8(e))
+}
+
+// Event is a lifecycle event for a Retry() call. These are emitted on the channel passed to WithEvents().
+type Event struct {
+	// Type is the type of event.
+	Type EventType
+	// Time is when the event occurred.
+	Time time.Time
+	// Record is the Record at the time of the event.
+	Record Record
+	// Interval is the interval we are going to wait before the next attempt. Only set for ETSleeping.
+	Interval time.Duration
+	// Err is the error returned by the Op for ETAttemptEnd or the error returned by Retry() for ETFinished.
+	Err error
+}
+
+// WithEvents sets a channel that receives lifecycle Events for every Retry() call made with the Backoff.
+// This allows monitoring goroutines or UIs to observe long running retry loops. Sends are non-blocking,
+// so if the channel is full the Event is dropped instead of delaying the retry. Use a buffered channel
+// sized for your needs. The channel is never closed by the Backoff.
+func WithEvents(ch chan<- Event) Option {
+	return func(b *Backoff) error {
+		b.events = ch
+		return nil
+	}
+}
+
+// emit sends an Event on the events channel if one was set with WithEvents(). If the channel
+// is full, the Event is dropped.
+func (b *Backoff) emit(t EventType, r Record, interval time.Duration, err error) {
+	if b.events == nil {
+		return
+	}
+
+	select {
+	case b.events <- Event{Type: t, Time: b.now(), Record: r, Interval: interval, Err: err}:
+	default:
+	}
+}
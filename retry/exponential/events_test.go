@@ -0,0 +1,74 @@
+package exponential
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan Event, 100)
+	b, err := New(WithTesting(), WithEvents(ch))
+	if err != nil {
+		panic(err)
+	}
+
+	f := NewRetryTester(Failures{numFailures: 2})
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		_, err := f.Run(ctx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("TestEvents: got err == %s, want err == nil", err)
+	}
+	close(ch)
+
+	want := []EventType{
+		ETAttemptStart, ETAttemptEnd, ETSleeping,
+		ETAttemptStart, ETAttemptEnd, ETSleeping,
+		ETAttemptStart, ETAttemptEnd, ETFinished,
+	}
+	got := []Event{}
+	for e := range ch {
+		got = append(got, e)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("TestEvents: got %d events, want %d", len(got), len(want))
+	}
+	for i, e := range got {
+		if e.Type != want[i] {
+			t.Errorf("TestEvents: event %d: got %v, want %v", i, e.Type, want[i])
+		}
+	}
+	if got[1].Err == nil {
+		t.Errorf("TestEvents: first AttemptEnd event should have an error")
+	}
+	if got[2].Interval <= 0 {
+		t.Errorf("TestEvents: Sleeping event should have an Interval > 0")
+	}
+	if got[len(got)-1].Record.Attempt != 3 {
+		t.Errorf("TestEvents: Finished event: got Attempt %d, want 3", got[len(got)-1].Record.Attempt)
+	}
+}
+
+func TestEventsDoNotBlock(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan Event) // Unbuffered and never read.
+	b, err := New(WithTesting(), WithEvents(ch))
+	if err != nil {
+		panic(err)
+	}
+
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		if r.Attempt == 3 {
+			return ErrPermanent
+		}
+		return errors.New("error")
+	})
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("TestEventsDoNotBlock: got err == %v, want ErrPermanent", err)
+	}
+}
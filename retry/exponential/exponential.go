@@ -4,47 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math/rand"
+	"math/rand/v2"
 	"sync"
 	"testing"
 	"time"
 )
 
-// timer is a type that wraps a channel that will receive a time.Time when the timer is done.
-// This is used to allow internal testing of the package.
-type timer struct {
-	// C is the channel that will receive a time.Time when the timer is done.
-	// When faking, "c" contains the channel without the <- so that we can feed it.
-	C <-chan time.Time
-	// c only exists if this is faking and is the same channel as C except without the <-.
-	c chan time.Time
-	// when is the time the timer is set to go off.
-	when time.Time
-	// timer is used when not faking and is the real time.Timer.
+// Timer is a timer created by a Clock. It provides the same functionality as a time.Timer.
+type Timer interface {
+	// C returns the channel that will receive the current time when the Timer fires.
+	C() <-chan time.Time
+	// Stop prevents the Timer from firing. It has the same semantics as time.Timer.Stop().
+	Stop() bool
+	// Reset changes the Timer to fire after d. It has the same semantics as time.Timer.Reset().
+	// It is only called on a Timer that has fired and had its channel drained.
+	Reset(d time.Duration) bool
+}
+
+// realTimer is a Timer backed by a time.Timer.
+type realTimer struct {
 	timer *time.Timer
-	// mu protects everything below.
-	mu sync.Mutex
-	// stopped is true if Stop() has been called. Only valid if faking.
-	stopped bool
-}
-
-// Stop implements time.Timer.Stop().
-func (t *timer) Stop() bool {
-	if t.timer == nil {
-		t.mu.Lock()
-		defer t.mu.Unlock()
-		t.stopped = true
-		return true
-	}
+}
+
+// C implements Timer.C().
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
 
+// Stop implements Timer.Stop().
+func (t realTimer) Stop() bool {
 	return t.timer.Stop()
 }
 
-// clock provides access to the various time functions we need.
-// This allows internal testing of the package.
-type clock interface {
+// Reset implements Timer.Reset().
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+// Clock provides access to the time functions used by a Backoff. The default uses the time package.
+// Providing a fake Clock with WithClock() lets tests of code that uses Retry() control time instead
+// of waiting on real timers.
+type Clock interface {
+	// Now returns the current time.
 	Now() time.Time
-	NewTimer(d time.Duration) *timer
+	// NewTimer returns a new Timer that fires after d.
+	NewTimer(d time.Duration) Timer
+	// Until returns the duration until t.
 	Until(t time.Time) time.Duration
 }
 
@@ -55,15 +60,29 @@ type clock interface {
 type Backoff struct {
 	// policy is the backoff policy to use.
 	policy Policy
+	// provider provides the policy at the start of each Retry() call. Set with WithPolicyProvider().
+	provider PolicyProvider
 	// useTest is true if we are using the test options. Set with WithTesting().
 	useTest bool
+	// testOpts are the options passed to WithTesting().
+	testOpts testOptions
+	// rng is the random source used to randomize intervals. Set with WithRandSource() or the WithSeed()
+	// TestOption. If nil, the math/rand/v2 package functions are used.
+	rng *lockedRand
 	// transformers is a list of error transformers to apply to the error before determining
 	// if we should retry.
 	transformers []ErrTransformer
-
-	// clock is used to allow internal testing of the package.
-	// If not set, uses the time package.
-	clock clock
+	// events is a channel that receives lifecycle events. Set with WithEvents().
+	events chan<- Event
+	// name is the name the Backoff is registered under. Set with WithName().
+	name string
+	// registry is the Registry the Backoff is registered in. Set with WithRegistry().
+	registry *Registry
+	// stats holds the statistics for the Backoff. Only set if the Backoff is registered.
+	stats *stats
+
+	// clock is the Clock to use. Set with WithClock(). If not set, uses the time package.
+	clock Clock
 }
 
 // Options are used to configure the backoff policy.
@@ -77,15 +96,60 @@ func WithPolicy(policy Policy) Option {
 	}
 }
 
-// testOptions is a placeholder for future test options.
-type testOptions struct{}
+// WithClock sets the Clock used for all time functions, such as waiting between attempts and checking the
+// Context deadline. This is used in tests to inject a fake Clock. If not specified, the time package is used.
+func WithClock(c Clock) Option {
+	return func(b *Backoff) error {
+		if c == nil {
+			return errors.New("WithClock() cannot be passed a nil Clock")
+		}
+		b.clock = c
+		return nil
+	}
+}
+
+// testOptions are options for WithTesting().
+type testOptions struct {
+	// intervals is a scripted list of intervals. Set with WithIntervals().
+	intervals []time.Duration
+	// rng is a random source created from a fixed seed. Set with WithSeed().
+	rng *lockedRand
+}
 
 // TestOption is an option for WithTesting(). Functions that implement TestOption
-// provide options for tests. This is a placeholder for future test options
-// and is not used at this time.
+// provide options for tests.
 type TestOption func(t *testOptions) error
 
-// WithTesting invokes the backoff policy with no actual delay.
+// WithIntervals scripts the intervals waited between attempts. The first interval is used after the first
+// attempt, the second after the second attempt and so on. Once all intervals have been used, the last one
+// is used for all remaining attempts. The Policy is not used to calculate intervals, but
+// Policy.MaxCumulativeInterval still applies and an ErrRetryAfter in an error can still make an interval longer.
+func WithIntervals(intervals ...time.Duration) TestOption {
+	return func(t *testOptions) error {
+		if len(intervals) == 0 {
+			return errors.New("WithIntervals() must be passed at least one interval")
+		}
+		for i, d := range intervals {
+			if d < 0 {
+				return fmt.Errorf("WithIntervals() interval %d(%v) must be >= 0", i, d)
+			}
+		}
+		t.intervals = intervals
+		return nil
+	}
+}
+
+// WithSeed sets the seed of the random source used to randomize intervals. This makes the intervals
+// calculated from a Policy the same on every run.
+func WithSeed(seed int64) TestOption {
+	return func(t *testOptions) error {
+		t.rng = newLockedRand(rand.NewPCG(uint64(seed), 0)) // #nosec
+		return nil
+	}
+}
+
+// WithTesting invokes the backoff policy with no actual delay. TestOptions can be passed to make the
+// intervals reported in the Record deterministic.
 // Cannot be used outside of a test or this will panic.
 func WithTesting(options ...TestOption) Option {
 	if !testing.Testing() {
@@ -94,10 +158,50 @@ func WithTesting(options ...TestOption) Option {
 
 	return func(b *Backoff) error {
 		b.useTest = true
+		for _, o := range options {
+			if err := o(&b.testOpts); err != nil {
+				return err
+			}
+		}
+		if b.testOpts.rng != nil {
+			b.rng = b.testOpts.rng
+		}
 		return nil
 	}
 }
 
+// WithRandSource sets the random source used to randomize intervals for this Backoff. This is useful
+// for reproducible intervals, such as with rand.NewPCG() and a fixed seed. Access to the source is
+// serialized with a lock, so it is safe to use with concurrent Retry() calls.
+// If not specified, the math/rand/v2 package functions are used, which do not share a lock between goroutines.
+func WithRandSource(src rand.Source) Option {
+	return func(b *Backoff) error {
+		if src == nil {
+			return errors.New("WithRandSource() cannot be passed a nil rand.Source")
+		}
+		b.rng = newLockedRand(src)
+		return nil
+	}
+}
+
+// lockedRand is a *rand.Rand that is safe for concurrent use.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// newLockedRand creates a new lockedRand from src.
+func newLockedRand(src rand.Source) *lockedRand {
+	return &lockedRand{rand: rand.New(src)} // #nosec
+}
+
+// Int64N implements rand.Int64N().
+func (l *lockedRand) Int64N(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rand.Int64N(n)
+}
+
 // ErrTransformer is a function that can be used to transform an error before it is returned.
 // The typical case is to make an error a permanent error based on some criteria in order to
 // stop retries. The other use is to use errors.ErrRetryAfter as a wrapper to specify the minimum
@@ -105,16 +209,51 @@ func WithTesting(options ...TestOption) Option {
 // retry logic in one place for reuse instead of in the Op.
 type ErrTransformer func(err error) error
 
-// WithErrTransformer sets the error transformers to use. If not specified, then no transformers are used.
+// WithErrTransformer adds error transformers to use. If not specified, then no transformers are used.
 // Passing multiple transformers will apply them in order. If WithErrTransformer is passed multiple times,
-// only the final transformers are used (aka don't do that).
+// the transformers are appended in the order the options were passed. The transformers are applied
+// with the same rules as ChainTransformers().
 func WithErrTransformer(transformers ...ErrTransformer) Option {
 	return func(b *Backoff) error {
-		b.transformers = transformers
+		for _, t := range transformers {
+			if t == nil {
+				return errors.New("WithErrTransformer() cannot be passed a nil ErrTransformer")
+			}
+		}
+		b.transformers = append(b.transformers, transformers...)
 		return nil
 	}
 }
 
+// ChainTransformers returns an ErrTransformer that applies the transformers in the order passed, with
+// each receiving the error returned by the one before it. The chain stops as soon as a transformer
+// returns an error wrapping ErrPermanent or returns nil, so later transformers never see an error
+// an earlier one has decided is permanent. Put the most specific transformers, such as ones for
+// your business errors, first and general ones, such as the gRPC helper, last.
+// nil transformers are skipped.
+func ChainTransformers(transformers ...ErrTransformer) ErrTransformer {
+	chain := make([]ErrTransformer, 0, len(transformers))
+	for _, t := range transformers {
+		if t != nil {
+			chain = append(chain, t)
+		}
+	}
+	return func(err error) error {
+		return applyChain(chain, err)
+	}
+}
+
+// applyChain applies the transformers to err using the rules defined in ChainTransformers().
+func applyChain(transformers []ErrTransformer, err error) error {
+	for _, t := range transformers {
+		if err == nil || errors.Is(err, ErrPermanent) {
+			return err
+		}
+		err = t(err)
+	}
+	return err
+}
+
 // New creates a new Backoff instance with the given options.
 func New(options ...Option) (*Backoff, error) {
 	b := &Backoff{
@@ -129,6 +268,14 @@ func New(options ...Option) (*Backoff, error) {
 	if err := b.policy.validate(); err != nil {
 		return nil, err
 	}
+	if b.provider != nil {
+		if err := b.provider.Policy().validate(); err != nil {
+			return nil, fmt.Errorf("PolicyProvider returned an invalid Policy: %w", err)
+		}
+	}
+	if err := b.register(); err != nil {
+		return nil, err
+	}
 
 	return b, nil
 }
@@ -141,13 +288,26 @@ type Record struct {
 	LastInterval time.Duration
 	// TotalInterval is the total amount of time spent in intervals between attempts.
 	TotalInterval time.Duration
+	// NextInterval is the interval that will be waited before the next attempt if this attempt fails.
+	// This can be used to tell a user or caller when the next attempt will be made. The actual wait may be
+	// longer if the Op returns an ErrRetryAfter with a later time or shorter if the Context expires.
+	NextInterval time.Duration
+	// StartTime is when Retry() was called.
+	StartTime time.Time
+	// LastAttemptStart is when the last attempt that has returned started. Inside an Op, this is the start of the
+	// prior attempt and is the zero value on the first attempt. Like Err, this is useful to correlate attempts
+	// against external logs and traces.
+	LastAttemptStart time.Time
+	// LastAttemptDuration is how long the last attempt that has returned took. Inside an Op, this is the duration
+	// of the prior attempt and is 0 on the first attempt.
+	LastAttemptDuration time.Duration
 	// Err is the last error returned by an operation. It is important to remember that this is
 	// the last error returned by the prior invocation of the Op and should only be used for logging
 	// purposes.
 	Err error
 }
 
-// now returns the current time. This is used to allow internal testing of the package.
+// now returns the current time from the Clock.
 // We do this instead of using clock directly to avoid dynamic dispatch.
 func (b *Backoff) now() time.Time {
 	if b.clock == nil {
@@ -156,7 +316,13 @@ func (b *Backoff) now() time.Time {
 	return b.clock.Now()
 }
 
-// until returns the time until the given time. This is used to allow internal testing of the package.
+// Now returns the current time from the Clock set with WithClock(). If no Clock was set, this is time.Now().
+// This lets code that creates an ErrRetryAfter use the same time as the Backoff.
+func (b *Backoff) Now() time.Time {
+	return b.now()
+}
+
+// until returns the time until the given time from the Clock.
 // We do this instead of using clock directly to avoid dynamic dispatch.
 func (b *Backoff) until(t time.Time) time.Duration {
 	if b.clock == nil {
@@ -166,19 +332,30 @@ func (b *Backoff) until(t time.Time) time.Duration {
 
 }
 
-// newTimer creates a new timer. This is used to allow internal testing of the package.
+// newTimer creates a new timer from the Clock.
 // We do this instead of using clock directly to avoid dynamic dispatch.
-func (b *Backoff) newTimer(d time.Duration) *timer {
+func (b *Backoff) newTimer(d time.Duration) Timer {
 	if b.clock == nil {
-		t := time.NewTimer(d)
-		return &timer{C: t.C, timer: t}
+		return realTimer{timer: time.NewTimer(d)}
 	}
 	return b.clock.NewTimer(d)
 }
 
-// Op is a function that can be retried.
+// Op is a function that can be retried. The Context passed to the Op holds the Record, which can be
+// retrieved with RecordFromContext().
 type Op func(context.Context, Record) error
 
+// recordKey is the Context key for the Record passed to an Op.
+type recordKey struct{}
+
+// RecordFromContext returns the Record stored in the Context passed to an Op. This allows code called
+// by the Op, such as middleware or logging, to discover it is executing inside a Retry() and on which attempt.
+// If the Context did not come from Retry(), this returns false.
+func RecordFromContext(ctx context.Context) (Record, bool) {
+	r, ok := ctx.Value(recordKey{}).(Record)
+	return r, ok
+}
+
 // RetryOption is an option for the Retry method. Functions that implement RetryOption
 // provide an override on a single call.
 type RetryOption func(o *retryOptions) error
@@ -190,45 +367,63 @@ type retryOptions struct{}
 // Retry will retry the given operation until it succeeds, the context is cancelled or an error
 // is returned with PermanentErr(). This is safe to call concurrently.
 func (b *Backoff) Retry(ctx context.Context, op Op, options ...RetryOption) error {
-	r := Record{Attempt: 1}
+	b.stats.start()
+
+	policy := b.currentPolicy()
+
+	baseInterval := policy.InitialInterval
+	r := Record{Attempt: 1, StartTime: b.now(), NextInterval: b.interval(policy, 1, baseInterval)}
 
 	// Make our first attempt.
-	err := op(ctx, r)
+	err := b.attempt(ctx, op, &r)
 	if err == nil {
-		return nil
+		return b.finish(r, nil)
 	}
 
 	// Well, that didn't work, so let's start our retry work.
 	r.Err = err
-	baseInterval := b.policy.InitialInterval
-	realInterval := b.randomize(baseInterval)
+
+	var timer Timer
 
 	for {
 		err = b.applyTransformers(err)
 
 		if errors.Is(err, ErrPermanent) {
-			return err
+			return b.finish(r, err)
 		}
 
 		// Check to see if the error contained an interval that is longer
 		// than the exponential retry timer. If it is, we will use the error
 		// retry timer.
-		realInterval = b.intervalSpecified(err, realInterval)
+		realInterval := b.intervalSpecified(err, r.NextInterval)
+
+		// If waiting would take us over our cumulative wait budget, then we are done.
+		if policy.MaxCumulativeInterval > 0 && r.TotalInterval+realInterval > policy.MaxCumulativeInterval {
+			return b.finish(r, fmt.Errorf("%w: %w", r.Err, ErrRetryExhausted))
+		}
 
 		// If our context is done or our interval goes over the context deadline,
 		// then we are done.
 		if !b.ctxOK(ctx, realInterval) {
-			return fmt.Errorf("r.Err: %w", ErrRetryCanceled)
+			return b.finish(r, fmt.Errorf("%w: %w", r.Err, ErrRetryCanceled))
 		}
 
+		b.emit(ETSleeping, r, realInterval, nil)
+
 		// Do this if they did not pass the WithTesting() option.
 		if !b.useTest {
-			timer := b.newTimer(realInterval)
+			// We use a single timer for the whole Retry() call. A timer is only reset after it
+			// fired and we received from its channel, so there is never a stale value to drain.
+			if timer == nil {
+				timer = b.newTimer(realInterval)
+			} else {
+				timer.Reset(realInterval)
+			}
 			select {
 			case <-ctx.Done():
 				timer.Stop() // Prevent goroutine leak
-				return fmt.Errorf("%w: %w ", r.Err, ErrRetryCanceled)
-			case <-timer.C:
+				return b.finish(r, fmt.Errorf("%w: %w ", r.Err, ErrRetryCanceled))
+			case <-timer.C():
 			}
 		}
 
@@ -237,54 +432,105 @@ func (b *Backoff) Retry(ctx context.Context, op Op, options ...RetryOption) erro
 		r.TotalInterval += realInterval
 		r.Attempt++
 
+		// Create our new base interval for the next attempt.
+		baseInterval = time.Duration(float64(baseInterval) * policy.Multiplier)
+		// Our base interval cannot exceed the maximum interval.
+		if baseInterval > policy.MaxInterval {
+			baseInterval = policy.MaxInterval
+		}
+		// Randomize the interval based on our randomization factor.
+		r.NextInterval = b.interval(policy, r.Attempt, baseInterval)
+
 		// NO WHAMMIES, NO WHAMMIES, STOP!
 		// https://www.youtube.com/watch?v=1mGrM72Z4-Y
-		err = op(ctx, r)
+		err = b.attempt(ctx, op, &r)
 		if err == nil {
-			return nil
+			return b.finish(r, nil)
 		}
 
 		// Captures our last error in the record.
 		r.Err = err
+	}
+}
 
-		// Create our new base interval for the next attempt.
-		baseInterval = time.Duration(float64(baseInterval) * b.policy.Multiplier)
-		// Our base interval cannot exceed the maximum interval.
-		if baseInterval > b.policy.MaxInterval {
-			baseInterval = b.policy.MaxInterval
-		}
-		// Randomize the interval based on our randomization factor.
-		realInterval = b.randomize(baseInterval)
+// currentPolicy returns the Policy to use for a Retry() call. If there is a PolicyProvider and it returns
+// a valid Policy, that is used. Otherwise the Policy set with WithPolicy() or the default is used.
+func (b *Backoff) currentPolicy() Policy {
+	if b.provider == nil {
+		return b.policy
 	}
+	p := b.provider.Policy()
+	if p.validate() != nil {
+		return b.policy
+	}
+	return p
 }
 
-// applyTransformers applies the error transformers to the error. If there are no transformers, the error
-// is returned as is.
+// attempt calls the Op with the Record, emitting the attempt events. The Record is stored in the Context
+// passed to the Op. After the Op returns, the Record is updated with the start time and duration of the attempt.
+func (b *Backoff) attempt(ctx context.Context, op Op, r *Record) error {
+	b.emit(ETAttemptStart, *r, 0, nil)
+	start := b.now()
+	err := op(context.WithValue(ctx, recordKey{}, *r), *r)
+	r.LastAttemptStart = start
+	r.LastAttemptDuration = b.now().Sub(start)
+	b.emit(ETAttemptEnd, *r, 0, err)
+	return err
+}
+
+// finish is called with the error Retry() will return. It records the outcome, emits the ETFinished
+// event and returns err.
+func (b *Backoff) finish(r Record, err error) error {
+	b.stats.end(err)
+	b.emit(ETFinished, r, 0, err)
+	return err
+}
+
+// applyTransformers applies the error transformers to the error, stopping early as described in
+// ChainTransformers(). If there are no transformers, the error is returned as is.
 func (b *Backoff) applyTransformers(err error) error {
 	if len(b.transformers) == 0 {
 		return err
 	}
-	for _, t := range b.transformers {
-		err = t(err)
+	return applyChain(b.transformers, err)
+}
+
+// interval returns the interval to wait after attempt, where baseInterval is the interval calculated
+// from the Policy before randomization.
+func (b *Backoff) interval(p Policy, attempt int, baseInterval time.Duration) time.Duration {
+	if n := len(b.testOpts.intervals); n > 0 {
+		if attempt > n {
+			return b.testOpts.intervals[n-1]
+		}
+		return b.testOpts.intervals[attempt-1]
 	}
-	return err
+	return p.floor(b.randomize(p.RandomizationFactor, baseInterval))
 }
 
 // randomize randomizes the interval based on the policy randomization factor. This can be be in the negative
 // or positive direction.
-func (b *Backoff) randomize(interval time.Duration) time.Duration {
-	if b.policy.RandomizationFactor == 0 {
+func (b *Backoff) randomize(factor float64, interval time.Duration) time.Duration {
+	if b.rng != nil {
+		return randomInterval(factor, interval, b.rng.Int64N)
+	}
+	return randomInterval(factor, interval, rand.Int64N) // #nosec
+}
+
+// randomInterval randomizes interval by factor, using int64N to get a random number in [0, n).
+// This is used by both Backoff and Policy.Simulate() so they have the same distribution.
+func randomInterval(factor float64, interval time.Duration, int64N func(n int64) int64) time.Duration {
+	if factor == 0 {
 		return interval
 	}
 
 	// Calculate the random range.
-	delta := b.policy.RandomizationFactor * float64(interval)
+	delta := factor * float64(interval)
 	min := interval - time.Duration(delta)
 	max := interval + time.Duration(delta)
 
 	// Get a random number in the range. So if RandomizationFactor is 0.5, and interval is 1s,
 	// then we will get a random number between 0.5s and 1.5s.
-	return time.Duration(rand.Int63n(int64(max-min))) + min // #nosec
+	return time.Duration(int64N(int64(max-min))) + min
 }
 
 // internalSpecified is used to check if the error message contains retry hints. If it does
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand/v2"
 	"sync"
 	"testing"
 	"time"
@@ -18,6 +19,52 @@ var (
 	_2secondsTime = time.Time{}.Add(2 * time.Second)
 )
 
+// fakeTimer is a Timer created by testClock.
+type fakeTimer struct {
+	// clock is the testClock that created the timer.
+	clock *testClock
+	// c is the channel that will receive a time.Time when the timer is done.
+	c chan time.Time
+	// when is the time the timer is set to go off.
+	when time.Time
+	// mu protects everything below.
+	mu sync.Mutex
+	// stopped is true if Stop() has been called.
+	stopped bool
+}
+
+// C implements Timer.C().
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop implements Timer.Stop().
+func (t *fakeTimer) Stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	return true
+}
+
+// Reset implements Timer.Reset(). Like time.Timer.Reset(), this returns false because Retry() only
+// resets a timer that has fired.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.mu.Lock()
+	t.stopped = false
+	t.mu.Unlock()
+
+	t.clock.mu.Lock()
+	t.clock.resets++
+	t.when = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+	t.clock.mu.Unlock()
+
+	if t.clock.onTimer != nil {
+		t.clock.onTimer(t.clock, d)
+	}
+	return false
+}
+
 // testClock provides a clock implementation for testing.
 // Use moveTime to move the clock forward by a duration.
 // This can be used by &testClock{} with no parameters, which starts at the zero time.
@@ -25,9 +72,13 @@ type testClock struct {
 	now time.Time
 	mu  sync.Mutex
 
-	timers []*timer
+	timers []*fakeTimer
+	// created is the number of timers created with NewTimer().
+	created int
+	// resets is the number of times a timer was Reset().
+	resets int
 
-	// onTimer fires after a new timer is created.
+	// onTimer fires after a new timer is created or a timer is reset.
 	onTimer func(t *testClock, d time.Duration)
 }
 
@@ -38,7 +89,7 @@ func (c *testClock) moveTime(d time.Duration) {
 
 	c.now = c.now.Add(d)
 
-	keep := []*timer{}
+	keep := []*fakeTimer{}
 	for _, t := range c.timers {
 		if t.stopped {
 			continue
@@ -68,7 +119,7 @@ func (c *testClock) Until(t time.Time) time.Duration {
 }
 
 // NewTimer creates a new timer that will fire after d. This is based on the internal time.
-func (c *testClock) NewTimer(d time.Duration) *timer {
+func (c *testClock) NewTimer(d time.Duration) Timer {
 	defer func() {
 		if c.onTimer != nil {
 			c.onTimer(c, d)
@@ -80,10 +131,11 @@ func (c *testClock) NewTimer(d time.Duration) *timer {
 
 	ch := make(chan time.Time, 1)
 
-	t := &timer{
-		C:    ch,
-		c:    ch,
-		when: c.now.Add(d),
+	c.created++
+	t := &fakeTimer{
+		clock: c,
+		c:     ch,
+		when:  c.now.Add(d),
 	}
 	c.timers = append(c.timers, t)
 	return t
@@ -116,7 +168,7 @@ func TestPolicyValidate(t *testing.T) {
 				RandomizationFactor: 0.5,
 				MaxInterval:         60 * time.Second,
 			},
-			want: errors.New("Policy.InitialInterval must be greater than 0"),
+			want: PolicyErrors{{Field: "InitialInterval", Value: time.Duration(0), Constraint: "must be greater than 0"}},
 		},
 		{
 			name: "Err: multiplier not greater than 1",
@@ -126,7 +178,7 @@ func TestPolicyValidate(t *testing.T) {
 				RandomizationFactor: 0.5,
 				MaxInterval:         60 * time.Second,
 			},
-			want: errors.New("Policy.Multiplier must be greater than 1"),
+			want: PolicyErrors{{Field: "Multiplier", Value: 1.0, Constraint: "must be greater than 1"}},
 		},
 		{
 			name: "Err: randomization factor out of range",
@@ -136,7 +188,7 @@ func TestPolicyValidate(t *testing.T) {
 				RandomizationFactor: 1.1,
 				MaxInterval:         60 * time.Second,
 			},
-			want: errors.New("Policy.RandomizationFactor must be between 0 and 1"),
+			want: PolicyErrors{{Field: "RandomizationFactor", Value: 1.1, Constraint: "must be between 0 and 1"}},
 		},
 		{
 			name: "Err: max interval zero",
@@ -146,7 +198,10 @@ func TestPolicyValidate(t *testing.T) {
 				RandomizationFactor: 0.5,
 				MaxInterval:         0,
 			},
-			want: errors.New("Policy.MaxInterval must be greater than 0"),
+			want: PolicyErrors{
+				{Field: "MaxInterval", Value: time.Duration(0), Constraint: "must be greater than 0"},
+				{Field: "InitialInterval", Value: 100 * time.Millisecond, Constraint: "must be less than or equal to Policy.MaxInterval"},
+			},
 		},
 		{
 			name: "Err: initial interval greater than max interval",
@@ -156,7 +211,38 @@ func TestPolicyValidate(t *testing.T) {
 				RandomizationFactor: 0.5,
 				MaxInterval:         1 * time.Minute,
 			},
-			want: errors.New("Policy.InitialInterval must be less than or equal to Policy.MaxInterval"),
+			want: PolicyErrors{{Field: "InitialInterval", Value: 2 * time.Minute, Constraint: "must be less than or equal to Policy.MaxInterval"}},
+		},
+		{
+			name: "Err: max cumulative interval negative",
+			policy: Policy{
+				InitialInterval:       100 * time.Millisecond,
+				Multiplier:            2.0,
+				RandomizationFactor:   0.5,
+				MaxInterval:           1 * time.Minute,
+				MaxCumulativeInterval: -1,
+			},
+			want: PolicyErrors{{Field: "MaxCumulativeInterval", Value: time.Duration(-1), Constraint: "must be greater than or equal to 0"}},
+		},
+		{
+			name: "Err: min interval greater than max interval",
+			policy: Policy{
+				InitialInterval:     100 * time.Millisecond,
+				Multiplier:          2.0,
+				RandomizationFactor: 0.5,
+				MaxInterval:         1 * time.Minute,
+				MinInterval:         2 * time.Minute,
+			},
+			want: PolicyErrors{{Field: "MinInterval", Value: 2 * time.Minute, Constraint: "must be less than or equal to Policy.MaxInterval"}},
+		},
+		{
+			name:   "Err: every invalid field is reported",
+			policy: Policy{},
+			want: PolicyErrors{
+				{Field: "InitialInterval", Value: time.Duration(0), Constraint: "must be greater than 0"},
+				{Field: "Multiplier", Value: 0.0, Constraint: "must be greater than 1"},
+				{Field: "MaxInterval", Value: time.Duration(0), Constraint: "must be greater than 0"},
+			},
 		},
 		{
 			name:   "Default policy must be valid",
@@ -281,6 +367,16 @@ func TestWithOptions(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name:   "WithClock",
+			option: func() Option { return WithClock(&testClock{}) },
+			tester: func(b *Backoff) error {
+				if _, ok := b.clock.(*testClock); !ok {
+					return fmt.Errorf("WithClock() option does not work")
+				}
+				return nil
+			},
+		},
 		{
 			name:   "WithTesting",
 			option: func() Option { return WithTesting() },
@@ -334,7 +430,7 @@ func TestRetry(t *testing.T) {
 		// cancelCtx is the duration to cancel the context after.
 		cancelCtx time.Duration
 		// clock is the clock to use for the test. If nil, the normal time package is used.
-		clock clock
+		clock Clock
 		// newErr is true if New() should return an error.
 		newErr bool
 		// retryErr inidicates if the Retry() function ends with an error.
@@ -552,8 +648,7 @@ func TestRandomize(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 			b := &Backoff{policy: defaults()}
-			b.policy.RandomizationFactor = test.randomizationFactor
-			got := b.randomize(test.interval)
+			got := b.randomize(test.randomizationFactor, test.interval)
 			if got < test.minValue || got > test.maxValue {
 				t.Errorf("randomize(): got %v, want between %v and %v", got, test.minValue, test.maxValue)
 			}
@@ -570,7 +665,7 @@ func TestEnsureRandomization(t *testing.T) {
 
 	b := &Backoff{policy: defaults()}
 	for i := 0; i < 100; i++ {
-		got := b.randomize(1 * time.Second)
+		got := b.randomize(b.policy.RandomizationFactor, 1*time.Second)
 		if seen[got] {
 			continue
 		}
@@ -716,3 +811,449 @@ func TestCtxOK(t *testing.T) {
 		})
 	}
 }
+
+func TestRecordNextInterval(t *testing.T) {
+	t.Parallel()
+
+	b, err := New(WithTesting())
+	if err != nil {
+		panic(err)
+	}
+	tt := defaults().TimeTable(5)
+
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		// The entry for the next attempt holds the interval we will wait before it.
+		e := tt.Entries[r.Attempt]
+		if r.NextInterval < e.MinInterval || r.NextInterval > e.MaxInterval {
+			t.Errorf("TestRecordNextInterval(attempt %d): got NextInterval %v, want between %v and %v", r.Attempt, r.NextInterval, e.MinInterval, e.MaxInterval)
+		}
+		if r.Attempt > 1 && r.LastInterval < tt.Entries[r.Attempt-1].MinInterval {
+			t.Errorf("TestRecordNextInterval(attempt %d): LastInterval %v below expected minimum", r.Attempt, r.LastInterval)
+		}
+		if r.Attempt < 4 {
+			return errors.New("transient error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TestRecordNextInterval: got err == %s, want err == nil", err)
+	}
+}
+
+func TestRecordFromContext(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := RecordFromContext(context.Background()); ok {
+		t.Errorf("TestRecordFromContext: got ok == true for a Context not from Retry()")
+	}
+
+	b, err := New(WithTesting())
+	if err != nil {
+		panic(err)
+	}
+
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		got, ok := RecordFromContext(ctx)
+		if !ok {
+			t.Fatalf("TestRecordFromContext: RecordFromContext() returned ok == false")
+		}
+		if diff := pretty.Compare(r, got); diff != "" {
+			t.Errorf("TestRecordFromContext(attempt %d): -want/+got:\n%s", r.Attempt, diff)
+		}
+		if r.Attempt < 3 {
+			return errors.New("transient error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TestRecordFromContext: got err == %s, want err == nil", err)
+	}
+}
+
+func TestChainTransformers(t *testing.T) {
+	t.Parallel()
+
+	errBusiness := errors.New("business error")
+	var calls []string
+	record := func(name string, f ErrTransformer) ErrTransformer {
+		return func(err error) error {
+			calls = append(calls, name)
+			return f(err)
+		}
+	}
+	permOnBusiness := record("business", func(err error) error {
+		if errors.Is(err, errBusiness) {
+			return fmt.Errorf("%w: %w", err, ErrPermanent)
+		}
+		return err
+	})
+	passThrough := record("passThrough", func(err error) error { return err })
+	toNil := record("toNil", func(err error) error { return nil })
+
+	tests := []struct {
+		name      string
+		chain     ErrTransformer
+		err       error
+		wantPerm  bool
+		wantNil   bool
+		wantCalls []string
+	}{
+		{
+			name:      "No transformers",
+			chain:     ChainTransformers(),
+			err:       errors.New("error"),
+			wantCalls: nil,
+		},
+		{
+			name:      "All applied in order",
+			chain:     ChainTransformers(passThrough, nil, permOnBusiness),
+			err:       errors.New("error"),
+			wantCalls: []string{"passThrough", "business"},
+		},
+		{
+			name:      "Permanent short circuits",
+			chain:     ChainTransformers(permOnBusiness, passThrough),
+			err:       errBusiness,
+			wantPerm:  true,
+			wantCalls: []string{"business"},
+		},
+		{
+			name:      "nil short circuits",
+			chain:     ChainTransformers(toNil, passThrough),
+			err:       errors.New("error"),
+			wantNil:   true,
+			wantCalls: []string{"toNil"},
+		},
+		{
+			name:      "Nested chains",
+			chain:     ChainTransformers(ChainTransformers(passThrough), permOnBusiness, passThrough),
+			err:       errBusiness,
+			wantPerm:  true,
+			wantCalls: []string{"passThrough", "business"},
+		},
+	}
+
+	for _, test := range tests {
+		calls = nil
+		got := test.chain(test.err)
+		if errors.Is(got, ErrPermanent) != test.wantPerm {
+			t.Errorf("TestChainTransformers(%s): got permanent == %v, want %v", test.name, errors.Is(got, ErrPermanent), test.wantPerm)
+		}
+		if (got == nil) != test.wantNil {
+			t.Errorf("TestChainTransformers(%s): got err == %v, want nil == %v", test.name, got, test.wantNil)
+		}
+		if diff := pretty.Compare(test.wantCalls, calls); diff != "" {
+			t.Errorf("TestChainTransformers(%s): calls -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestWithErrTransformerMultiple(t *testing.T) {
+	t.Parallel()
+
+	errBusiness := errors.New("business error")
+	first := func(err error) error { return err }
+	second := func(err error) error {
+		if errors.Is(err, errBusiness) {
+			return fmt.Errorf("%w: %w", err, ErrPermanent)
+		}
+		return err
+	}
+
+	b, err := New(WithTesting(), WithErrTransformer(first), WithErrTransformer(second))
+	if err != nil {
+		panic(err)
+	}
+	if len(b.transformers) != 2 {
+		t.Fatalf("TestWithErrTransformerMultiple: got %d transformers, want 2", len(b.transformers))
+	}
+
+	attempts := 0
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		attempts++
+		return errBusiness
+	})
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("TestWithErrTransformerMultiple: got err == %v, want ErrPermanent", err)
+	}
+	if attempts != 1 {
+		t.Errorf("TestWithErrTransformerMultiple: got %d attempts, want 1", attempts)
+	}
+
+	if _, err := New(WithErrTransformer(nil)); err == nil {
+		t.Errorf("TestWithErrTransformerMultiple: WithErrTransformer(nil): got err == nil, want err != nil")
+	}
+}
+
+func TestMaxCumulativeInterval(t *testing.T) {
+	t.Parallel()
+
+	// Intervals are 1s, 2s, 4s, 8s..., so the cumulative waits are 1s, 3s, 7s, 15s...
+	policy := Policy{
+		InitialInterval:       time.Second,
+		Multiplier:            2,
+		MaxInterval:           time.Minute,
+		MaxCumulativeInterval: 10 * time.Second,
+	}
+	b, err := New(WithTesting(), WithPolicy(policy))
+	if err != nil {
+		panic(err)
+	}
+
+	errOp := errors.New("op error")
+	var last Record
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		last = r
+		return errOp
+	})
+	if !errors.Is(err, ErrRetryExhausted) {
+		t.Fatalf("TestMaxCumulativeInterval: got err == %v, want ErrRetryExhausted", err)
+	}
+	if !errors.Is(err, errOp) {
+		t.Errorf("TestMaxCumulativeInterval: got err == %v, want it to wrap the Op error", err)
+	}
+	if errors.Is(err, ErrRetryCanceled) || errors.Is(err, ErrPermanent) {
+		t.Errorf("TestMaxCumulativeInterval: got err == %v, should only be ErrRetryExhausted", err)
+	}
+	if last.Attempt != 4 || last.TotalInterval != 7*time.Second {
+		t.Errorf("TestMaxCumulativeInterval: got Attempt == %d, TotalInterval == %v, want 4 and 7s", last.Attempt, last.TotalInterval)
+	}
+}
+
+func TestWithTestingOptions(t *testing.T) {
+	t.Parallel()
+
+	intervals := func(b *Backoff) []time.Duration {
+		var got []time.Duration
+		err := b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+			got = append(got, r.NextInterval)
+			if r.Attempt < 5 {
+				return errors.New("transient error")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("TestWithTestingOptions: got err == %s, want err == nil", err)
+		}
+		return got
+	}
+
+	b, err := New(WithTesting(WithIntervals(time.Second, 3*time.Second, 2*time.Second)))
+	if err != nil {
+		panic(err)
+	}
+	want := []time.Duration{time.Second, 3 * time.Second, 2 * time.Second, 2 * time.Second, 2 * time.Second}
+	if diff := pretty.Compare(want, intervals(b)); diff != "" {
+		t.Errorf("TestWithTestingOptions(WithIntervals): -want/+got:\n%s", diff)
+	}
+
+	b1, err := New(WithTesting(WithSeed(42)))
+	if err != nil {
+		panic(err)
+	}
+	b2, err := New(WithTesting(WithSeed(42)))
+	if err != nil {
+		panic(err)
+	}
+	if diff := pretty.Compare(intervals(b1), intervals(b2)); diff != "" {
+		t.Errorf("TestWithTestingOptions(WithSeed): same seed gave different intervals:\n%s", diff)
+	}
+
+	if _, err := New(WithTesting(WithIntervals())); err == nil {
+		t.Errorf("TestWithTestingOptions: WithIntervals(): got err == nil, want err != nil")
+	}
+	if _, err := New(WithTesting(WithIntervals(-1))); err == nil {
+		t.Errorf("TestWithTestingOptions: WithIntervals(-1): got err == nil, want err != nil")
+	}
+}
+
+func TestRetryReusesTimer(t *testing.T) {
+	t.Parallel()
+
+	clock := &testClock{
+		onTimer: func(t *testClock, d time.Duration) {
+			t.moveTime(d)
+		},
+	}
+	b, err := New(WithClock(clock))
+	if err != nil {
+		panic(err)
+	}
+
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		if r.Attempt < 5 {
+			return errors.New("transient error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TestRetryReusesTimer: got err == %s, want err == nil", err)
+	}
+
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	if clock.created != 1 || clock.resets != 3 {
+		t.Errorf("TestRetryReusesTimer: got %d timers created and %d resets, want 1 and 3", clock.created, clock.resets)
+	}
+}
+
+func TestWithRandSource(t *testing.T) {
+	t.Parallel()
+
+	randomize := func(b *Backoff) []time.Duration {
+		got := make([]time.Duration, 10)
+		for i := range got {
+			got[i] = b.randomize(0.5, time.Second)
+		}
+		return got
+	}
+
+	b1, err := New(WithRandSource(rand.NewPCG(1, 2)))
+	if err != nil {
+		panic(err)
+	}
+	b2, err := New(WithRandSource(rand.NewPCG(1, 2)))
+	if err != nil {
+		panic(err)
+	}
+	if diff := pretty.Compare(randomize(b1), randomize(b2)); diff != "" {
+		t.Errorf("TestWithRandSource: same source seed gave different intervals:\n%s", diff)
+	}
+
+	if _, err := New(WithRandSource(nil)); err == nil {
+		t.Errorf("TestWithRandSource: WithRandSource(nil): got err == nil, want err != nil")
+	}
+}
+
+func TestPolicyErrorsFromNew(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(WithPolicy(Policy{InitialInterval: time.Second, Multiplier: 0.5, MaxInterval: time.Second}))
+
+	var pes PolicyErrors
+	if !errors.As(err, &pes) {
+		t.Fatalf("TestPolicyErrorsFromNew: got err == %v, want PolicyErrors", err)
+	}
+	var pe PolicyError
+	if !errors.As(err, &pe) {
+		t.Fatalf("TestPolicyErrorsFromNew: errors.As() could not find a PolicyError")
+	}
+	if pe.Field != "Multiplier" || pe.Value != 0.5 {
+		t.Errorf("TestPolicyErrorsFromNew: got PolicyError %+v, want Field == Multiplier, Value == 0.5", pe)
+	}
+	if want := "Policy.Multiplier(0.5) must be greater than 1"; err.Error() != want {
+		t.Errorf("TestPolicyErrorsFromNew: got err.Error() == %q, want %q", err.Error(), want)
+	}
+}
+
+func TestAttemptsForBudget(t *testing.T) {
+	t.Parallel()
+
+	// Intervals are 0, 1s, 2s, 4s, 4s... with min/max of 0.5x/1.5x.
+	policy := Policy{InitialInterval: time.Second, Multiplier: 2, RandomizationFactor: 0.5, MaxInterval: 4 * time.Second}
+
+	tests := []struct {
+		name     string
+		attempts int
+		budget   time.Duration
+		want     AttemptBudget
+	}{
+		{name: "Negative budget", attempts: -1, budget: -1, want: AttemptBudget{}},
+		{name: "Zero budget", attempts: -1, budget: 0, want: AttemptBudget{WorstCase: 1, Expected: 1}},
+		// Expected starts: 0, 1s, 3s, 7s. Worst case starts: 0, 1.5s, 4.5s, 10.5s.
+		{name: "Within the entries", attempts: -1, budget: 4 * time.Second, want: AttemptBudget{WorstCase: 2, Expected: 3}},
+		{name: "Exactly on a boundary", attempts: -1, budget: 7 * time.Second, want: AttemptBudget{WorstCase: 3, Expected: 4}},
+		// Expected starts continue 11s, 15s, 19s. Worst case starts continue 16.5s, 22.5s.
+		{name: "Past the entries", attempts: -1, budget: 20 * time.Second, want: AttemptBudget{WorstCase: 5, Expected: 7}},
+		{name: "Only one entry", attempts: 1, budget: time.Hour, want: AttemptBudget{WorstCase: 1, Expected: 1}},
+	}
+
+	for _, test := range tests {
+		got := policy.TimeTable(test.attempts).AttemptsForBudget(test.budget)
+		if got != test.want {
+			t.Errorf("TestAttemptsForBudget(%s): got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRecordTimestamps(t *testing.T) {
+	t.Parallel()
+
+	clock := &testClock{
+		now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		onTimer: func(t *testClock, d time.Duration) {
+			t.moveTime(d)
+		},
+	}
+	start := clock.Now()
+	b, err := New(WithClock(clock), WithTesting(WithIntervals(time.Second)))
+	if err != nil {
+		panic(err)
+	}
+
+	var records []Record
+	events := make(chan Event, 100)
+	b.events = events
+	err = b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		records = append(records, r)
+		// Each attempt takes 2 seconds.
+		clock.moveTime(2 * time.Second)
+		if r.Attempt < 3 {
+			return errors.New("transient error")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TestRecordTimestamps: got err == %s, want err == nil", err)
+	}
+
+	for _, r := range records {
+		if !r.StartTime.Equal(start) {
+			t.Errorf("TestRecordTimestamps(attempt %d): got StartTime == %v, want %v", r.Attempt, r.StartTime, start)
+		}
+	}
+	if !records[0].LastAttemptStart.IsZero() || records[0].LastAttemptDuration != 0 {
+		t.Errorf("TestRecordTimestamps(attempt 1): got LastAttemptStart == %v, LastAttemptDuration == %v, want zero values", records[0].LastAttemptStart, records[0].LastAttemptDuration)
+	}
+	// WithTesting() does not wait, so attempt 2 starts right after attempt 1.
+	if !records[2].LastAttemptStart.Equal(start.Add(2*time.Second)) || records[2].LastAttemptDuration != 2*time.Second {
+		t.Errorf("TestRecordTimestamps(attempt 3): got LastAttemptStart == %v, LastAttemptDuration == %v, want %v and 2s", records[2].LastAttemptStart, records[2].LastAttemptDuration, start.Add(2*time.Second))
+	}
+
+	close(events)
+	var last Event
+	for e := range events {
+		last = e
+	}
+	if last.Type != ETFinished || !last.Record.LastAttemptStart.Equal(start.Add(4*time.Second)) {
+		t.Errorf("TestRecordTimestamps: got final Event %v with LastAttemptStart == %v, want ETFinished and %v", last.Type, last.Record.LastAttemptStart, start.Add(4*time.Second))
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	t.Parallel()
+
+	policy := Policy{
+		InitialInterval:     time.Second,
+		Multiplier:          2,
+		RandomizationFactor: 1,
+		MaxInterval:         4 * time.Second,
+		MinInterval:         1500 * time.Millisecond,
+	}
+	b, err := New(WithTesting(), WithPolicy(policy), WithRandSource(rand.NewPCG(1, 1)))
+	if err != nil {
+		panic(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if got := b.interval(policy, 1, time.Second); got < policy.MinInterval {
+			t.Fatalf("TestMinInterval: got interval %v, want >= %v", got, policy.MinInterval)
+		}
+	}
+
+	tt := policy.TimeTable(3)
+	// Attempt 2 waits 1s +/- 1s, which is floored to 1.5s to 2s.
+	if e := tt.Entries[1]; e.MinInterval != 1500*time.Millisecond || e.MaxInterval != 2*time.Second {
+		t.Errorf("TestMinInterval: got TimeTable entry %+v, want MinInterval 1.5s and MaxInterval 2s", e)
+	}
+}
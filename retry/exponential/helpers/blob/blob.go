@@ -0,0 +1,412 @@
+/*
+Package blob provides an exponential.ErrTransformer for errors returned by cloud object storage clients
+for Amazon S3, Google Cloud Storage (GCS) and Azure Blob Storage. Errors are identified by the error code
+and HTTP status code the service returned, so this package does not depend on any provider SDK.
+
+Errors that are retriable: throttling (SlowDown, ServerBusy, 429, 503), server errors (500, 502, 504),
+request timeouts, clock skew (RequestTimeTooSkewed), checksum mismatches (BadDigest, Md5Mismatch or
+ErrChecksumMismatch) and streams that ended early (io.ErrUnexpectedEOF). Errors that are permanent:
+permission errors (AccessDenied, AuthorizationFailure, 401, 403), not found errors (NoSuchKey,
+BlobNotFound, 404) and malformed requests (400). Other errors are returned unchanged, which means they
+will be retried.
+
+Each provider can be tuned with per-provider options, such as WithRetryNotFound(), which is useful when
+reading an object that another process has just written.
+
+Example using just defaults:
+
+	blobTransform, _ := blob.New()
+
+	backoff, _ := exponential.New(exponential.WithErrTransformer(blobTransform.ErrTransformer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			_, err := client.PutObject(ctx, input)
+			return err
+		},
+	)
+	cancel()
+
+Example waiting for an S3 object to appear and treating a custom error code as retriable:
+
+	blobTransform, _ := blob.New(
+		blob.WithRetryNotFound(blob.S3),
+		blob.WithRetriableCodes(blob.S3, "ObjectNotInActiveTierError"),
+	)
+*/
+package blob
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrChecksumMismatch can be wrapped by an Op to indicate that the data read or written did not match
+// its checksum. These errors are retriable, as the data is usually corrupted in transit.
+var ErrChecksumMismatch = errors.New("blob checksum mismatch")
+
+// Provider is a cloud object storage provider.
+type Provider uint8
+
+const (
+	// UnknownProvider indicates the provider could not be determined.
+	UnknownProvider Provider = 0
+	// S3 is Amazon S3 or an S3 compatible service.
+	S3 Provider = 1
+	// GCS is Google Cloud Storage.
+	GCS Provider = 2
+	// Azure is Azure Blob Storage.
+	Azure Provider = 3
+)
+
+// String implements fmt.Stringer.
+func (p Provider) String() string {
+	switch p {
+	case S3:
+		return "S3"
+	case GCS:
+		return "GCS"
+	case Azure:
+		return "Azure"
+	}
+	return "Unknown"
+}
+
+// providerConfig holds the settings for a single Provider.
+type providerConfig struct {
+	// retryNotFound makes not found errors retriable.
+	retryNotFound bool
+	// codes are error codes that override the default classification.
+	codes map[string]kind
+}
+
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
+type Transformer struct {
+	providers [4]providerConfig
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithRetryNotFound makes not found errors retriable for the providers passed. If no providers are
+// passed, this applies to all of them. This is useful when waiting for an object written by another
+// process to become visible.
+func WithRetryNotFound(providers ...Provider) Option {
+	return func(t *Transformer) error {
+		ps, err := toProviders(providers)
+		if err != nil {
+			return fmt.Errorf("WithRetryNotFound(): %w", err)
+		}
+		for _, p := range ps {
+			t.providers[p].retryNotFound = true
+		}
+		return nil
+	}
+}
+
+// WithRetriableCodes makes the provider's error codes passed retriable. This overrides the default
+// classification of the codes.
+func WithRetriableCodes(p Provider, codes ...string) Option {
+	return func(t *Transformer) error {
+		if err := t.addCodes(p, kRetriable, codes); err != nil {
+			return fmt.Errorf("WithRetriableCodes(): %w", err)
+		}
+		return nil
+	}
+}
+
+// WithPermanentCodes makes the provider's error codes passed permanent. This overrides the default
+// classification of the codes.
+func WithPermanentCodes(p Provider, codes ...string) Option {
+	return func(t *Transformer) error {
+		if err := t.addCodes(p, kPermanent, codes); err != nil {
+			return fmt.Errorf("WithPermanentCodes(): %w", err)
+		}
+		return nil
+	}
+}
+
+// New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// addCodes adds codes with kind k to the Provider.
+func (t *Transformer) addCodes(p Provider, k kind, codes []string) error {
+	if !validProvider(p) {
+		return fmt.Errorf("invalid Provider(%d)", p)
+	}
+	pc := &t.providers[p]
+	if pc.codes == nil {
+		pc.codes = map[string]kind{}
+	}
+	for _, c := range codes {
+		pc.codes[c] = k
+	}
+	return nil
+}
+
+// kind is the classification of a storage error.
+type kind uint8
+
+const (
+	kUnknown   kind = 0
+	kRetriable kind = 1
+	kPermanent kind = 2
+	kNotFound  kind = 3
+)
+
+// s3Codes are the S3 error codes we classify.
+var s3Codes = map[string]kind{
+	"SlowDown":                  kRetriable,
+	"Throttling":                kRetriable,
+	"ThrottlingException":       kRetriable,
+	"RequestLimitExceeded":      kRetriable,
+	"RequestTimeout":            kRetriable,
+	"RequestTimeTooSkewed":      kRetriable,
+	"InternalError":             kRetriable,
+	"ServiceUnavailable":        kRetriable,
+	"OperationAborted":          kRetriable,
+	"BadDigest":                 kRetriable,
+	"XAmzContentSHA256Mismatch": kRetriable,
+	"AccessDenied":              kPermanent,
+	"AllAccessDisabled":         kPermanent,
+	"AccountProblem":            kPermanent,
+	"InvalidAccessKeyId":        kPermanent,
+	"SignatureDoesNotMatch":     kPermanent,
+	"InvalidBucketName":         kPermanent,
+	"InvalidArgument":           kPermanent,
+	"InvalidObjectState":        kPermanent,
+	"EntityTooLarge":            kPermanent,
+	"EntityTooSmall":            kPermanent,
+	"Forbidden":                 kPermanent,
+	"NoSuchKey":                 kNotFound,
+	"NoSuchBucket":              kNotFound,
+	"NoSuchUpload":              kNotFound,
+	"NotFound":                  kNotFound,
+}
+
+// azureCodes are the Azure Blob Storage error codes we classify.
+var azureCodes = map[string]kind{
+	"ServerBusy":                      kRetriable,
+	"OperationTimedOut":               kRetriable,
+	"InternalError":                   kRetriable,
+	"Md5Mismatch":                     kRetriable,
+	"Crc64Mismatch":                   kRetriable,
+	"AuthenticationFailed":            kPermanent,
+	"AuthorizationFailure":            kPermanent,
+	"AuthorizationPermissionMismatch": kPermanent,
+	"InsufficientAccountPermissions":  kPermanent,
+	"AccountIsDisabled":               kPermanent,
+	"InvalidResourceName":             kPermanent,
+	"BlobNotFound":                    kNotFound,
+	"ContainerNotFound":               kNotFound,
+	"ResourceNotFound":                kNotFound,
+}
+
+// gcsCodes are the GCS error reasons we classify. GCS uses HTTP status codes for most errors.
+var gcsCodes = map[string]kind{
+	"rateLimitExceeded": kRetriable,
+	"backendError":      kRetriable,
+	"forbidden":         kPermanent,
+	"notFound":          kNotFound,
+}
+
+// ErrTransformer returns a transformer that can be used to detect non-retriable errors.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent.
+func (t *Transformer) ErrTransformer(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, ErrChecksumMismatch) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+
+	p, code, httpCode := details(err)
+	k := t.classify(p, code, httpCode)
+	if k == kNotFound && t.providers[p].retryNotFound {
+		return err
+	}
+	if k == kPermanent || k == kNotFound {
+		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+	}
+	return err
+}
+
+// classify returns the kind of error for the provider's error code and HTTP status code.
+// The error code takes precedence over the HTTP status code.
+func (t *Transformer) classify(p Provider, code string, httpCode int) kind {
+	if code != "" {
+		if k, ok := t.providers[p].codes[code]; ok {
+			return k
+		}
+		var table map[string]kind
+		switch p {
+		case S3:
+			table = s3Codes
+		case GCS:
+			table = gcsCodes
+		case Azure:
+			table = azureCodes
+		}
+		if k, ok := table[code]; ok {
+			return k
+		}
+	}
+
+	switch httpCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return kRetriable
+	case http.StatusNotFound:
+		return kNotFound
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed,
+		http.StatusLengthRequired, http.StatusPreconditionFailed, http.StatusRequestEntityTooLarge,
+		http.StatusRequestedRangeNotSatisfiable:
+		return kPermanent
+	}
+	return kUnknown
+}
+
+// s3Error is implemented by smithy.APIError from the AWS SDK for Go v2.
+type s3Error interface {
+	ErrorCode() string
+}
+
+// httpStatusError is implemented by awshttp.ResponseError from the AWS SDK for Go v2.
+type httpStatusError interface {
+	HTTPStatusCode() int
+}
+
+// details extracts the Provider, error code and HTTP status code from an error. Any of these may be
+// the zero value if they cannot be found.
+func details(err error) (p Provider, code string, httpCode int) {
+	var s3Err s3Error
+	if errors.As(err, &s3Err) {
+		p, code = S3, s3Err.ErrorCode()
+		var hErr httpStatusError
+		if errors.As(err, &hErr) {
+			httpCode = hErr.HTTPStatusCode()
+		}
+		return p, code, httpCode
+	}
+
+	// The GCS client can use gRPC instead of JSON, in which case it returns a gRPC status.
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		return GCS, "", grpcToHTTP(s.Code())
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "googleapi: Error "):
+		return GCS, gcsReason(msg), statusAfter(msg, "googleapi: Error ")
+	case strings.Contains(msg, "storage: object doesn't exist"), strings.Contains(msg, "storage: bucket doesn't exist"):
+		return GCS, "", http.StatusNotFound
+	case strings.Contains(msg, "ERROR CODE: "):
+		// azcore.ResponseError formats as "...\nRESPONSE 404: 404 The specified blob does not exist.\nERROR CODE: BlobNotFound\n...".
+		return Azure, lineAfter(msg, "ERROR CODE: "), statusAfter(msg, "RESPONSE ")
+	}
+	return UnknownProvider, "", 0
+}
+
+// gcsReason extracts the reason from a googleapi.Error message, which looks like
+// "googleapi: Error 429: The rate of change requests to the object exceeds the rate limit., rateLimitExceeded".
+func gcsReason(msg string) string {
+	line := lineAfter(msg, "googleapi: Error ")
+	i := strings.LastIndex(line, ", ")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(line[i+2:])
+}
+
+// statusAfter returns the HTTP status code that immediately follows prefix in msg or 0.
+func statusAfter(msg, prefix string) int {
+	i := strings.Index(msg, prefix)
+	if i < 0 {
+		return 0
+	}
+	s := msg[i+len(prefix):]
+	if len(s) < 3 {
+		return 0
+	}
+	c, err := strconv.Atoi(s[:3])
+	if err != nil {
+		return 0
+	}
+	return c
+}
+
+// lineAfter returns the rest of the line after prefix in msg.
+func lineAfter(msg, prefix string) string {
+	i := strings.Index(msg, prefix)
+	if i < 0 {
+		return ""
+	}
+	s := msg[i+len(prefix):]
+	if j := strings.IndexByte(s, '\n'); j >= 0 {
+		s = s[:j]
+	}
+	return strings.TrimSpace(s)
+}
+
+// grpcToHTTP converts the gRPC codes returned by the GCS gRPC API into the equivalent HTTP status code.
+func grpcToHTTP(c codes.Code) int {
+	switch c {
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Internal:
+		return http.StatusInternalServerError
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	}
+	return 0
+}
+
+// toProviders validates the providers passed. If none are passed, all providers are returned.
+func toProviders(providers []Provider) ([]Provider, error) {
+	if len(providers) == 0 {
+		return []Provider{S3, GCS, Azure}, nil
+	}
+	for _, p := range providers {
+		if !validProvider(p) {
+			return nil, fmt.Errorf("invalid Provider(%d)", p)
+		}
+	}
+	return providers, nil
+}
+
+// validProvider returns true if p is a known Provider.
+func validProvider(p Provider) bool {
+	return p == S3 || p == GCS || p == Azure
+}
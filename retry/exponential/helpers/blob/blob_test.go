@@ -0,0 +1,112 @@
+package blob
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeS3Err mimics smithy.APIError wrapped in an awshttp.ResponseError.
+type fakeS3Err struct {
+	code   string
+	status int
+}
+
+func (f fakeS3Err) Error() string       { return "api error " + f.code }
+func (f fakeS3Err) ErrorCode() string   { return f.code }
+func (f fakeS3Err) HTTPStatusCode() int { return f.status }
+
+func azureErr(status int, code string) error {
+	return fmt.Errorf("PUT https://account.blob.core.windows.net/c/b\n--------------------------------------------------------------------------------\nRESPONSE %d: %d message\nERROR CODE: %s\n--------------------------------------------------------------------------------\n", status, status, code)
+}
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  []Option
+		err      error
+		wantPerm bool
+	}{
+		{name: "Unknown error", err: fmt.Errorf("some error")},
+		{name: "Unexpected EOF", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF)},
+		{name: "ErrChecksumMismatch", err: fmt.Errorf("crc32c: %w", ErrChecksumMismatch)},
+		{name: "S3 SlowDown", err: fakeS3Err{code: "SlowDown", status: 503}},
+		{name: "S3 RequestTimeTooSkewed is a 403 but retriable", err: fakeS3Err{code: "RequestTimeTooSkewed", status: 403}},
+		{name: "S3 BadDigest", err: fmt.Errorf("put: %w", fakeS3Err{code: "BadDigest", status: 400})},
+		{name: "S3 AccessDenied", err: fakeS3Err{code: "AccessDenied", status: 403}, wantPerm: true},
+		{name: "S3 NoSuchKey", err: fakeS3Err{code: "NoSuchKey", status: 404}, wantPerm: true},
+		{
+			name:    "S3 NoSuchKey with WithRetryNotFound(S3)",
+			options: []Option{WithRetryNotFound(S3)},
+			err:     fakeS3Err{code: "NoSuchKey", status: 404},
+		},
+		{
+			name:     "S3 NoSuchKey with WithRetryNotFound(Azure)",
+			options:  []Option{WithRetryNotFound(Azure)},
+			err:      fakeS3Err{code: "NoSuchKey", status: 404},
+			wantPerm: true,
+		},
+		{name: "S3 unknown code uses status", err: fakeS3Err{code: "Whatever", status: 429}},
+		{name: "S3 unknown code with 401", err: fakeS3Err{code: "Whatever", status: 401}, wantPerm: true},
+		{
+			name:     "S3 WithPermanentCodes",
+			options:  []Option{WithPermanentCodes(S3, "SlowDown")},
+			err:      fakeS3Err{code: "SlowDown", status: 503},
+			wantPerm: true,
+		},
+		{
+			name:    "S3 WithRetriableCodes",
+			options: []Option{WithRetriableCodes(S3, "InvalidObjectState")},
+			err:     fakeS3Err{code: "InvalidObjectState", status: 403},
+		},
+		{name: "GCS 429", err: fmt.Errorf("googleapi: Error 429: too many requests, rateLimitExceeded")},
+		{name: "GCS 503", err: fmt.Errorf("googleapi: Error 503: backend, backendError")},
+		{name: "GCS 403", err: fmt.Errorf("googleapi: Error 403: caller does not have access, forbidden"), wantPerm: true},
+		{name: "GCS object doesn't exist", err: fmt.Errorf("storage: object doesn't exist"), wantPerm: true},
+		{
+			name:    "GCS object doesn't exist with WithRetryNotFound()",
+			options: []Option{WithRetryNotFound()},
+			err:     fmt.Errorf("storage: object doesn't exist"),
+		},
+		{name: "GCS gRPC Unavailable", err: status.Error(codes.Unavailable, "unavailable")},
+		{name: "GCS gRPC PermissionDenied", err: status.Error(codes.PermissionDenied, "denied"), wantPerm: true},
+		{name: "Azure ServerBusy", err: azureErr(503, "ServerBusy")},
+		{name: "Azure Md5Mismatch", err: azureErr(400, "Md5Mismatch")},
+		{name: "Azure AuthorizationFailure", err: azureErr(403, "AuthorizationFailure"), wantPerm: true},
+		{name: "Azure BlobNotFound", err: azureErr(404, "BlobNotFound"), wantPerm: true},
+		{name: "Azure unknown code uses status", err: azureErr(500, "SomethingNew")},
+	}
+
+	for _, test := range tests {
+		tr, err := New(test.options...)
+		if err != nil {
+			panic(err)
+		}
+
+		got := tr.ErrTransformer(test.err)
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformer(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+		if !errors.Is(got, test.err) {
+			t.Errorf("TestErrTransformer(%s): returned error does not wrap the original error", test.name)
+		}
+	}
+
+	tr, _ := New()
+	if tr.ErrTransformer(nil) != nil {
+		t.Errorf("TestErrTransformer(nil): got err != nil")
+	}
+	if _, err := New(WithRetryNotFound(Provider(9))); err == nil {
+		t.Errorf("TestErrTransformer: WithRetryNotFound(Provider(9)): got err == nil, want err != nil")
+	}
+	if _, err := New(WithRetriableCodes(UnknownProvider, "code")); err == nil {
+		t.Errorf("TestErrTransformer: WithRetriableCodes(UnknownProvider): got err == nil, want err != nil")
+	}
+}
@@ -73,14 +73,39 @@ Example with custom message inspection:
 		},
 	)
 	cancel()
+
+Example installing retries on a ClientConn with an interceptor:
+
+	boff, err := exponential.New()
+	if err != nil {
+		// Handle error
+	}
+	intercept, err := grpc.UnaryClientInterceptor(boff, grpc.WithAttemptMetadata("x-retry-attempt"))
+	if err != nil {
+		// Handle error
+	}
+
+	conn, err := grpclib.Dial(addr, grpclib.WithUnaryInterceptor(intercept))
+	if err != nil {
+		// Handle error
+	}
+	client := pb.NewGreeterClient(conn)
+
+	// Every call is now retried until it succeeds, has a permanent error or the Context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	resp, err := client.SayHello(ctx, &pb.HelloRequest{Name: "John"})
+	cancel()
 */
 package grpc
 
 import (
 	"fmt"
 	"reflect"
+	"time"
 
+	"github.com/gostdlib/ops/retry/exponential"
 	"github.com/gostdlib/ops/retry/internal/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/protobuf/proto"
 
 	"google.golang.org/grpc/codes"
@@ -90,16 +115,34 @@ import (
 /*
 Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
 The following codes are retriable: Canceled, DeadlineExceeded, Unknown, Internal, Unavailable, ResourceExhausted.
-Any other code is not.
+Any other code is not. If a retriable status carries a google.rpc.RetryInfo detail, the error is wrapped in
+an errors.ErrRetryAfter so that the Backoff waits at least the delay the server requested.
 */
 type Transformer struct {
 	extras       map[codes.Code]bool
 	protosToErrs []ProtoToErr
+	// retriable replaces grpcRetriable if set. Set with WithRetriableCodes().
+	retriable map[codes.Code]bool
+	// clock is the Clock set with WithClock(). If nil, the time package is used.
+	clock exponential.Clock
 }
 
 // Option is an option for the New() constructor.
 type Option func(t *Transformer) error
 
+// WithClock sets the Clock used to set the time of an errors.ErrRetryAfter. Pass the Clock given to
+// exponential.WithClock() so the delay is measured with the same time as the Backoff. UnaryClientInterceptor()
+// always uses the time of its Backoff.
+func WithClock(c exponential.Clock) Option {
+	return func(t *Transformer) error {
+		if c == nil {
+			return errors.New("WithClock() cannot be passed a nil Clock")
+		}
+		t.clock = c
+		return nil
+	}
+}
+
 // WithExtraCodes defines extra grpc status codes that are considered retriable.
 func WithExtraCodes(extras ...codes.Code) Option {
 	return func(t *Transformer) error {
@@ -110,6 +153,18 @@ func WithExtraCodes(extras ...codes.Code) Option {
 	}
 }
 
+// WithRetriableCodes replaces the list of retriable grpc status codes listed on Transformer with codes.
+// Codes added with WithExtraCodes() are still retriable.
+func WithRetriableCodes(retriable ...codes.Code) Option {
+	return func(t *Transformer) error {
+		t.retriable = make(map[codes.Code]bool, len(retriable))
+		for _, code := range retriable {
+			t.retriable[code] = true
+		}
+		return nil
+	}
+}
+
 // ProtoToErr inspects a protocol buffer message and determines if the call was really an error.
 // If it was not, this returns nil.
 type ProtoToErr func(msg proto.Message) error
@@ -140,8 +195,14 @@ func New(options ...Option) (*Transformer, error) {
 }
 
 // ErrTransformer returns a transformer that can be used to detect non-retriable errors.
-// If it is non-retriable it will wrap the error with errors.ErrPermanent.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent. If it is retriable and the
+// status has a RetryInfo detail, it will wrap the error with errors.ErrRetryAfter.
 func (t *Transformer) ErrTransformer(err error) error {
+	return t.transform(err, t.now)
+}
+
+// transform implements ErrTransformer(). now returns the time an errors.ErrRetryAfter is relative to.
+func (t *Transformer) transform(err error, now func() time.Time) error {
 	is, code := t.isGRPCErr(err)
 	if !is {
 		return err
@@ -150,9 +211,39 @@ func (t *Transformer) ErrTransformer(err error) error {
 	if t.isGRPCPermanent(code) {
 		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
 	}
+	if d := retryDelay(err); d > 0 {
+		return errors.ErrRetryAfter{Time: now().Add(d), Err: err}
+	}
 	return err
 }
 
+// now returns the current time from the Clock.
+func (t *Transformer) now() time.Time {
+	if t.clock == nil {
+		return time.Now()
+	}
+	return t.clock.Now()
+}
+
+// retryDelay returns the delay in a google.rpc.RetryInfo detail attached to the gRPC status in err.
+// If there is no RetryInfo, this returns 0.
+func retryDelay(err error) time.Duration {
+	s, ok := status.FromError(err)
+	if !ok {
+		return 0
+	}
+	for _, detail := range s.Details() {
+		ri, ok := detail.(*errdetails.RetryInfo)
+		if !ok || ri.GetRetryDelay() == nil {
+			continue
+		}
+		if d := ri.GetRetryDelay().AsDuration(); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
 // isGRPCErr returns true if the error is a gRPC error and the gRPC code.
 func (t *Transformer) isGRPCErr(err error) (bool, codes.Code) {
 	// The gRPC status package is actually a wrapper around an internal status package. While Status is exposed
@@ -189,7 +280,11 @@ var grpcRetriable = map[codes.Code]bool{
 
 // isGRPCPermanent returns true if the error is a GRPC error that is permanent.
 func (t *Transformer) isGRPCPermanent(code codes.Code) bool {
-	if grpcRetriable[code] {
+	retriable := grpcRetriable
+	if t.retriable != nil {
+		retriable = t.retriable
+	}
+	if retriable[code] {
 		return false
 	}
 	if t.extras[code] {
@@ -3,11 +3,15 @@ package grpc
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/gostdlib/ops/retry/exponential"
 	"github.com/gostdlib/ops/retry/internal/errors"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func TestErrTransformer(t *testing.T) {
@@ -102,3 +106,113 @@ func TestIsGRPCPermanent(t *testing.T) {
 		t.Errorf("isGRPCPermanent(%v): got %v, want %v", codes.PermissionDenied, got, true)
 	}
 }
+
+func TestErrTransformerRetryInfo(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New()
+	if err != nil {
+		panic(err)
+	}
+
+	withInfo := func(code codes.Code, d time.Duration) error {
+		s, err := status.New(code, "test error").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(d)})
+		if err != nil {
+			panic(err)
+		}
+		return s.Err()
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		wantAfter bool
+		wantPerm  bool
+	}{
+		{
+			name: "Retriable code without RetryInfo",
+			err:  status.Error(codes.Unavailable, "test error"),
+		},
+		{
+			name:      "Retriable code with RetryInfo",
+			err:       withInfo(codes.ResourceExhausted, 10*time.Second),
+			wantAfter: true,
+		},
+		{
+			name:     "Permanent code with RetryInfo",
+			err:      withInfo(codes.PermissionDenied, 10*time.Second),
+			wantPerm: true,
+		},
+	}
+
+	for _, test := range tests {
+		start := time.Now()
+		got := tr.ErrTransformer(test.err)
+
+		ra := errors.ErrRetryAfter{}
+		gotAfter := errors.As(got, &ra)
+		if gotAfter != test.wantAfter {
+			t.Errorf("TestErrTransformerRetryInfo(%s): got ErrRetryAfter == %v, want %v", test.name, gotAfter, test.wantAfter)
+		}
+		if gotAfter && ra.Time.Before(start.Add(10*time.Second)) {
+			t.Errorf("TestErrTransformerRetryInfo(%s): ErrRetryAfter.Time was less than the RetryInfo delay", test.name)
+		}
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformerRetryInfo(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+		if status.Code(got) != status.Code(test.err) {
+			t.Errorf("TestErrTransformerRetryInfo(%s): lost the gRPC status code", test.name)
+		}
+	}
+}
+
+// fixedClock is an exponential.Clock whose time does not move.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time                             { return c.now }
+func (c fixedClock) NewTimer(d time.Duration) exponential.Timer { panic("not used") }
+func (c fixedClock) Until(t time.Time) time.Duration            { return t.Sub(c.now) }
+
+func TestErrTransformerClock(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(WithClock(nil)); err == nil {
+		t.Errorf("TestErrTransformerClock: New(WithClock(nil)): got err == nil, want err != nil")
+	}
+
+	clock := fixedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s, err := status.New(codes.Unavailable, "test error").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)})
+	if err != nil {
+		panic(err)
+	}
+
+	tr, err := New(WithClock(clock))
+	if err != nil {
+		panic(err)
+	}
+	boff, err := exponential.New(exponential.WithClock(clock))
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name      string
+		transform func(error) error
+	}{
+		{name: "WithClock", transform: tr.ErrTransformer},
+		{name: "Backoff clock", transform: func(err error) error { return tr.transform(err, boff.Now) }},
+	}
+
+	for _, test := range tests {
+		ra := errors.ErrRetryAfter{}
+		if !errors.As(test.transform(s.Err()), &ra) {
+			t.Errorf("TestErrTransformerClock(%s): got no ErrRetryAfter, want one", test.name)
+			continue
+		}
+		if want := clock.now.Add(time.Second); !ra.Time.Equal(want) {
+			t.Errorf("TestErrTransformerClock(%s): got ErrRetryAfter.Time == %v, want %v", test.name, ra.Time, want)
+		}
+	}
+}
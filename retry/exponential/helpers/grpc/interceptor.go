@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// interceptor holds the settings for UnaryClientInterceptor().
+type interceptor struct {
+	backoff     *exponential.Backoff
+	transformer *Transformer
+	attemptKey  string
+	onAttempt   OnAttempt
+	sc          *ServiceConfig
+}
+
+// InterceptorOption is an option for UnaryClientInterceptor().
+type InterceptorOption func(i *interceptor) error
+
+// WithTransformer sets the Transformer used to classify errors. If not set, a Transformer created
+// with New() and no options is used.
+func WithTransformer(t *Transformer) InterceptorOption {
+	return func(i *interceptor) error {
+		i.transformer = t
+		return nil
+	}
+}
+
+// WithAttemptMetadata adds the attempt number to the outgoing metadata of each call under key.
+// This allows a server to know a call is a retry.
+func WithAttemptMetadata(key string) InterceptorOption {
+	return func(i *interceptor) error {
+		i.attemptKey = key
+		return nil
+	}
+}
+
+// OnAttempt is called before each attempt of a call with the method name and the Record for the attempt.
+// The returned Context is used for the attempt, which allows adding outgoing metadata or call values.
+type OnAttempt func(ctx context.Context, method string, r exponential.Record) context.Context
+
+// WithOnAttempt sets a function that is called before each attempt.
+func WithOnAttempt(f OnAttempt) InterceptorOption {
+	return func(i *interceptor) error {
+		i.onAttempt = f
+		return nil
+	}
+}
+
+// WithServiceConfig uses the MethodConfig in sc that matches the method of a call instead of
+// the Backoff and Transformer passed to UnaryClientInterceptor(). Calls to methods without a
+// MethodConfig use the defaults. MethodConfig.MaxAttempts is enforced.
+func WithServiceConfig(sc *ServiceConfig) InterceptorOption {
+	return func(i *interceptor) error {
+		i.sc = sc
+		return nil
+	}
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that retries calls with backoff.
+// This allows retries to be installed once on a grpc.ClientConn with grpc.WithUnaryInterceptor()
+// instead of wrapping every call in Backoff.Retry(). The Context of each call bounds the retries, so
+// use a Context with a deadline. Errors are classified with the Transformer and replies that are
+// proto.Message(s) are checked with Transformer.RespToErr().
+func UnaryClientInterceptor(backoff *exponential.Backoff, options ...InterceptorOption) (grpc.UnaryClientInterceptor, error) {
+	if backoff == nil {
+		return nil, errors.New("UnaryClientInterceptor() cannot be passed a nil *exponential.Backoff")
+	}
+
+	i := &interceptor{backoff: backoff}
+	for _, o := range options {
+		if err := o(i); err != nil {
+			return nil, err
+		}
+	}
+	if i.transformer == nil {
+		t, err := New()
+		if err != nil {
+			return nil, err
+		}
+		i.transformer = t
+	}
+
+	return i.intercept, nil
+}
+
+// intercept implements grpc.UnaryClientInterceptor.
+func (i *interceptor) intercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	backoff, transformer, maxAttempts := i.backoff, i.transformer, 0
+	if i.sc != nil {
+		if mc, ok := i.sc.Method(method); ok {
+			backoff, transformer, maxAttempts = mc.Backoff, mc.Transformer, mc.MaxAttempts
+		}
+	}
+
+	return backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			if i.attemptKey != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, i.attemptKey, strconv.Itoa(r.Attempt))
+			}
+			if i.onAttempt != nil {
+				ctx = i.onAttempt(ctx, method, r)
+			}
+
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				if msg, ok := reply.(proto.Message); ok {
+					_, err = transformer.RespToErr(msg, nil)
+				}
+			}
+			if err == nil {
+				return nil
+			}
+			if maxAttempts > 0 && r.Attempt >= maxAttempts {
+				return fmt.Errorf("%w: max attempts(%d) reached: %w", err, maxAttempts, errors.ErrPermanent)
+			}
+			return transformer.transform(err, backoff.Now)
+		},
+	)
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+
+	if _, err := UnaryClientInterceptor(nil); err == nil {
+		t.Errorf("TestUnaryClientInterceptor: UnaryClientInterceptor(nil): got err == nil, want err != nil")
+	}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{
+			name:      "Success on first attempt",
+			wantCalls: 1,
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "Success after retriable errors",
+			errs:      []error{status.Error(codes.Unavailable, "unavailable"), status.Error(codes.Internal, "internal")},
+			wantCalls: 3,
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "Permanent error stops retries",
+			errs:      []error{status.Error(codes.Unavailable, "unavailable"), status.Error(codes.PermissionDenied, "denied")},
+			wantCalls: 2,
+			wantCode:  codes.PermissionDenied,
+		},
+	}
+
+	for _, test := range tests {
+		boff, err := exponential.New(exponential.WithTesting())
+		if err != nil {
+			panic(err)
+		}
+
+		hookCalls := 0
+		intercept, err := UnaryClientInterceptor(
+			boff,
+			WithAttemptMetadata("x-retry-attempt"),
+			WithOnAttempt(func(ctx context.Context, method string, r exponential.Record) context.Context {
+				hookCalls++
+				return ctx
+			}),
+		)
+		if err != nil {
+			panic(err)
+		}
+
+		calls := 0
+		invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			calls++
+			md, _ := metadata.FromOutgoingContext(ctx)
+			if got := md.Get("x-retry-attempt"); len(got) != 1 || got[0] != strconv.Itoa(calls) {
+				t.Errorf("TestUnaryClientInterceptor(%s): attempt metadata: got %v, want [%d]", test.name, got, calls)
+			}
+			if calls <= len(test.errs) {
+				return test.errs[calls-1]
+			}
+			return nil
+		}
+
+		err = intercept(context.Background(), "/service/Method", nil, nil, nil, invoker)
+		if calls != test.wantCalls {
+			t.Errorf("TestUnaryClientInterceptor(%s): got %d calls, want %d", test.name, calls, test.wantCalls)
+		}
+		if hookCalls != test.wantCalls {
+			t.Errorf("TestUnaryClientInterceptor(%s): got %d OnAttempt calls, want %d", test.name, hookCalls, test.wantCalls)
+		}
+		if code := status.Code(err); code != test.wantCode {
+			t.Errorf("TestUnaryClientInterceptor(%s): got code %v, want %v", test.name, code, test.wantCode)
+		}
+		if test.wantCode != codes.OK && !errors.Is(err, errors.ErrPermanent) {
+			t.Errorf("TestUnaryClientInterceptor(%s): got err %v, want permanent error", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+
+	"google.golang.org/grpc/codes"
+)
+
+// serviceConfigRandomizationFactor is the RandomizationFactor used for policies from a service config.
+// The service config format has no setting for this, so we use the same value as the default Policy.
+const serviceConfigRandomizationFactor = 0.5
+
+// maxServiceConfigAttempts is the limit gRPC puts on retryPolicy.maxAttempts. Larger values are
+// reduced to this.
+const maxServiceConfigAttempts = 5
+
+// MethodConfig is the retry configuration for a gRPC method derived from a service config.
+type MethodConfig struct {
+	// Policy is the Policy derived from the retryPolicy.
+	Policy exponential.Policy
+	// Backoff is a Backoff using Policy.
+	Backoff *exponential.Backoff
+	// Transformer is a Transformer that only retries the retryableStatusCodes.
+	Transformer *Transformer
+	// MaxAttempts is the maximum number of attempts, including the first attempt. Like gRPC, this is
+	// never more than 5.
+	MaxAttempts int
+}
+
+// ServiceConfig holds MethodConfigs parsed from a gRPC service config. Create with ParseServiceConfig().
+type ServiceConfig struct {
+	// methods is keyed by "/service/method" for a method, "/service/" for all methods in a service
+	// and "" for the default.
+	methods map[string]MethodConfig
+}
+
+// Method returns the MethodConfig for the full method name, such as "/package.Service/Method". This follows
+// the service config lookup rules: an exact method match is used first, then the service match and
+// finally the default config (a methodConfig with an empty name). If none match, this returns false.
+func (s *ServiceConfig) Method(fullMethod string) (MethodConfig, bool) {
+	if mc, ok := s.methods[fullMethod]; ok {
+		return mc, true
+	}
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		if mc, ok := s.methods[fullMethod[:i+1]]; ok {
+			return mc, true
+		}
+	}
+	mc, ok := s.methods[""]
+	return mc, ok
+}
+
+// scJSON is the subset of the gRPC service config JSON that we use.
+type scJSON struct {
+	MethodConfig []struct {
+		Name []struct {
+			Service string `json:"service"`
+			Method  string `json:"method"`
+		} `json:"name"`
+		RetryPolicy *struct {
+			MaxAttempts          int          `json:"maxAttempts"`
+			InitialBackoff       string       `json:"initialBackoff"`
+			MaxBackoff           string       `json:"maxBackoff"`
+			BackoffMultiplier    float64      `json:"backoffMultiplier"`
+			RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+		} `json:"retryPolicy"`
+	} `json:"methodConfig"`
+}
+
+// ParseServiceConfig parses the retryPolicy settings in a gRPC service config JSON document. This allows
+// retry configuration to be kept in the same format used by gRPC in other languages. See
+// https://github.com/grpc/proposal/blob/master/A6-client-retries.md for the format. Method configs without
+// a retryPolicy are ignored. A maxAttempts greater than 5 is treated as 5, as gRPC does. options are passed
+// to exponential.New() for each Backoff after the Policy.
+func ParseServiceConfig(b []byte, options ...exponential.Option) (*ServiceConfig, error) {
+	conf := scJSON{}
+	if err := json.Unmarshal(b, &conf); err != nil {
+		return nil, fmt.Errorf("service config is not valid JSON: %w", err)
+	}
+
+	sc := &ServiceConfig{methods: map[string]MethodConfig{}}
+	for _, m := range conf.MethodConfig {
+		rp := m.RetryPolicy
+		if rp == nil {
+			continue
+		}
+
+		if rp.MaxAttempts < 2 {
+			return nil, fmt.Errorf("retryPolicy.maxAttempts must be greater than 1, was %d", rp.MaxAttempts)
+		}
+		if len(rp.RetryableStatusCodes) == 0 {
+			return nil, fmt.Errorf("retryPolicy.retryableStatusCodes must not be empty")
+		}
+		initial, err := parseSCDuration(rp.InitialBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("retryPolicy.initialBackoff: %w", err)
+		}
+		max, err := parseSCDuration(rp.MaxBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("retryPolicy.maxBackoff: %w", err)
+		}
+
+		mc := MethodConfig{
+			Policy: exponential.Policy{
+				InitialInterval:     initial,
+				Multiplier:          rp.BackoffMultiplier,
+				RandomizationFactor: serviceConfigRandomizationFactor,
+				MaxInterval:         max,
+			},
+			MaxAttempts: min(rp.MaxAttempts, maxServiceConfigAttempts),
+		}
+		mc.Backoff, err = exponential.New(append([]exponential.Option{exponential.WithPolicy(mc.Policy)}, options...)...)
+		if err != nil {
+			return nil, err
+		}
+		mc.Transformer, err = New(WithRetriableCodes(rp.RetryableStatusCodes...))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(m.Name) == 0 {
+			sc.methods[""] = mc
+			continue
+		}
+		for _, n := range m.Name {
+			switch {
+			case n.Service == "" && n.Method == "":
+				sc.methods[""] = mc
+			case n.Service == "":
+				return nil, fmt.Errorf("methodConfig.name cannot have a method without a service")
+			default:
+				sc.methods["/"+n.Service+"/"+n.Method] = mc
+			}
+		}
+	}
+	return sc, nil
+}
+
+// parseSCDuration parses a service config duration, which is a decimal number of seconds followed by "s".
+func parseSCDuration(s string) (time.Duration, error) {
+	if !strings.HasSuffix(s, "s") {
+		return 0, fmt.Errorf("duration %q must end with 's'", s)
+	}
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("duration %q is not valid: %w", s, err)
+	}
+	return time.Duration(f * float64(time.Second)), nil
+}
@@ -0,0 +1,185 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/kylelemons/godebug/pretty"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testServiceConfig = `{
+	"methodConfig": [
+		{
+			"name": [{"service": "pkg.Greeter", "method": "SayHello"}],
+			"retryPolicy": {
+				"maxAttempts": 3,
+				"initialBackoff": "0.1s",
+				"maxBackoff": "1s",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE"]
+			}
+		},
+		{
+			"name": [{"service": "pkg.Greeter"}],
+			"retryPolicy": {
+				"maxAttempts": 7,
+				"initialBackoff": "1s",
+				"maxBackoff": "10s",
+				"backoffMultiplier": 1.5,
+				"retryableStatusCodes": ["UNAVAILABLE", 8]
+			}
+		},
+		{
+			"name": [{"service": "pkg.NoRetry"}]
+		}
+	]
+}`
+
+func TestParseServiceConfig(t *testing.T) {
+	t.Parallel()
+
+	sc, err := ParseServiceConfig([]byte(testServiceConfig))
+	if err != nil {
+		t.Fatalf("TestParseServiceConfig: unexpected error: %s", err)
+	}
+
+	tests := []struct {
+		name            string
+		method          string
+		wantOK          bool
+		wantPolicy      exponential.Policy
+		wantMaxAttempts int
+		wantRetriable   []codes.Code
+	}{
+		{
+			name:   "Exact method match",
+			method: "/pkg.Greeter/SayHello",
+			wantOK: true,
+			wantPolicy: exponential.Policy{
+				InitialInterval:     100 * time.Millisecond,
+				Multiplier:          2,
+				RandomizationFactor: serviceConfigRandomizationFactor,
+				MaxInterval:         time.Second,
+			},
+			wantMaxAttempts: 3,
+			wantRetriable:   []codes.Code{codes.Unavailable},
+		},
+		{
+			// maxAttempts of 7 is clamped to 5.
+			name:   "Service match",
+			method: "/pkg.Greeter/SayGoodbye",
+			wantOK: true,
+			wantPolicy: exponential.Policy{
+				InitialInterval:     time.Second,
+				Multiplier:          1.5,
+				RandomizationFactor: serviceConfigRandomizationFactor,
+				MaxInterval:         10 * time.Second,
+			},
+			wantMaxAttempts: 5,
+			wantRetriable:   []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+		},
+		{
+			name:   "No retryPolicy",
+			method: "/pkg.NoRetry/Call",
+		},
+		{
+			name:   "No match",
+			method: "/pkg.Other/Call",
+		},
+	}
+
+	for _, test := range tests {
+		mc, ok := sc.Method(test.method)
+		if ok != test.wantOK {
+			t.Errorf("TestParseServiceConfig(%s): got ok == %v, want %v", test.name, ok, test.wantOK)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if diff := pretty.Compare(test.wantPolicy, mc.Policy); diff != "" {
+			t.Errorf("TestParseServiceConfig(%s): Policy: -want/+got:\n%s", test.name, diff)
+		}
+		if mc.MaxAttempts != test.wantMaxAttempts {
+			t.Errorf("TestParseServiceConfig(%s): got MaxAttempts %d, want %d", test.name, mc.MaxAttempts, test.wantMaxAttempts)
+		}
+		for _, code := range test.wantRetriable {
+			if mc.Transformer.isGRPCPermanent(code) {
+				t.Errorf("TestParseServiceConfig(%s): code %v should be retriable", test.name, code)
+			}
+		}
+		if !mc.Transformer.isGRPCPermanent(codes.Internal) {
+			t.Errorf("TestParseServiceConfig(%s): code %v should not be retriable", test.name, codes.Internal)
+		}
+	}
+}
+
+func TestParseServiceConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		conf string
+	}{
+		{name: "Bad JSON", conf: `{`},
+		{
+			name: "maxAttempts too small",
+			conf: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 1, "initialBackoff": "1s", "maxBackoff": "2s", "backoffMultiplier": 2, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`,
+		},
+		{
+			name: "Bad duration",
+			conf: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1m", "maxBackoff": "2s", "backoffMultiplier": 2, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`,
+		},
+		{
+			name: "No retryable codes",
+			conf: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "2s", "backoffMultiplier": 2}}]}`,
+		},
+		{
+			name: "Invalid Policy",
+			conf: `{"methodConfig": [{"retryPolicy": {"maxAttempts": 2, "initialBackoff": "1s", "maxBackoff": "2s", "backoffMultiplier": 1, "retryableStatusCodes": ["UNAVAILABLE"]}}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseServiceConfig([]byte(test.conf)); err == nil {
+			t.Errorf("TestParseServiceConfigErrors(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
+
+func TestInterceptorWithServiceConfig(t *testing.T) {
+	t.Parallel()
+
+	sc, err := ParseServiceConfig([]byte(testServiceConfig), exponential.WithTesting())
+	if err != nil {
+		panic(err)
+	}
+	boff, err := exponential.New(exponential.WithTesting())
+	if err != nil {
+		panic(err)
+	}
+	intercept, err := UnaryClientInterceptor(boff, WithServiceConfig(sc))
+	if err != nil {
+		panic(err)
+	}
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+
+	err = intercept(context.Background(), "/pkg.Greeter/SayHello", nil, nil, nil, invoker)
+	if calls != 3 {
+		t.Errorf("TestInterceptorWithServiceConfig: got %d calls, want 3 (maxAttempts)", calls)
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("TestInterceptorWithServiceConfig: got code %v, want %v", status.Code(err), codes.Unavailable)
+	}
+}
@@ -2,76 +2,181 @@
 Package http provides an ErrTransformer for http.Client from the standard library.
 Other third-party HTTP clients are not supported by this package.
 
-Example that handle HTTP non-temporary error codes:
+New() used to be New(respToErrs ...RespToErr) *Transformer. It now takes Options and returns an error, so
+replace http.New(f) with http.New(http.WithRespToErrs(f)) and handle the error. A non-2xx status code is now
+an error from RespToErr() and Do().
 
-		httpTransform := http.New()
+When RespToErr() or Do() return an error with a Response, the Response Body has been read and closed, so the
+connection can be reused by the next attempt. Response.Body is replaced with what was read, up to
+MaxErrBody bytes, and does not need to be closed. On success, the caller must close the Body as usual.
 
-		backoff := exponential.WithErrTransformer(httpTransform)
-	    ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+Example that handle HTTP non-temporary errors and non-retriable status codes:
 
-	    var resp *http.Response
+	httpTransform, _ := http.New()
 
-	    err := backoff.Retry(
-	    	ctx,
-	     	func(ctx context.Context, r Record) error {
-	      		var err error
-	        	resp, err = httpClient.Do(someRequest)
-	         	return err
-	        },
-	    )
-	    cancel()
+	backoff, _ := exponential.New(exponential.WithErrTransformer(httpTransform.ErrTransformer))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 
-Example with custom errors:
+	var resp *http.Response
 
-		bodyHasErr := func(r *http.Response) error {
-	 		b, err :io.ReadAll(r.Body)
-	 		if err != nil {
-	 			return fmt.Errorf("response body had error: %s", err)
-	    	}
-
-			s := strings.TrimSpace(string(b))
-	 		if strings.HasPrefix(s, "error") {
-	 			if strings.Contains(s, "errors: permament") {
-	 				return fmt.Errorf("error: %w: %w", s, errors.ErrPermanent)
-	 			}
-	 			return fmt.Errorf("error: %s", s)
-			}
-	 		return nil
-	   }
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			var err error
+			resp, err = httpTransform.RespToErr(httpClient.Do(someRequest)) // <- note the call wrapper
+			return err
+		},
+	)
+	cancel()
+
+Example that also retries on a 404 status code:
+
+	httpTransform, err := http.New(http.WithRetriableStatusCodes(http.StatusNotFound))
+	if err != nil {
+		// Handle error
+	}
+	... // The rest is the same
 
-	   httpTransform := http.New(bodyHasErr)
+Example that only retries idempotent methods or requests with an Idempotency-Key header:
+
+	httpTransform, err := http.New(http.WithIdempotentOnly())
+	if err != nil {
+		// Handle error
+	}
+	...
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			var err error
+			resp, err = httpTransform.Do(httpClient, someRequest) // <- Do() can see the request method and headers
+			return err
+		},
+	)
+
+Example with custom errors:
 
-	   backoff := exponential.WithErrTransformer(httpTransform)
-	   ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	bodyHasErr := func(r *http.Response) error {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return fmt.Errorf("response body had error: %s", err)
+		}
 
-	   var resp *http.Response
+		s := strings.TrimSpace(string(b))
+		if strings.HasPrefix(s, "error") {
+			if strings.Contains(s, "errors: permament") {
+				return fmt.Errorf("error: %s: %w", s, errors.ErrPermanent)
+			}
+			return fmt.Errorf("error: %s", s)
+		}
+		return nil
+	}
 
-	   err := backoff.Retry(
-	   		ctx,
-	     	func(ctx context.Context, r Record) error {
-	      		var err error
-	        	resp, err = httpTransform.RespToErr(httpClient.Do(someRequest)) // <- note the call wrapper
-	         	return err
-	        },
-	    )
-	    cancel()
+	httpTransform, err := http.New(http.WithRespToErrs(bodyHasErr))
+	if err != nil {
+		// Handle error
+	}
+	... // The rest is the same
 */
 package http
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/gostdlib/ops/retry/internal/errors"
 )
 
-// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
-// The following codes are retriable: StatusRequestTimeout, StatusConflict, StatusLocked, StatusTooEarly,
-// StatusTooManyRequests, StatusInternalServerError and StatusGatewayTimeout.
-// Any other code is not.
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors and
+// a RespToErr method that converts http.Response status codes into errors.
+// A non-2xx status code is an error. The following 4xx codes are retriable: StatusRequestTimeout,
+// StatusTooEarly and StatusTooManyRequests. All 5xx codes are retriable. Any other code is permanent.
 type Transformer struct {
 	respToErrs []RespToErr
+	// extras are extra status codes that are retriable.
+	extras map[int]bool
+	// methods are the methods that can be retried. If nil, all methods can be retried.
+	methods map[string]bool
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithRetriableStatusCodes defines extra status codes that are considered retriable.
+func WithRetriableStatusCodes(codes ...int) Option {
+	return func(t *Transformer) error {
+		for _, code := range codes {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("WithRetriableStatusCodes(): %d is not a valid status code", code)
+			}
+			t.extras[code] = true
+		}
+		return nil
+	}
+}
+
+// WithRespToErrs passes functions that look at an http.Response to determine if the
+// Response actually indicates an error. These are run after the status code is checked.
+func WithRespToErrs(respToErrs ...RespToErr) Option {
+	return func(t *Transformer) error {
+		t.respToErrs = respToErrs
+		return nil
+	}
+}
+
+// IdempotencyKeyHeader is the header used to make a non-idempotent request, such as a POST, safe to retry.
+// See https://datatracker.ietf.org/doc/draft-ietf-httpapi-idempotency-key-header/ .
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentMethods are the methods that WithIdempotentOnly() allows by default.
+var idempotentMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPut,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// WithIdempotentOnly makes errors for requests that are not idempotent permanent. Blindly retrying a
+// POST can cause duplicate side effects. By default GET, HEAD, PUT, DELETE and OPTIONS are retriable.
+// Passing methods replaces that list. A request with any method is retriable if it has the
+// IdempotencyKeyHeader set. The request is only known when using Do() or when the http.Response
+// passed to RespToErr() has its Request field set, which http.Client always does.
+func WithIdempotentOnly(methods ...string) Option {
+	return func(t *Transformer) error {
+		if len(methods) == 0 {
+			methods = idempotentMethods
+		}
+		t.methods = make(map[string]bool, len(methods))
+		for _, m := range methods {
+			t.methods[strings.ToUpper(m)] = true
+		}
+		return nil
+	}
+}
+
+// MaxErrBody is the most bytes of a Response Body that are kept when RespToErr() returns an error.
+const MaxErrBody = 64 << 10
+
+// StatusError is returned by Transformer.RespToErr() when the http.Response has a non-2xx status code.
+type StatusError struct {
+	// Code is the http status code.
+	Code int
+	// Status is the http status line, such as "404 Not Found".
+	Status string
+	// Body is the start of the Response Body, up to MaxErrBody bytes.
+	Body string
+}
+
+// Error implements error.Error().
+func (s StatusError) Error() string {
+	if s.Status != "" {
+		return fmt.Sprintf("http response had status %s", s.Status)
+	}
+	return fmt.Sprintf("http response had status code %d", s.Code)
 }
 
 // RespToErr allows you to inspect a Response and determine if the result is really an error.
@@ -81,8 +186,17 @@ type Transformer struct {
 type RespToErr func(r *http.Response) error
 
 // New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
-func New(respToErrs ...RespToErr) *Transformer {
-	return &Transformer{respToErrs: respToErrs}
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{
+		extras: map[int]bool{},
+	}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
 }
 
 // ErrTransformer returns a transformer that can be used to detect non-retriable errors.
@@ -102,21 +216,64 @@ func (t *Transformer) ErrTransformer(err error) error {
 }
 
 // RespToErr takes an http.Resp and an error from an http.Client call method and returns the Response
-// and an error. If error != nil , this simply return the values passed. Otherwise it will inspect the
-// Response accord to rules passed to New() to determine if we have an error. It will always execute
-// all error RespToErr(s) unless the error returned is wrapped with ErrPermanent.
+// and an error. If error != nil , this simply return the values passed. Otherwise it will check the status
+// code and then inspect the Response accord to rules passed to New() to determine if we have an error.
+// A non-2xx status code returns a StatusError, which is wrapped with ErrPermanent if the code is not retriable.
+// It will always execute all error RespToErr(s) unless the error returned is wrapped with ErrPermanent.
 func (t *Transformer) RespToErr(r *http.Response, err error) (*http.Response, error) {
-	if len(t.respToErrs) == 0 {
-		return r, err
-	}
 	if err != nil {
 		return r, err
 	}
 
-	var retErr error
+	retErr := t.respToErr(r)
+	if r != nil {
+		retErr = t.idempotentErr(r.Request, retErr)
+	}
+	if retErr != nil {
+		bufferBody(r)
+	}
+	return r, retErr
+}
+
+// bufferBody reads up to MaxErrBody bytes of the Body of r, drains and closes it so the connection can be
+// reused, and replaces it with the bytes read. It returns the bytes read. If the Body was already replaced,
+// it is rewound so the caller can read it from the start. r may be nil.
+func bufferBody(r *http.Response) []byte {
+	if r == nil || r.Body == nil {
+		return nil
+	}
+
+	var b []byte
+	if eb, ok := r.Body.(errBody); ok {
+		b = eb.b
+	} else {
+		b, _ = io.ReadAll(io.LimitReader(r.Body, MaxErrBody))
+		io.Copy(io.Discard, r.Body)
+		r.Body.Close()
+	}
+	r.Body = errBody{Reader: bytes.NewReader(b), b: b}
+	return b
+}
+
+// errBody is a Response Body replaced by bufferBody().
+type errBody struct {
+	*bytes.Reader
+	b []byte
+}
+
+// Close implements io.Closer.
+func (errBody) Close() error { return nil }
+
+// respToErr checks the status code and runs the RespToErr(s) passed to New().
+func (t *Transformer) respToErr(r *http.Response) error {
+	retErr := t.statusToErr(r)
+	if errors.Is(retErr, errors.ErrPermanent) {
+		return retErr
+	}
+
 	for _, respToErr := range t.respToErrs {
 		wasPermanent := false
-		if err = respToErr(r); err != nil {
+		if err := respToErr(r); err != nil {
 			wasPermanent = errors.Is(err, errors.ErrPermanent)
 			if retErr == nil {
 				retErr = err
@@ -128,5 +285,68 @@ func (t *Transformer) RespToErr(r *http.Response, err error) (*http.Response, er
 			}
 		}
 	}
-	return r, retErr
+	return retErr
+}
+
+// statusToErr returns a StatusError if the Response has a non-2xx status code. If the code is not
+// retriable, it is wrapped with ErrPermanent.
+func (t *Transformer) statusToErr(r *http.Response) error {
+	if r == nil || r.StatusCode == 0 {
+		return nil
+	}
+	if r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+
+	// The Body is buffered before the RespToErr(s) run, so they can read it and it is kept in the StatusError.
+	err := StatusError{Code: r.StatusCode, Status: r.Status, Body: string(bufferBody(r))}
+	if t.isRetriable(r.StatusCode) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+}
+
+// httpRetriable is a list of 4xx status codes that are retriable.
+var httpRetriable = map[int]bool{
+	http.StatusRequestTimeout:  true,
+	http.StatusTooEarly:        true,
+	http.StatusTooManyRequests: true,
+}
+
+// isRetriable returns true if the status code is retriable.
+func (t *Transformer) isRetriable(code int) bool {
+	switch {
+	case code >= 500 && code < 600:
+		return true
+	case httpRetriable[code]:
+		return true
+	case t.extras[code]:
+		return true
+	}
+	return false
+}
+
+// Do calls client.Do(req) and passes the result through RespToErr(). Unlike ErrTransformer(), this
+// knows the request that caused a transport error, so WithIdempotentOnly() can honor the method and
+// IdempotencyKeyHeader in that case.
+func (t *Transformer) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return resp, t.idempotentErr(req, err)
+	}
+	return t.RespToErr(resp, nil)
+}
+
+// idempotentErr wraps err with ErrPermanent if WithIdempotentOnly() was used and req cannot be retried.
+func (t *Transformer) idempotentErr(req *http.Request, err error) error {
+	if err == nil || t.methods == nil || req == nil {
+		return err
+	}
+	if errors.Is(err, errors.ErrPermanent) {
+		return err
+	}
+	if t.methods[req.Method] || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return err
+	}
+	return fmt.Errorf("%w: request method %s is not idempotent: %w", err, req.Method, errors.ErrPermanent)
 }
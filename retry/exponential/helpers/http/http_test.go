@@ -2,8 +2,10 @@ package http
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gostdlib/ops/retry/internal/errors"
@@ -156,3 +158,183 @@ func TestRespToErr(t *testing.T) {
 		}
 	}
 }
+
+func TestRespToErrStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New(WithRetriableStatusCodes(http.StatusNotFound))
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name     string
+		code     int
+		wantErr  bool
+		wantPerm bool
+	}{
+		{name: "200 OK", code: http.StatusOK},
+		{name: "204 No Content", code: http.StatusNoContent},
+		{name: "400 Bad Request", code: http.StatusBadRequest, wantErr: true, wantPerm: true},
+		{name: "403 Forbidden", code: http.StatusForbidden, wantErr: true, wantPerm: true},
+		{name: "404 Not Found is an extra code", code: http.StatusNotFound, wantErr: true},
+		{name: "408 Request Timeout", code: http.StatusRequestTimeout, wantErr: true},
+		{name: "425 Too Early", code: http.StatusTooEarly, wantErr: true},
+		{name: "429 Too Many Requests", code: http.StatusTooManyRequests, wantErr: true},
+		{name: "500 Internal Server Error", code: http.StatusInternalServerError, wantErr: true},
+		{name: "503 Service Unavailable", code: http.StatusServiceUnavailable, wantErr: true},
+	}
+
+	for _, test := range tests {
+		_, err := tr.RespToErr(&http.Response{StatusCode: test.code}, nil)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRespToErrStatusCodes(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRespToErrStatusCodes(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err == nil:
+			continue
+		}
+		if errors.Is(err, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestRespToErrStatusCodes(%s): got permanent == %v, want %v", test.name, errors.Is(err, errors.ErrPermanent), test.wantPerm)
+		}
+		var se StatusError
+		if !errors.As(err, &se) || se.Code != test.code {
+			t.Errorf("TestRespToErrStatusCodes(%s): error did not contain StatusError with code %d", test.name, test.code)
+		}
+	}
+}
+
+func TestWithRetriableStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(WithRetriableStatusCodes(1000)); err == nil {
+		t.Errorf("TestWithRetriableStatusCodes: got err == nil, want err != nil for an invalid code")
+	}
+}
+
+func TestIdempotentOnly(t *testing.T) {
+	t.Parallel()
+
+	keyed := http.Header{}
+	keyed.Set(IdempotencyKeyHeader, "key")
+
+	tests := []struct {
+		name     string
+		options  []Option
+		req      *http.Request
+		wantPerm bool
+	}{
+		{
+			name: "Option not set, POST is retriable",
+			req:  &http.Request{Method: http.MethodPost},
+		},
+		{
+			name:    "GET is retriable",
+			options: []Option{WithIdempotentOnly()},
+			req:     &http.Request{Method: http.MethodGet},
+		},
+		{
+			name:     "POST is not retriable",
+			options:  []Option{WithIdempotentOnly()},
+			req:      &http.Request{Method: http.MethodPost},
+			wantPerm: true,
+		},
+		{
+			name:    "POST with Idempotency-Key is retriable",
+			options: []Option{WithIdempotentOnly()},
+			req:     &http.Request{Method: http.MethodPost, Header: keyed},
+		},
+		{
+			name:    "POST in override list is retriable",
+			options: []Option{WithIdempotentOnly("post")},
+			req:     &http.Request{Method: http.MethodPost},
+		},
+		{
+			name:     "GET not in override list is not retriable",
+			options:  []Option{WithIdempotentOnly(http.MethodPost)},
+			req:      &http.Request{Method: http.MethodGet},
+			wantPerm: true,
+		},
+	}
+
+	for _, test := range tests {
+		tr, err := New(test.options...)
+		if err != nil {
+			panic(err)
+		}
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Request: test.req}
+		_, err = tr.RespToErr(resp, nil)
+		if err == nil {
+			t.Errorf("TestIdempotentOnly(%s): got err == nil, want err != nil", test.name)
+			continue
+		}
+		if errors.Is(err, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestIdempotentOnly(%s): got permanent == %v, want %v", test.name, errors.Is(err, errors.ErrPermanent), test.wantPerm)
+		}
+	}
+}
+
+// trackBody is a Response Body that records if it was closed.
+type trackBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestRespToErrBody(t *testing.T) {
+	t.Parallel()
+
+	var seen string
+	readBody := func(r *http.Response) error {
+		b, err := io.ReadAll(r.Body)
+		seen = string(b)
+		return err
+	}
+	tr, err := New(WithRespToErrs(readBody))
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name       string
+		code       int
+		wantErr    bool
+		wantClosed bool
+	}{
+		{name: "Success leaves the Body to the caller", code: http.StatusOK},
+		{name: "Error status closes the Body", code: http.StatusServiceUnavailable, wantErr: true, wantClosed: true},
+	}
+
+	for _, test := range tests {
+		seen = ""
+		body := &trackBody{Reader: strings.NewReader("overloaded")}
+		resp, err := tr.RespToErr(&http.Response{StatusCode: test.code, Body: body}, nil)
+		if (err != nil) != test.wantErr {
+			t.Errorf("TestRespToErrBody(%s): got err == %v, want err != nil == %v", test.name, err, test.wantErr)
+		}
+		if body.closed != test.wantClosed {
+			t.Errorf("TestRespToErrBody(%s): got Body closed == %v, want %v", test.name, body.closed, test.wantClosed)
+		}
+		if seen != "overloaded" {
+			t.Errorf("TestRespToErrBody(%s): RespToErr got Body %q, want %q", test.name, seen, "overloaded")
+		}
+		if !test.wantErr {
+			continue
+		}
+
+		var se StatusError
+		if !errors.As(err, &se) || se.Body != "overloaded" {
+			t.Errorf("TestRespToErrBody(%s): got StatusError.Body %q, want %q", test.name, se.Body, "overloaded")
+		}
+		if b, _ := io.ReadAll(resp.Body); string(b) != "overloaded" {
+			t.Errorf("TestRespToErrBody(%s): got Response.Body %q, want %q", test.name, b, "overloaded")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Package k8s provides an exponential.ErrTransformer for errors returned by the Kubernetes API server
+through client-go. It is built on k8s.io/apimachinery/pkg/api/errors.
+
+Errors that are retriable: TooManyRequests, ServerTimeout, ServiceUnavailable, Timeout and InternalError.
+If the API server suggests a delay, such as with a Retry-After header, the error is wrapped with
+errors.ErrRetryAfter so the Backoff waits at least that long. Conflict errors are permanent unless
+WithRetryConflicts() is used. Errors that are permanent: Forbidden, Unauthorized, Invalid, BadRequest,
+NotFound, AlreadyExists, Gone, MethodNotSupported and RequestEntityTooLarge. Other errors are
+returned unchanged, which means they will be retried.
+
+Example updating an object where conflicts should be retried after re-reading the object:
+
+	k8sTransform, _ := k8s.New(k8s.WithRetryConflicts())
+
+	backoff, _ := exponential.New(exponential.WithErrTransformer(k8sTransform.ErrTransformer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			pod, err := client.CoreV1().Pods(ns).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			pod.Labels["updated"] = "true"
+			_, err = client.CoreV1().Pods(ns).Update(ctx, pod, metav1.UpdateOptions{})
+			return err
+		},
+	)
+	cancel()
+*/
+package k8s
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
+type Transformer struct {
+	retryConflicts bool
+	// clock is the Clock set with WithClock(). If nil, the time package is used.
+	clock exponential.Clock
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithRetryConflicts makes Conflict errors retriable. This is useful when the Op re-reads the object
+// before updating it, so a retry can succeed.
+func WithRetryConflicts() Option {
+	return func(t *Transformer) error {
+		t.retryConflicts = true
+		return nil
+	}
+}
+
+// WithClock sets the Clock used to set the time of an errors.ErrRetryAfter. Pass the Clock given to
+// exponential.WithClock() so the delay is measured with the same time as the Backoff.
+func WithClock(c exponential.Clock) Option {
+	return func(t *Transformer) error {
+		if c == nil {
+			return errors.New("WithClock() cannot be passed a nil Clock")
+		}
+		t.clock = c
+		return nil
+	}
+}
+
+// New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// ErrTransformer returns a transformer that can be used to detect non-retriable errors.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent. If the API server suggested
+// a delay, it will wrap the error with errors.ErrRetryAfter.
+func (t *Transformer) ErrTransformer(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if t.isPermanent(err) {
+		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+	}
+	if secs, ok := apierrors.SuggestsClientDelay(err); ok && secs > 0 {
+		return errors.ErrRetryAfter{Time: t.now().Add(time.Duration(secs) * time.Second), Err: err}
+	}
+	return err
+}
+
+// now returns the current time from the Clock.
+func (t *Transformer) now() time.Time {
+	if t.clock == nil {
+		return time.Now()
+	}
+	return t.clock.Now()
+}
+
+// isPermanent returns true if the error is an API error that is permanent.
+func (t *Transformer) isPermanent(err error) bool {
+	switch {
+	case apierrors.IsConflict(err):
+		return !t.retryConflicts
+	case apierrors.IsForbidden(err),
+		apierrors.IsUnauthorized(err),
+		apierrors.IsInvalid(err),
+		apierrors.IsBadRequest(err),
+		apierrors.IsNotFound(err),
+		apierrors.IsAlreadyExists(err),
+		apierrors.IsGone(err),
+		apierrors.IsMethodNotSupported(err),
+		apierrors.IsRequestEntityTooLargeError(err):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// fixedClock is an exponential.Clock whose time does not move.
+type fixedClock struct {
+	now time.Time
+}
+
+func (c fixedClock) Now() time.Time                             { return c.now }
+func (c fixedClock) NewTimer(d time.Duration) exponential.Timer { panic("not used") }
+func (c fixedClock) Until(t time.Time) time.Duration            { return t.Sub(c.now) }
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	clock := fixedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	gk := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	tests := []struct {
+		name      string
+		options   []Option
+		err       error
+		wantPerm  bool
+		wantAfter time.Duration
+	}{
+		{name: "Non-API error", err: fmt.Errorf("some error")},
+		{name: "TooManyRequests without delay", err: apierrors.NewTooManyRequests("slow down", 0)},
+		{name: "TooManyRequests with delay", err: apierrors.NewTooManyRequests("slow down", 5), wantAfter: 5 * time.Second},
+		{name: "ServerTimeout", err: apierrors.NewServerTimeout(gr, "get", 2), wantAfter: 2 * time.Second},
+		{name: "ServiceUnavailable", err: apierrors.NewServiceUnavailable("unavailable")},
+		{name: "InternalError", err: apierrors.NewInternalError(fmt.Errorf("boom"))},
+		{name: "Conflict is permanent by default", err: apierrors.NewConflict(gr, "name", fmt.Errorf("conflict")), wantPerm: true},
+		{
+			name:    "Conflict with WithRetryConflicts",
+			options: []Option{WithRetryConflicts()},
+			err:     apierrors.NewConflict(gr, "name", fmt.Errorf("conflict")),
+		},
+		{name: "Forbidden", err: apierrors.NewForbidden(gr, "name", fmt.Errorf("no")), wantPerm: true},
+		{name: "NotFound", err: apierrors.NewNotFound(gr, "name"), wantPerm: true},
+		{name: "Invalid", err: apierrors.NewInvalid(gk, "name", field.ErrorList{}), wantPerm: true},
+		{name: "Wrapped NotFound", err: fmt.Errorf("get: %w", apierrors.NewNotFound(gr, "name")), wantPerm: true},
+	}
+
+	for _, test := range tests {
+		tr, err := New(append([]Option{WithClock(clock)}, test.options...)...)
+		if err != nil {
+			panic(err)
+		}
+
+		got := tr.ErrTransformer(test.err)
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformer(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+
+		ra := errors.ErrRetryAfter{}
+		gotAfter := errors.As(got, &ra)
+		switch {
+		case gotAfter != (test.wantAfter > 0):
+			t.Errorf("TestErrTransformer(%s): got ErrRetryAfter == %v, want %v", test.name, gotAfter, test.wantAfter > 0)
+		case gotAfter && !ra.Time.Equal(clock.now.Add(test.wantAfter)):
+			t.Errorf("TestErrTransformer(%s): got ErrRetryAfter.Time == %v, want %v", test.name, ra.Time, clock.now.Add(test.wantAfter))
+		}
+	}
+
+	tr, _ := New()
+	if tr.ErrTransformer(nil) != nil {
+		t.Errorf("TestErrTransformer(nil): got err != nil")
+	}
+	if _, err := New(WithClock(nil)); err == nil {
+		t.Errorf("TestErrTransformer: WithClock(nil): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Package net provides an exponential.ErrTransformer for errors from the standard library net package.
+This is the lowest level transformer and is useful for raw network clients or before a higher level
+transformer such as the http one.
+
+Errors that are retriable: timeouts, temporary DNS failures, connection refused, connection reset and
+other dial, read and write failures in a *net.OpError. Errors that are permanent: DNS names that do not
+exist (NXDOMAIN), address parsing errors (*net.AddrError, *net.ParseError, net.InvalidAddrError) and
+unknown networks (net.UnknownNetworkError). Other errors are returned unchanged, which means they will
+be retried.
+
+Example using just defaults:
+
+	netTransform, _ := net.New()
+
+	backoff, _ := exponential.New(exponential.WithErrTransformer(netTransform.ErrTransformer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+
+	var conn net.Conn
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			var err error
+			conn, err = dialer.DialContext(ctx, "tcp", "service.internal:8080")
+			return err
+		},
+	)
+	cancel()
+*/
+package net
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/gostdlib/ops/retry/internal/errors"
+)
+
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
+type Transformer struct {
+	retryNotFound bool
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithRetryNotFound makes DNS names that do not exist retriable. This is useful when waiting for
+// a DNS record to be created, such as a service that is starting.
+func WithRetryNotFound() Option {
+	return func(t *Transformer) error {
+		t.retryNotFound = true
+		return nil
+	}
+}
+
+// New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// ErrTransformer returns a transformer that can be used to detect non-retriable errors.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent.
+func (t *Transformer) ErrTransformer(err error) error {
+	if err == nil {
+		return nil
+	}
+	if t.isPermanent(err) {
+		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+	}
+	return err
+}
+
+// isPermanent returns true if the error is a net error that is permanent.
+func (t *Transformer) isPermanent(err error) bool {
+	// Timeouts are always retriable, no matter what type of error carries them.
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsNotFound {
+			return !t.retryNotFound
+		}
+		return false
+	}
+
+	var addrErr *net.AddrError
+	if errors.As(err, &addrErr) {
+		return true
+	}
+	var parseErr *net.ParseError
+	if errors.As(err, &parseErr) {
+		return true
+	}
+	var invalidAddr net.InvalidAddrError
+	if errors.As(err, &invalidAddr) {
+		return true
+	}
+	var unknownNet net.UnknownNetworkError
+	if errors.As(err, &unknownNet) {
+		return true
+	}
+
+	// Everything else, such as a *net.OpError wrapping ECONNREFUSED or ECONNRESET, is retriable.
+	return false
+}
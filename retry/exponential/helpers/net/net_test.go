@@ -0,0 +1,84 @@
+package net
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/internal/errors"
+)
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  []Option
+		err      error
+		wantPerm bool
+	}{
+		{name: "Unknown error", err: fmt.Errorf("some error")},
+		{
+			name: "Connection refused",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+		},
+		{
+			name: "Connection reset",
+			err:  &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+		},
+		{
+			name: "Timeout",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "timeout", IsTimeout: true}},
+		},
+		{name: "Temporary DNS failure", err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}},
+		{name: "DNS NXDOMAIN", err: &net.DNSError{Err: "no such host", IsNotFound: true}, wantPerm: true},
+		{
+			name:    "DNS NXDOMAIN with WithRetryNotFound",
+			options: []Option{WithRetryNotFound()},
+			err:     &net.DNSError{Err: "no such host", IsNotFound: true},
+		},
+		{
+			name:     "Wrapped DNS NXDOMAIN",
+			err:      &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", IsNotFound: true}},
+			wantPerm: true,
+		},
+		{name: "AddrError", err: &net.AddrError{Err: "missing port in address", Addr: "host"}, wantPerm: true},
+		{name: "ParseError", err: &net.ParseError{Type: "IP address", Text: "nope"}, wantPerm: true},
+		{name: "UnknownNetworkError", err: net.UnknownNetworkError("tcp5"), wantPerm: true},
+	}
+
+	for _, test := range tests {
+		tr, err := New(test.options...)
+		if err != nil {
+			panic(err)
+		}
+
+		got := tr.ErrTransformer(test.err)
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformer(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+	}
+
+	tr, _ := New()
+	if tr.ErrTransformer(nil) != nil {
+		t.Errorf("TestErrTransformer(nil): got err != nil")
+	}
+}
+
+func TestErrTransformerRealErrors(t *testing.T) {
+	t.Parallel()
+
+	tr, _ := New()
+
+	_, err := net.Dial("tcp", "no-port")
+	if !errors.Is(tr.ErrTransformer(err), errors.ErrPermanent) {
+		t.Errorf("TestErrTransformerRealErrors(missing port): got %v, want permanent error", err)
+	}
+
+	_, err = net.Dial("tcp5", "127.0.0.1:80")
+	if !errors.Is(tr.ErrTransformer(err), errors.ErrPermanent) {
+		t.Errorf("TestErrTransformerRealErrors(unknown network): got %v, want permanent error", err)
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Package oauth provides an exponential.ErrTransformer for OAuth2 token failures, such as those returned
+by golang.org/x/oauth2 or by a resource server that rejected an access token.
+
+Errors for expired or revoked access tokens (the invalid_token error code or ErrTokenExpired) are permanent
+unless a Refresh function is provided with WithRefresh() and the Op is wrapped with Transformer.Op(). The
+wrapped Op calls the Refresh with the Context of the attempt after an attempt fails with an expired token,
+and the error is retriable if the refresh succeeds. At most one refresh is made per Retry() call, which can
+be changed with WithMaxRefreshes().
+
+The invalid_grant error code means the grant used to get a token, such as a refresh token, is invalid, so
+it is permanent. Use WithRefreshOnInvalidGrant() to treat it as an expired token when the Refresh can get a
+new grant, such as by authenticating again. Errors for a misconfigured client (invalid_client,
+invalid_scope, invalid_request, unauthorized_client and unsupported_grant_type) are always permanent. The
+server_error and temporarily_unavailable error codes are retriable. Other errors are returned unchanged,
+which means they will be retried.
+
+Example refreshing a cached token when the server says it is no longer valid:
+
+	oauthTransform, _ := oauth.New(
+		oauth.WithRefresh(func(ctx context.Context) error {
+			return tokenCache.Refresh(ctx)
+		}),
+	)
+
+	backoff, _ := exponential.New(exponential.WithErrTransformer(oauthTransform.ErrTransformer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := backoff.Retry(
+		ctx,
+		oauthTransform.Op(func(ctx context.Context, r exponential.Record) error {
+			resp, err := callService(ctx, tokenCache.Token())
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode == http.StatusUnauthorized {
+				return fmt.Errorf("call rejected: %w", oauth.ErrTokenExpired)
+			}
+			return nil
+		}),
+	)
+	cancel()
+*/
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrTokenExpired can be wrapped by an Op to indicate that an access token was rejected because it
+// expired or was revoked, such as when a resource server returns a 401 with error="invalid_token".
+var ErrTokenExpired = errors.New("oauth token expired")
+
+// errRefreshed is wrapped by Transformer.Op() around an expired token error after a successful refresh.
+var errRefreshed = errors.New("oauth token refreshed")
+
+// Refresh refreshes a token. It is called by an Op wrapped with Transformer.Op() after an attempt fails
+// because a token is expired or revoked. ctx is the Context of the attempt, so the refresh is bounded by the
+// Context passed to Retry(). If it returns an error, the retries stop.
+type Refresh func(ctx context.Context) error
+
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
+type Transformer struct {
+	refresh Refresh
+	// maxRefreshes is the number of refreshes allowed per Retry() call.
+	maxRefreshes int
+	// grantExpired treats the invalid_grant error code as an expired token. Set with WithRefreshOnInvalidGrant().
+	grantExpired bool
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithRefresh sets a function that is called to refresh a token when a token is expired or revoked.
+// It is only called by an Op wrapped with Transformer.Op(). If the function succeeds, the error is
+// retriable. The Transformer may be used concurrently by multiple Retry() calls, so the Refresh must be
+// safe for concurrent use.
+func WithRefresh(f Refresh) Option {
+	return func(t *Transformer) error {
+		if f == nil {
+			return fmt.Errorf("WithRefresh() cannot be passed a nil function")
+		}
+		t.refresh = f
+		return nil
+	}
+}
+
+// WithMaxRefreshes sets the number of times the Refresh may be called per Retry() call. If an attempt fails
+// with an expired token after that, the error is permanent. Defaults to 1.
+func WithMaxRefreshes(n int) Option {
+	return func(t *Transformer) error {
+		if n < 1 {
+			return fmt.Errorf("WithMaxRefreshes(%d) must be > 0", n)
+		}
+		t.maxRefreshes = n
+		return nil
+	}
+}
+
+// WithRefreshOnInvalidGrant treats the invalid_grant error code as an expired token, so the Refresh is called.
+// Only use this if the Refresh gets a new grant instead of using the one the server rejected.
+func WithRefreshOnInvalidGrant() Option {
+	return func(t *Transformer) error {
+		t.grantExpired = true
+		return nil
+	}
+}
+
+// New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{maxRefreshes: 1}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// kind is the classification of an OAuth error.
+type kind uint8
+
+const (
+	kUnknown   kind = 0
+	kExpired   kind = 1
+	kPermanent kind = 2
+	kRetriable kind = 3
+)
+
+// errorCodes maps RFC 6749 and RFC 6750 error codes to a kind.
+var errorCodes = map[string]kind{
+	"invalid_grant":           kPermanent,
+	"invalid_token":           kExpired,
+	"invalid_client":          kPermanent,
+	"invalid_scope":           kPermanent,
+	"invalid_request":         kPermanent,
+	"unauthorized_client":     kPermanent,
+	"unsupported_grant_type":  kPermanent,
+	"access_denied":           kPermanent,
+	"server_error":            kRetriable,
+	"temporarily_unavailable": kRetriable,
+}
+
+// ErrTransformer returns a transformer that can be used to detect non-retriable errors.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent. An expired token is only
+// retriable if the Op was wrapped with Transformer.Op() and the token was refreshed.
+func (t *Transformer) ErrTransformer(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch t.classify(err) {
+	case kExpired:
+		if errors.Is(err, errRefreshed) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+	case kPermanent:
+		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+	}
+	return err
+}
+
+// Op wraps op so that when an attempt fails with an expired token, the Refresh set with WithRefresh() is
+// called with the Context of the attempt before the error is returned. A new Op must be created for each
+// Retry() call, as it counts the refreshes for WithMaxRefreshes(). If WithRefresh() was not used, op is
+// returned.
+func (t *Transformer) Op(op exponential.Op) exponential.Op {
+	if t.refresh == nil {
+		return op
+	}
+
+	refreshes := 0
+	return func(ctx context.Context, r exponential.Record) error {
+		err := op(ctx, r)
+		if err == nil || t.classify(err) != kExpired {
+			return err
+		}
+		if refreshes >= t.maxRefreshes {
+			return fmt.Errorf("%w: token still expired after %d refreshes: %w", err, refreshes, errors.ErrPermanent)
+		}
+		refreshes++
+		if rErr := t.refresh(ctx); rErr != nil {
+			return fmt.Errorf("%w: token refresh failed(%s): %w", err, rErr, errors.ErrPermanent)
+		}
+		return fmt.Errorf("%w: %w", err, errRefreshed)
+	}
+}
+
+// classify returns the kind of error.
+func (t *Transformer) classify(err error) kind {
+	if errors.Is(err, ErrTokenExpired) {
+		return kExpired
+	}
+
+	var re *oauth2.RetrieveError
+	if errors.As(err, &re) {
+		if re.ErrorCode == "invalid_grant" && t.grantExpired {
+			return kExpired
+		}
+		if k, ok := errorCodes[re.ErrorCode]; ok {
+			return k
+		}
+		return kUnknown
+	}
+
+	// golang.org/x/oauth2 returns an unexported error when a token expired and cannot be refreshed.
+	if strings.Contains(err.Error(), "oauth2: token expired") {
+		return kExpired
+	}
+	return kUnknown
+}
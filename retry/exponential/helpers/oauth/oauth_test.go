@@ -0,0 +1,168 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+
+	"golang.org/x/oauth2"
+)
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		options  []Option
+		err      error
+		wantPerm bool
+	}{
+		{name: "Unknown error", err: fmt.Errorf("some error")},
+		{name: "server_error", err: &oauth2.RetrieveError{ErrorCode: "server_error"}},
+		{name: "invalid_client", err: &oauth2.RetrieveError{ErrorCode: "invalid_client"}, wantPerm: true},
+		{name: "invalid_scope", err: fmt.Errorf("token: %w", &oauth2.RetrieveError{ErrorCode: "invalid_scope"}), wantPerm: true},
+		{name: "invalid_grant", err: &oauth2.RetrieveError{ErrorCode: "invalid_grant"}, wantPerm: true},
+		{
+			name:     "invalid_grant with WithRefreshOnInvalidGrant() is expired",
+			options:  []Option{WithRefreshOnInvalidGrant()},
+			err:      &oauth2.RetrieveError{ErrorCode: "invalid_grant"},
+			wantPerm: true,
+		},
+		{name: "ErrTokenExpired without a refresh", err: fmt.Errorf("call: %w", ErrTokenExpired), wantPerm: true},
+		{name: "ErrTokenExpired after a refresh", err: fmt.Errorf("call: %w: %w", ErrTokenExpired, errRefreshed)},
+		{
+			name:     "oauth2 token expired message",
+			err:      fmt.Errorf("oauth2: token expired and refresh token is not set"),
+			wantPerm: true,
+		},
+	}
+
+	for _, test := range tests {
+		tr, err := New(test.options...)
+		if err != nil {
+			panic(err)
+		}
+
+		got := tr.ErrTransformer(test.err)
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformer(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+	}
+
+	tr, _ := New()
+	if tr.ErrTransformer(nil) != nil {
+		t.Errorf("TestErrTransformer(nil): got err != nil")
+	}
+	if _, err := New(WithRefresh(nil)); err == nil {
+		t.Errorf("TestErrTransformer: WithRefresh(nil): got err == nil, want err != nil")
+	}
+	if _, err := New(WithMaxRefreshes(0)); err == nil {
+		t.Errorf("TestErrTransformer: WithMaxRefreshes(0): got err == nil, want err != nil")
+	}
+}
+
+type ctxKey struct{}
+
+func TestOp(t *testing.T) {
+	t.Parallel()
+
+	grant := &oauth2.RetrieveError{ErrorCode: "invalid_grant"}
+
+	tests := []struct {
+		name          string
+		options       []Option
+		refreshErr    error
+		errs          []error
+		wantErr       bool
+		wantCalls     int
+		wantRefreshes int
+	}{
+		{
+			name:          "Expired token is refreshed and retried",
+			errs:          []error{ErrTokenExpired},
+			wantCalls:     2,
+			wantRefreshes: 1,
+		},
+		{
+			name:          "Failed refresh is permanent",
+			refreshErr:    fmt.Errorf("refresh failed"),
+			errs:          []error{ErrTokenExpired},
+			wantErr:       true,
+			wantCalls:     1,
+			wantRefreshes: 1,
+		},
+		{
+			name:          "Refreshes are limited",
+			errs:          []error{ErrTokenExpired, ErrTokenExpired, ErrTokenExpired},
+			wantErr:       true,
+			wantCalls:     2,
+			wantRefreshes: 1,
+		},
+		{
+			name:          "WithMaxRefreshes",
+			options:       []Option{WithMaxRefreshes(2)},
+			errs:          []error{ErrTokenExpired, ErrTokenExpired},
+			wantCalls:     3,
+			wantRefreshes: 2,
+		},
+		{
+			name:      "invalid_grant is not refreshed",
+			errs:      []error{grant},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:          "invalid_grant with WithRefreshOnInvalidGrant()",
+			options:       []Option{WithRefreshOnInvalidGrant()},
+			errs:          []error{grant},
+			wantCalls:     2,
+			wantRefreshes: 1,
+		},
+	}
+
+	for _, test := range tests {
+		refreshes := 0
+		refresh := func(ctx context.Context) error {
+			if ctx.Value(ctxKey{}) == nil {
+				t.Errorf("TestOp(%s): Refresh was not passed the Context of the attempt", test.name)
+			}
+			refreshes++
+			return test.refreshErr
+		}
+		tr, err := New(append([]Option{WithRefresh(refresh)}, test.options...)...)
+		if err != nil {
+			panic(err)
+		}
+		boff, err := exponential.New(exponential.WithTesting(), exponential.WithErrTransformer(tr.ErrTransformer))
+		if err != nil {
+			panic(err)
+		}
+
+		calls := 0
+		op := func(ctx context.Context, r exponential.Record) error {
+			calls++
+			if calls <= len(test.errs) {
+				return test.errs[calls-1]
+			}
+			return nil
+		}
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, true)
+		err = boff.Retry(ctx, tr.Op(op))
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestOp(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestOp(%s): got err == %s, want err == nil", test.name, err)
+		}
+		if calls != test.wantCalls {
+			t.Errorf("TestOp(%s): got %d calls, want %d", test.name, calls, test.wantCalls)
+		}
+		if refreshes != test.wantRefreshes {
+			t.Errorf("TestOp(%s): got %d refreshes, want %d", test.name, refreshes, test.wantRefreshes)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Package redis provides an exponential.ErrTransformer for errors returned by Redis clients such as
+github.com/redis/go-redis and github.com/redis/rueidis. Redis errors are identified by the error
+prefix sent by the server, so this package does not depend on any client.
+
+Errors that are retriable: LOADING, CLUSTERDOWN, TRYAGAIN, MASTERDOWN, READONLY, BUSY, MOVED, ASK and
+network timeouts. Errors that are permanent: WRONGTYPE, NOSCRIPT, NOAUTH, WRONGPASS, NOPERM, syntax
+errors, unknown commands, wrong number of arguments and nil replies (the key does not exist).
+Other errors are returned unchanged, which means they will be retried.
+
+Example using just defaults:
+
+	redisTransform, _ := redis.New()
+
+	backoff, _ := exponential.New(exponential.WithErrTransformer(redisTransform.ErrTransformer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	var val string
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			var err error
+			val, err = client.Get(ctx, "key").Result()
+			return err
+		},
+	)
+	cancel()
+
+Example following MOVED and ASK redirects with a client you manage:
+
+	var addr atomic.Pointer[string]
+
+	redisTransform, _ := redis.New(
+		redis.WithRetarget(func(r redis.Redirect) {
+			addr.Store(&r.Addr)
+		}),
+	)
+*/
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gostdlib/ops/retry/internal/errors"
+)
+
+// Redirect is a MOVED or ASK redirect sent by a Redis cluster.
+type Redirect struct {
+	// Ask is true if this was an ASK redirect, false if this was a MOVED redirect.
+	Ask bool
+	// Slot is the hash slot that was redirected.
+	Slot int
+	// Addr is the address of the node that serves the slot.
+	Addr string
+}
+
+// Retarget is called with a Redirect when an error is a MOVED or ASK redirect. This is called
+// between attempts, before the next attempt is made.
+type Retarget func(r Redirect)
+
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
+type Transformer struct {
+	retarget Retarget
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithRetarget sets a function that is called when a MOVED or ASK redirect is received. This allows
+// the Op to send the next attempt to the right node when the client does not follow redirects itself.
+func WithRetarget(f Retarget) Option {
+	return func(t *Transformer) error {
+		if f == nil {
+			return fmt.Errorf("WithRetarget() cannot be passed a nil function")
+		}
+		t.retarget = f
+		return nil
+	}
+}
+
+// New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// permanentPrefixes are the error prefixes that are permanent.
+var permanentPrefixes = []string{
+	"WRONGTYPE",
+	"NOSCRIPT",
+	"NOAUTH",
+	"WRONGPASS",
+	"NOPERM",
+	"ERR syntax error",
+	"ERR unknown command",
+	"ERR wrong number of arguments",
+	"redis: nil",        // go-redis redis.Nil
+	"redis nil message", // rueidis Nil
+}
+
+// ErrTransformer returns a transformer that can be used to detect non-retriable errors.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent. If the error is a MOVED
+// or ASK redirect and WithRetarget() was used, the Retarget function is called.
+func (t *Transformer) ErrTransformer(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		msg := e.Error()
+		for _, prefix := range permanentPrefixes {
+			if strings.HasPrefix(msg, prefix) {
+				return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+			}
+		}
+		if r, ok := parseRedirect(msg); ok {
+			if t.retarget != nil {
+				t.retarget(r)
+			}
+			return err
+		}
+	}
+	// Everything else, including network timeouts and the retriable server errors, is retried.
+	return err
+}
+
+// parseRedirect parses a MOVED or ASK error message, which looks like "MOVED 3999 127.0.0.1:6381".
+func parseRedirect(msg string) (Redirect, bool) {
+	var r Redirect
+	switch {
+	case strings.HasPrefix(msg, "MOVED "):
+	case strings.HasPrefix(msg, "ASK "):
+		r.Ask = true
+	default:
+		return Redirect{}, false
+	}
+
+	fields := strings.Fields(msg)
+	if len(fields) != 3 {
+		return Redirect{}, false
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Redirect{}, false
+	}
+	r.Slot = slot
+	r.Addr = fields[2]
+	return r, true
+}
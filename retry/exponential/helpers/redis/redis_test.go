@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/internal/errors"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// redisError mimics the go-redis proto.RedisError type.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		err          error
+		wantPerm     bool
+		wantRedirect *Redirect
+	}{
+		{name: "Unknown error", err: fmt.Errorf("some error")},
+		{name: "LOADING", err: redisError("LOADING Redis is loading the dataset in memory")},
+		{name: "CLUSTERDOWN", err: redisError("CLUSTERDOWN The cluster is down")},
+		{name: "TRYAGAIN", err: redisError("TRYAGAIN Multiple keys request during rehashing of slot")},
+		{
+			name:         "MOVED",
+			err:          redisError("MOVED 3999 127.0.0.1:6381"),
+			wantRedirect: &Redirect{Slot: 3999, Addr: "127.0.0.1:6381"},
+		},
+		{
+			name:         "Wrapped ASK",
+			err:          fmt.Errorf("get: %w", redisError("ASK 3999 127.0.0.1:6381")),
+			wantRedirect: &Redirect{Ask: true, Slot: 3999, Addr: "127.0.0.1:6381"},
+		},
+		{name: "WRONGTYPE", err: redisError("WRONGTYPE Operation against a key holding the wrong kind of value"), wantPerm: true},
+		{name: "Syntax error", err: fmt.Errorf("set: %w", redisError("ERR syntax error")), wantPerm: true},
+		{name: "go-redis Nil", err: redisError("redis: nil"), wantPerm: true},
+	}
+
+	for _, test := range tests {
+		var gotRedirect *Redirect
+		tr, err := New(WithRetarget(func(r Redirect) { gotRedirect = &r }))
+		if err != nil {
+			panic(err)
+		}
+
+		got := tr.ErrTransformer(test.err)
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformer(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+		if diff := pretty.Compare(test.wantRedirect, gotRedirect); diff != "" {
+			t.Errorf("TestErrTransformer(%s): Redirect: -want/+got:\n%s", test.name, diff)
+		}
+	}
+
+	tr, _ := New()
+	if tr.ErrTransformer(nil) != nil {
+		t.Errorf("TestErrTransformer(nil): got err != nil")
+	}
+	if _, err := New(WithRetarget(nil)); err == nil {
+		t.Errorf("TestErrTransformer: WithRetarget(nil): got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Package sql provides an exponential.ErrTransformer for database/sql from the standard library.
+This detects errors from the common Postgres drivers (github.com/jackc/pgx and github.com/lib/pq) and
+the MySQL driver (github.com/go-sql-driver/mysql) without importing them.
+
+Errors that are retriable: driver.ErrBadConn, serialization failures, deadlocks, lock timeouts, connection
+errors and resource exhaustion such as too many connections. Errors that are permanent: constraint
+violations, syntax errors, access errors, data errors, sql.ErrNoRows and sql.ErrTxDone. Other errors
+are returned unchanged, which means they will be retried.
+
+Example using just defaults:
+
+	sqlTransform, _ := sql.New()
+
+	backoff, _ := exponential.New(exponential.WithErrTransformer(sqlTransform.ErrTransformer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	var name string
+	err := backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			return db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = $1", id).Scan(&name)
+		},
+	)
+	cancel()
+
+Example retrying a transaction that has serialization conflicts:
+
+	err := sqlTransform.RetryTx(
+		ctx,
+		backoff,
+		db,
+		&sql.TxOptions{Isolation: sql.LevelSerializable},
+		func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2", amount, id)
+			return err
+		},
+	)
+*/
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+)
+
+// Transformer provides an ErrTransformer method that can be used to detect non-retriable errors.
+type Transformer struct {
+	// extras are extra SQLSTATE codes that are retriable.
+	extras map[string]bool
+}
+
+// Option is an option for the New() constructor.
+type Option func(t *Transformer) error
+
+// WithExtraStates defines extra SQLSTATE codes, such as "23505", that are considered retriable.
+func WithExtraStates(states ...string) Option {
+	return func(t *Transformer) error {
+		for _, state := range states {
+			if len(state) != 5 {
+				return fmt.Errorf("WithExtraStates(): %q is not a valid SQLSTATE", state)
+			}
+			t.extras[state] = true
+		}
+		return nil
+	}
+}
+
+// New returns a new Transformer. This implements exponential.ErrTransformer with the method ErrTransformer.
+func New(options ...Option) (*Transformer, error) {
+	t := &Transformer{
+		extras: map[string]bool{},
+	}
+
+	for _, o := range options {
+		if err := o(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// ErrTransformer returns a transformer that can be used to detect non-retriable errors.
+// If it is non-retriable it will wrap the error with errors.ErrPermanent.
+func (t *Transformer) ErrTransformer(err error) error {
+	if err == nil {
+		return nil
+	}
+	if t.isPermanent(err) {
+		return fmt.Errorf("%w: %w", err, errors.ErrPermanent)
+	}
+	return err
+}
+
+// isPermanent returns true if the error is known to be permanent.
+func (t *Transformer) isPermanent(err error) bool {
+	switch {
+	case errors.Is(err, driver.ErrBadConn):
+		return false
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, sql.ErrTxDone):
+		return true
+	}
+
+	if state, ok := sqlState(err); ok {
+		if t.extras[state] {
+			return false
+		}
+		return permanentStates[state[:2]]
+	}
+	if num, ok := mysqlNumber(err); ok {
+		return mysqlPermanent[num]
+	}
+	return false
+}
+
+// permanentStates are the SQLSTATE classes (the first two characters) that are permanent.
+// Classes not listed here, such as 40 (transaction rollback, which includes serialization failures
+// and deadlocks), 08 (connection exception), 53 (insufficient resources) and 55 (object not in
+// prerequisite state, which includes lock timeouts) are retriable.
+var permanentStates = map[string]bool{
+	"0A": true, // feature not supported
+	"22": true, // data exception
+	"23": true, // integrity constraint violation
+	"28": true, // invalid authorization specification
+	"2B": true, // dependent privilege descriptors still exist
+	"3D": true, // invalid catalog name
+	"3F": true, // invalid schema name
+	"42": true, // syntax error or access rule violation
+	"44": true, // with check option violation
+}
+
+// mysqlPermanent are the MySQL error numbers that are permanent. Deadlocks(1213) and lock wait
+// timeouts(1205) are not listed, so they are retriable.
+var mysqlPermanent = map[uint16]bool{
+	1044: true, // ER_DBACCESS_DENIED_ERROR
+	1045: true, // ER_ACCESS_DENIED_ERROR
+	1048: true, // ER_BAD_NULL_ERROR
+	1049: true, // ER_BAD_DB_ERROR
+	1054: true, // ER_BAD_FIELD_ERROR
+	1062: true, // ER_DUP_ENTRY
+	1064: true, // ER_PARSE_ERROR
+	1146: true, // ER_NO_SUCH_TABLE
+	1216: true, // ER_NO_REFERENCED_ROW
+	1217: true, // ER_ROW_IS_REFERENCED
+	1264: true, // ER_WARN_DATA_OUT_OF_RANGE
+	1406: true, // ER_DATA_TOO_LONG
+	1451: true, // ER_ROW_IS_REFERENCED_2
+	1452: true, // ER_NO_REFERENCED_ROW_2
+	3819: true, // ER_CHECK_CONSTRAINT_VIOLATED
+}
+
+// sqlStater is implemented by the error types of pgx (*pgconn.PgError) and lib/pq (*pq.Error).
+type sqlStater interface {
+	SQLState() string
+}
+
+// sqlState returns the SQLSTATE code of a Postgres driver error.
+func sqlState(err error) (string, bool) {
+	var s sqlStater
+	if !errors.As(err, &s) {
+		return "", false
+	}
+	state := s.SQLState()
+	if len(state) != 5 {
+		return "", false
+	}
+	return state, true
+}
+
+// mysqlNumber returns the error number of a *mysql.MySQLError.
+func mysqlNumber(err error) (uint16, bool) {
+	// We don't want to depend on the mysql driver, so we look for its error type by name and read
+	// the Number field with reflection. The tests protect us in case the field changes.
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.ValueOf(err)
+		if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		v = v.Elem()
+		if v.Type().Name() != "MySQLError" {
+			continue
+		}
+		f := v.FieldByName("Number")
+		if !f.IsValid() || f.Kind() != reflect.Uint16 {
+			return 0, false
+		}
+		return uint16(f.Uint()), true
+	}
+	return 0, false
+}
+
+// TxFunc is a function that executes inside a transaction. It should not call Commit() or Rollback().
+type TxFunc func(ctx context.Context, tx *sql.Tx) error
+
+// RetryTx runs fn inside a transaction started on db with opts and commits it. If fn or the commit
+// fails, the transaction is rolled back and the whole transaction is retried with backoff, unless the error
+// is permanent according to ErrTransformer(). This is useful for serializable transactions that fail
+// with serialization conflicts.
+func (t *Transformer) RetryTx(ctx context.Context, backoff *exponential.Backoff, db *sql.DB, opts *sql.TxOptions, fn TxFunc) error {
+	return backoff.Retry(
+		ctx,
+		func(ctx context.Context, r exponential.Record) error {
+			return t.ErrTransformer(t.tx(ctx, db, opts, fn))
+		},
+	)
+}
+
+// tx executes a single attempt of a transaction.
+func (t *Transformer) tx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn TxFunc) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w: rollback also failed: %s", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
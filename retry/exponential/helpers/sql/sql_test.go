@@ -0,0 +1,227 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/exponential"
+	"github.com/gostdlib/ops/retry/internal/errors"
+)
+
+// pgError mimics *pgconn.PgError and *pq.Error.
+type pgError struct {
+	Code string
+}
+
+func (e *pgError) Error() string    { return "pg error: " + e.Code }
+func (e *pgError) SQLState() string { return e.Code }
+
+// MySQLError mimics *mysql.MySQLError.
+type MySQLError struct {
+	Number   uint16
+	SQLState [5]byte
+	Message  string
+}
+
+func (e *MySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+func TestErrTransformer(t *testing.T) {
+	t.Parallel()
+
+	tr, err := New(WithExtraStates("23505"))
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantPerm bool
+	}{
+		{name: "Unknown error", err: fmt.Errorf("some error")},
+		{name: "driver.ErrBadConn", err: fmt.Errorf("query: %w", driver.ErrBadConn)},
+		{name: "sql.ErrNoRows", err: sql.ErrNoRows, wantPerm: true},
+		{name: "sql.ErrTxDone", err: sql.ErrTxDone, wantPerm: true},
+		{name: "Postgres serialization failure", err: &pgError{Code: "40001"}},
+		{name: "Postgres deadlock", err: &pgError{Code: "40P01"}},
+		{name: "Postgres lock not available", err: &pgError{Code: "55P03"}},
+		{name: "Postgres connection failure", err: &pgError{Code: "08006"}},
+		{name: "Postgres foreign key violation", err: &pgError{Code: "23503"}, wantPerm: true},
+		{name: "Postgres unique violation is an extra state", err: &pgError{Code: "23505"}},
+		{name: "Postgres syntax error", err: fmt.Errorf("wrapped: %w", &pgError{Code: "42601"}), wantPerm: true},
+		{name: "MySQL deadlock", err: &MySQLError{Number: 1213}},
+		{name: "MySQL lock wait timeout", err: &MySQLError{Number: 1205}},
+		{name: "MySQL duplicate entry", err: &MySQLError{Number: 1062}, wantPerm: true},
+		{name: "MySQL syntax error", err: fmt.Errorf("wrapped: %w", &MySQLError{Number: 1064}), wantPerm: true},
+	}
+
+	for _, test := range tests {
+		got := tr.ErrTransformer(test.err)
+		if errors.Is(got, errors.ErrPermanent) != test.wantPerm {
+			t.Errorf("TestErrTransformer(%s): got permanent == %v, want %v", test.name, errors.Is(got, errors.ErrPermanent), test.wantPerm)
+		}
+		if !errors.Is(got, test.err) {
+			t.Errorf("TestErrTransformer(%s): returned error does not wrap the original", test.name)
+		}
+	}
+
+	if tr.ErrTransformer(nil) != nil {
+		t.Errorf("TestErrTransformer(nil): got err != nil")
+	}
+}
+
+func TestWithExtraStates(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New(WithExtraStates("123")); err == nil {
+		t.Errorf("TestWithExtraStates: got err == nil, want err != nil")
+	}
+}
+
+// fakeDrivers is a database/sql driver that opens connections to the fakeDriver added for the DSN passed to
+// sql.Open(). It is registered once, as sql.Register() panics if a name is registered twice.
+type fakeDrivers struct {
+	mu      sync.Mutex
+	drivers map[string]*fakeDriver
+}
+
+var drivers = &fakeDrivers{drivers: map[string]*fakeDriver{}}
+
+func init() {
+	sql.Register("fakeDriver", drivers)
+}
+
+// add sets d as the fakeDriver for dsn, replacing any from an earlier run of the test.
+func (f *fakeDrivers) add(dsn string, d *fakeDriver) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drivers[dsn] = d
+}
+
+func (f *fakeDrivers) Open(dsn string) (driver.Conn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	d, ok := f.drivers[dsn]
+	if !ok {
+		return nil, fmt.Errorf("no fakeDriver for DSN %q", dsn)
+	}
+	return &fakeConn{d: d}, nil
+}
+
+// fakeDriver holds the state of a fake database where Commit() returns the errors in commitErrs in order.
+type fakeDriver struct {
+	mu         sync.Mutex
+	commitErrs []error
+	commits    int
+	rollbacks  int
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.ErrPermanent }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return &fakeTx{d: c.d}, nil }
+
+type fakeTx struct{ d *fakeDriver }
+
+func (t *fakeTx) Commit() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+
+	t.d.commits++
+	if t.d.commits <= len(t.d.commitErrs) {
+		return t.d.commitErrs[t.d.commits-1]
+	}
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+
+	t.d.rollbacks++
+	return nil
+}
+
+func TestRetryTx(t *testing.T) {
+	t.Parallel()
+
+	fnErr := fmt.Errorf("fn: %w", &pgError{Code: "40P01"})
+
+	tests := []struct {
+		name          string
+		commitErrs    []error
+		fnErrs        []error
+		wantErr       bool
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{
+			name:        "Success on first attempt",
+			wantCommits: 1,
+		},
+		{
+			name:        "Serialization failures on commit are retried",
+			commitErrs:  []error{&pgError{Code: "40001"}, &pgError{Code: "40001"}},
+			wantCommits: 3,
+		},
+		{
+			name:          "Deadlock in fn is retried after a rollback",
+			fnErrs:        []error{fnErr},
+			wantCommits:   1,
+			wantRollbacks: 1,
+		},
+		{
+			name:        "Constraint violation on commit is permanent",
+			commitErrs:  []error{&pgError{Code: "23503"}},
+			wantErr:     true,
+			wantCommits: 1,
+		},
+	}
+
+	for _, test := range tests {
+		d := &fakeDriver{commitErrs: test.commitErrs}
+		drivers.add(test.name, d)
+		db, err := sql.Open("fakeDriver", test.name)
+		if err != nil {
+			panic(err)
+		}
+
+		boff, err := exponential.New(exponential.WithTesting())
+		if err != nil {
+			panic(err)
+		}
+		tr, err := New()
+		if err != nil {
+			panic(err)
+		}
+
+		calls := 0
+		err = tr.RetryTx(context.Background(), boff, db, nil, func(ctx context.Context, tx *sql.Tx) error {
+			calls++
+			if calls <= len(test.fnErrs) {
+				return test.fnErrs[calls-1]
+			}
+			return nil
+		})
+		db.Close()
+
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRetryTx(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRetryTx(%s): got err == %s, want err == nil", test.name, err)
+		}
+		if d.commits != test.wantCommits {
+			t.Errorf("TestRetryTx(%s): got %d commits, want %d", test.name, d.commits, test.wantCommits)
+		}
+		if d.rollbacks != test.wantRollbacks {
+			t.Errorf("TestRetryTx(%s): got %d rollbacks, want %d", test.name, d.rollbacks, test.wantRollbacks)
+		}
+	}
+}
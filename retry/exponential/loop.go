@@ -0,0 +1,97 @@
+package exponential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LoopOption is an option for the Loop method.
+type LoopOption func(o *loopOptions) error
+
+// loopOptions are the options for a Loop() call.
+type loopOptions struct {
+	// resetAfter is how long an attempt must run before a failure resets the backoff.
+	resetAfter time.Duration
+}
+
+// WithResetAfter resets the backoff when an attempt that ran for at least d fails. This is for an Op that
+// runs a long session, such as a connection that is served until it breaks. A session that breaks after
+// running for a long time is reconnected immediately with a new Record instead of waiting as if every
+// session before it had failed.
+func WithResetAfter(d time.Duration) LoopOption {
+	return func(o *loopOptions) error {
+		if d <= 0 {
+			return errors.New("WithResetAfter() must be passed a duration > 0")
+		}
+		o.resetAfter = d
+		return nil
+	}
+}
+
+// Loop calls the Op over and over, such as in a reconnect loop, until the Context is done or the Op returns
+// a permanent error. Failed attempts are retried with the backoff just like Retry(). When an attempt succeeds,
+// the backoff is reset, so the next call of the Op starts at attempt 1 with no wait and intervals start again
+// at Policy.InitialInterval. This means the Op should block for as long as its work runs, such as for the life
+// of a connection. If an Op returns without an error before Policy.InitialInterval has passed, Loop waits
+// for the rest of the Policy.InitialInterval before the next call, so an Op that returns immediately does not
+// spin. Loop returns the error that stopped it, which is a permanent error, an error wrapping
+// ErrRetryExhausted or an error wrapping ErrRetryCanceled. This is safe to call concurrently.
+func (b *Backoff) Loop(ctx context.Context, op Op, options ...LoopOption) error {
+	opts := loopOptions{}
+	for _, o := range options {
+		if err := o(&opts); err != nil {
+			return err
+		}
+	}
+
+	// sessionErr is the error from an attempt that failed after running for opts.resetAfter.
+	var sessionErr error
+	wrapped := op
+	if opts.resetAfter > 0 {
+		wrapped = func(ctx context.Context, r Record) error {
+			start := b.now()
+			err := op(ctx, r)
+			if err != nil && b.now().Sub(start) >= opts.resetAfter && !errors.Is(err, ErrPermanent) {
+				sessionErr = err
+				return nil
+			}
+			return err
+		}
+	}
+
+	for {
+		sessionErr = nil
+		start := b.now()
+		if err := b.Retry(ctx, wrapped); err != nil {
+			return err
+		}
+		b.waitMin(ctx, start)
+		if ctx.Err() != nil {
+			if sessionErr != nil {
+				return fmt.Errorf("%w: %w", sessionErr, ErrRetryCanceled)
+			}
+			return fmt.Errorf("%w: %w", ctx.Err(), ErrRetryCanceled)
+		}
+	}
+}
+
+// waitMin waits until Policy.InitialInterval has passed since start or the Context is done. This is not
+// done if WithTesting() was passed.
+func (b *Backoff) waitMin(ctx context.Context, start time.Time) {
+	if b.useTest || ctx.Err() != nil {
+		return
+	}
+	d := b.currentPolicy().InitialInterval - b.now().Sub(start)
+	if d <= 0 {
+		return
+	}
+
+	timer := b.newTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+	case <-timer.C():
+	}
+}
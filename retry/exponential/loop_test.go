@@ -0,0 +1,121 @@
+package exponential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestLoop(t *testing.T) {
+	t.Parallel()
+
+	b, err := New(WithTesting())
+	if err != nil {
+		panic(err)
+	}
+
+	errTransient := errors.New("transient")
+	errStop := fmt.Errorf("stop: %w", ErrPermanent)
+
+	// results are returned by each call of the Op in order.
+	results := []error{errTransient, errTransient, nil, errTransient, nil, errStop}
+	var attempts []int
+	err = b.Loop(context.Background(), func(ctx context.Context, r Record) error {
+		attempts = append(attempts, r.Attempt)
+		err := results[0]
+		results = results[1:]
+		return err
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("TestLoop: got err == %v, want errStop", err)
+	}
+	if diff := pretty.Compare([]int{1, 2, 3, 1, 2, 1}, attempts); diff != "" {
+		t.Errorf("TestLoop: attempts -want/+got:\n%s", diff)
+	}
+
+	// The Context being cancelled after a success stops the loop.
+	ctx, cancel := context.WithCancel(context.Background())
+	err = b.Loop(ctx, func(ctx context.Context, r Record) error {
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, ErrRetryCanceled) {
+		t.Errorf("TestLoop(cancel): got err == %v, want ErrRetryCanceled", err)
+	}
+
+	if err := b.Loop(context.Background(), nil, WithResetAfter(0)); err == nil {
+		t.Errorf("TestLoop: WithResetAfter(0): got err == nil, want err != nil")
+	}
+}
+
+func TestLoopWithResetAfter(t *testing.T) {
+	t.Parallel()
+
+	clock := &testClock{}
+	b, err := New(WithTesting(), WithClock(clock))
+	if err != nil {
+		panic(err)
+	}
+
+	errBroken := errors.New("connection broken")
+	// runFor is how long each session runs before it breaks.
+	runFor := []time.Duration{0, 0, time.Hour, 0, time.Hour}
+	var attempts []int
+	err = b.Loop(
+		context.Background(),
+		func(ctx context.Context, r Record) error {
+			attempts = append(attempts, r.Attempt)
+			if len(runFor) == 0 {
+				return fmt.Errorf("done: %w", ErrPermanent)
+			}
+			clock.moveTime(runFor[0])
+			runFor = runFor[1:]
+			return errBroken
+		},
+		WithResetAfter(time.Minute),
+	)
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("TestLoopWithResetAfter: got err == %v, want ErrPermanent", err)
+	}
+	// Sessions that ran for an hour reset the attempts.
+	if diff := pretty.Compare([]int{1, 2, 3, 1, 2, 1}, attempts); diff != "" {
+		t.Errorf("TestLoopWithResetAfter: attempts -want/+got:\n%s", diff)
+	}
+}
+
+func TestLoopMinInterval(t *testing.T) {
+	t.Parallel()
+
+	// Timers fire as soon as they are created.
+	clock := &testClock{onTimer: func(c *testClock, d time.Duration) { c.moveTime(d) }}
+	policy := defaults()
+	policy.InitialInterval = time.Second
+	b, err := New(WithPolicy(policy), WithClock(clock))
+	if err != nil {
+		panic(err)
+	}
+
+	// The first call runs for longer than the InitialInterval, the next two return immediately.
+	runFor := []time.Duration{2 * time.Second, 0, 0}
+	err = b.Loop(context.Background(), func(ctx context.Context, r Record) error {
+		if len(runFor) == 0 {
+			return fmt.Errorf("done: %w", ErrPermanent)
+		}
+		clock.moveTime(runFor[0])
+		runFor = runFor[1:]
+		return nil
+	})
+	if !errors.Is(err, ErrPermanent) {
+		t.Errorf("TestLoopMinInterval: got err == %v, want ErrPermanent", err)
+	}
+	if clock.created != 2 {
+		t.Errorf("TestLoopMinInterval: got %d waits, want 2", clock.created)
+	}
+	if got, want := clock.Now().Sub(time.Time{}), 4*time.Second; got != want {
+		t.Errorf("TestLoopMinInterval: got %v of time passed, want %v", got, want)
+	}
+}
@@ -1,7 +1,7 @@
 package exponential
 
 import (
-	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -35,25 +35,92 @@ type Policy struct {
 	// MaxInterval is the maximum amount of time to wait between retries. Must be > 0.
 	// Defaults to 60s.
 	MaxInterval time.Duration
+	// MinInterval is the minimum amount of time to wait between retries after randomization. Randomization
+	// can make a wait much shorter than the interval, down to 0 with a RandomizationFactor of 1, which is
+	// not what you want against a rate limited service. Must be >= 0 and <= MaxInterval.
+	// Defaults to 0, which means there is no minimum.
+	MinInterval time.Duration
+	// MaxCumulativeInterval is the maximum amount of time to spend waiting between all attempts of a
+	// Retry() call, where MaxInterval caps a single wait. If waiting for the next attempt would exceed
+	// this, Retry() returns an error wrapping ErrRetryExhausted. This does not include the time spent in
+	// the Op. Must be >= 0. Defaults to 0, which means there is no limit.
+	MaxCumulativeInterval time.Duration
+}
+
+// PolicyError describes a Policy field that failed validation.
+type PolicyError struct {
+	// Field is the name of the Policy field, such as "InitialInterval".
+	Field string
+	// Value is the value the field was set to.
+	Value any
+	// Constraint is the constraint the value did not meet, such as "must be greater than 0".
+	Constraint string
+}
+
+// Error implements error.Error().
+func (e PolicyError) Error() string {
+	return fmt.Sprintf("Policy.%s(%v) %s", e.Field, e.Value, e.Constraint)
+}
+
+// PolicyErrors is a list of all the PolicyError found when validating a Policy. It is returned by New() and
+// other functions that validate a Policy. Use errors.As() to retrieve it from an error.
+type PolicyErrors []PolicyError
+
+// Error implements error.Error(). Each PolicyError is on its own line.
+func (e PolicyErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, pe := range e {
+		msgs = append(msgs, pe.Error())
+	}
+	return strings.Join(msgs, "\n")
 }
 
+// Unwrap returns each PolicyError so that errors.As() can retrieve a single PolicyError.
+func (e PolicyErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, pe := range e {
+		errs = append(errs, pe)
+	}
+	return errs
+}
+
+// validate validates the Policy. If it is not valid, the returned error is a PolicyErrors containing
+// every field that is not valid.
 func (p Policy) validate() error {
+	var errs PolicyErrors
+	add := func(field string, value any, constraint string) {
+		errs = append(errs, PolicyError{Field: field, Value: value, Constraint: constraint})
+	}
+
 	if p.InitialInterval <= 0 {
-		return errors.New("Policy.InitialInterval must be greater than 0")
+		add("InitialInterval", p.InitialInterval, "must be greater than 0")
 	}
 	if p.Multiplier <= 1 {
-		return errors.New("Policy.Multiplier must be greater than 1")
+		add("Multiplier", p.Multiplier, "must be greater than 1")
 	}
 	if p.RandomizationFactor < 0 || p.RandomizationFactor > 1 {
-		return errors.New("Policy.RandomizationFactor must be between 0 and 1")
+		add("RandomizationFactor", p.RandomizationFactor, "must be between 0 and 1")
 	}
 	if p.MaxInterval <= 0 {
-		return errors.New("Policy.MaxInterval must be greater than 0")
+		add("MaxInterval", p.MaxInterval, "must be greater than 0")
 	}
 	if p.InitialInterval > p.MaxInterval {
-		return errors.New("Policy.InitialInterval must be less than or equal to Policy.MaxInterval")
+		add("InitialInterval", p.InitialInterval, "must be less than or equal to Policy.MaxInterval")
+	}
+	if p.MinInterval < 0 {
+		add("MinInterval", p.MinInterval, "must be greater than or equal to 0")
+	}
+	if p.MinInterval > p.MaxInterval {
+		add("MinInterval", p.MinInterval, "must be less than or equal to Policy.MaxInterval")
+	}
+	if p.MaxCumulativeInterval < 0 {
+		add("MaxCumulativeInterval", p.MaxCumulativeInterval, "must be greater than or equal to 0")
+	}
+
+	if len(errs) == 0 {
+		return nil
 	}
-	return nil
+	return errs
 }
 
 // TimeTableEntry is an entry in the time table.
@@ -125,6 +192,54 @@ func (t TimeTable) Litter() string {
 	return litterConf.Sdump(t)
 }
 
+// AttemptBudget is the number of attempts that start within a time budget. Returned by TimeTable.AttemptsForBudget().
+type AttemptBudget struct {
+	// WorstCase is the number of attempts that start within the budget if every wait is the longest
+	// possible after randomization (TimeTableEntry.MaxInterval). This is the number you can count on.
+	WorstCase int
+	// Expected is the number of attempts that start within the budget if every wait is the interval
+	// before randomization (TimeTableEntry.Interval), which is the average wait.
+	Expected int
+}
+
+// AttemptsForBudget returns how many attempts will start within budget, such as a Context timeout. The
+// first attempt always starts at 0, so any budget >= 0 includes at least one attempt. The time spent
+// in the Op is not counted, so subtract how long you expect each attempt to take if it matters.
+// If the budget extends past the Entries, the last entry's interval is repeated. This is correct for a
+// TimeTable from Policy.TimeTable(-1), as its last entry is at Policy.MaxInterval.
+func (t TimeTable) AttemptsForBudget(budget time.Duration) AttemptBudget {
+	if budget < 0 {
+		return AttemptBudget{}
+	}
+	return AttemptBudget{
+		WorstCase: t.attemptsWithin(budget, func(e TimeTableEntry) time.Duration { return e.MaxInterval }),
+		Expected:  t.attemptsWithin(budget, func(e TimeTableEntry) time.Duration { return e.Interval }),
+	}
+}
+
+// attemptsWithin returns the number of attempts that start within budget, where interval returns the
+// wait before the attempt of an entry.
+func (t TimeTable) attemptsWithin(budget time.Duration, interval func(e TimeTableEntry) time.Duration) int {
+	var elapsed time.Duration
+	attempts := 0
+	for _, e := range t.Entries {
+		elapsed += interval(e)
+		if elapsed > budget {
+			return attempts
+		}
+		attempts++
+	}
+
+	if len(t.Entries) == 0 {
+		return attempts
+	}
+	last := interval(t.Entries[len(t.Entries)-1])
+	if last <= 0 {
+		return attempts
+	}
+	return attempts + int((budget-elapsed)/last)
+}
+
 // TimeTable will return a TimeTable for the Policy. If attempts is >= 0, then the TimeTable will
 // be for that number of attempts. If attempts is < 0, then the TimeTable will be for all entries
 // until the maximum interval is reached. This should only be used in tools and testing.
@@ -155,8 +270,8 @@ func (p Policy) timeTableWithAttempts(attempts int) TimeTable {
 	interval := p.InitialInterval
 
 	for i := 2; i <= attempts; i++ {
-		minInterval := interval - time.Duration(float64(interval)*p.RandomizationFactor)
-		maxInterval := interval + time.Duration(float64(interval)*p.RandomizationFactor)
+		minInterval := p.floor(interval - time.Duration(float64(interval)*p.RandomizationFactor))
+		maxInterval := p.floor(interval + time.Duration(float64(interval)*p.RandomizationFactor))
 
 		entry := TimeTableEntry{
 			Attempt:     i,
@@ -194,8 +309,8 @@ func (p Policy) timeTable() TimeTable {
 
 	var i int
 	for i = 2; interval != p.MaxInterval; i++ {
-		minInterval := interval - time.Duration(float64(interval)*p.RandomizationFactor)
-		maxInterval := interval + time.Duration(float64(interval)*p.RandomizationFactor)
+		minInterval := p.floor(interval - time.Duration(float64(interval)*p.RandomizationFactor))
+		maxInterval := p.floor(interval + time.Duration(float64(interval)*p.RandomizationFactor))
 
 		entry := TimeTableEntry{
 			Attempt:     i,
@@ -217,8 +332,8 @@ func (p Policy) timeTable() TimeTable {
 	entry := TimeTableEntry{
 		Attempt:     i,
 		Interval:    interval,
-		MinInterval: interval - time.Duration(float64(interval)*p.RandomizationFactor),
-		MaxInterval: interval + time.Duration(float64(interval)*p.RandomizationFactor),
+		MinInterval: p.floor(interval - time.Duration(float64(interval)*p.RandomizationFactor)),
+		MaxInterval: p.floor(interval + time.Duration(float64(interval)*p.RandomizationFactor)),
 	}
 	tt.MinTime += entry.MinInterval
 	tt.MaxTime += entry.MaxInterval
@@ -227,6 +342,14 @@ func (p Policy) timeTable() TimeTable {
 	return tt
 }
 
+// floor returns d or MinInterval if d is less than MinInterval.
+func (p Policy) floor(d time.Duration) time.Duration {
+	if d < p.MinInterval {
+		return p.MinInterval
+	}
+	return d
+}
+
 // defaults creates a new Policy with the default values.
 func defaults() Policy {
 	// progression will be:
@@ -0,0 +1,56 @@
+package exponential
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// PolicyProvider provides the Policy for a Backoff. Policy() is called at the start of every Retry() call,
+// which allows operators to tighten or loosen a Policy at runtime, such as from a config file watcher or a
+// feature flag, without recreating a Backoff. A Retry() call that is in progress keeps the Policy it started
+// with. Policy() may be called concurrently, so it must be safe for concurrent use and should be fast.
+type PolicyProvider interface {
+	Policy() Policy
+}
+
+// WithPolicyProvider sets a PolicyProvider that is used to get the Policy at the start of each Retry() call.
+// The PolicyProvider must return a valid Policy when New() is called. If it later returns an invalid Policy,
+// the Retry() call uses the Policy set with WithPolicy() or the default Policy instead.
+func WithPolicyProvider(p PolicyProvider) Option {
+	return func(b *Backoff) error {
+		if p == nil {
+			return errors.New("WithPolicyProvider() cannot be passed a nil PolicyProvider")
+		}
+		b.provider = p
+		return nil
+	}
+}
+
+// AtomicPolicy is a PolicyProvider where the Policy can be changed at any time with Store(). Create with
+// NewAtomicPolicy(). An AtomicPolicy is safe for concurrent use.
+type AtomicPolicy struct {
+	p atomic.Pointer[Policy]
+}
+
+// NewAtomicPolicy creates a new AtomicPolicy that provides the Policy p.
+func NewAtomicPolicy(p Policy) (*AtomicPolicy, error) {
+	a := &AtomicPolicy{}
+	if err := a.Store(p); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Store validates p and, if valid, makes it the Policy that is provided.
+func (a *AtomicPolicy) Store(p Policy) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+	a.p.Store(&p)
+	return nil
+}
+
+// Policy implements PolicyProvider.
+func (a *AtomicPolicy) Policy() Policy {
+	return *a.p.Load()
+}
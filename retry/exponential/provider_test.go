@@ -0,0 +1,82 @@
+package exponential
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// badPolicy is a PolicyProvider that can return an invalid Policy.
+type badPolicy struct {
+	valid bool
+}
+
+func (b *badPolicy) Policy() Policy {
+	if b.valid {
+		return Policy{InitialInterval: time.Second, Multiplier: 2, MaxInterval: time.Second}
+	}
+	return Policy{}
+}
+
+func TestWithPolicyProvider(t *testing.T) {
+	t.Parallel()
+
+	fast := Policy{InitialInterval: time.Millisecond, Multiplier: 2, MaxInterval: time.Millisecond}
+	slow := Policy{InitialInterval: time.Hour, Multiplier: 2, MaxInterval: time.Hour}
+
+	ap, err := NewAtomicPolicy(fast)
+	if err != nil {
+		panic(err)
+	}
+	b, err := New(WithTesting(), WithPolicyProvider(ap))
+	if err != nil {
+		panic(err)
+	}
+
+	check := func(want time.Duration) {
+		t.Helper()
+		err := b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+			if r.NextInterval != want {
+				t.Errorf("TestWithPolicyProvider: got NextInterval == %v, want %v", r.NextInterval, want)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("TestWithPolicyProvider: got err == %s, want err == nil", err)
+		}
+	}
+
+	check(time.Millisecond)
+	if err := ap.Store(slow); err != nil {
+		panic(err)
+	}
+	check(time.Hour)
+
+	if err := ap.Store(Policy{}); err == nil {
+		t.Errorf("TestWithPolicyProvider: AtomicPolicy.Store(Policy{}): got err == nil, want err != nil")
+	}
+	check(time.Hour)
+
+	// An invalid Policy at New() is an error.
+	bp := &badPolicy{}
+	if _, err := New(WithPolicyProvider(bp)); err == nil {
+		t.Errorf("TestWithPolicyProvider: New() with invalid PolicyProvider: got err == nil, want err != nil")
+	}
+	if _, err := New(WithPolicyProvider(nil)); err == nil {
+		t.Errorf("TestWithPolicyProvider: WithPolicyProvider(nil): got err == nil, want err != nil")
+	}
+
+	// An invalid Policy later falls back to the WithPolicy() Policy.
+	bp.valid = true
+	b, err = New(WithTesting(), WithPolicy(fast), WithPolicyProvider(bp))
+	if err != nil {
+		panic(err)
+	}
+	bp.valid = false
+	if got := b.currentPolicy(); got != fast {
+		t.Errorf("TestWithPolicyProvider: invalid PolicyProvider Policy: got %+v, want %+v", got, fast)
+	}
+	if _, err := NewAtomicPolicy(Policy{}); err == nil {
+		t.Errorf("TestWithPolicyProvider: NewAtomicPolicy(Policy{}): got err == nil, want err != nil")
+	}
+}
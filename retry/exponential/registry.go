@@ -0,0 +1,211 @@
+package exponential
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultRegistry is the Registry used by WithName() when WithRegistry() is not provided.
+var DefaultRegistry = NewRegistry()
+
+// Stats are aggregate statistics for a Backoff that has been registered in a Registry.
+type Stats struct {
+	// InFlight is the number of Retry() calls currently executing.
+	InFlight int64
+	// Succeeded is the number of Retry() calls that returned nil.
+	Succeeded uint64
+	// Permanent is the number of Retry() calls that ended with a permanent error.
+	Permanent uint64
+	// Canceled is the number of Retry() calls that ended because the Context was cancelled or
+	// did not have time for another attempt.
+	Canceled uint64
+	// Exhausted is the number of Retry() calls that ended because Policy.MaxCumulativeInterval was reached.
+	Exhausted uint64
+}
+
+// stats holds the live counters for a Backoff. All methods are safe to call on a nil *stats.
+type stats struct {
+	inFlight  atomic.Int64
+	succeeded atomic.Uint64
+	permanent atomic.Uint64
+	canceled  atomic.Uint64
+	exhausted atomic.Uint64
+}
+
+// start records the start of a Retry() call.
+func (s *stats) start() {
+	if s == nil {
+		return
+	}
+	s.inFlight.Add(1)
+}
+
+// end records the end of a Retry() call that returned err.
+func (s *stats) end(err error) {
+	if s == nil {
+		return
+	}
+	s.inFlight.Add(-1)
+
+	switch {
+	case err == nil:
+		s.succeeded.Add(1)
+	case errors.Is(err, ErrPermanent):
+		s.permanent.Add(1)
+	case errors.Is(err, ErrRetryCanceled):
+		s.canceled.Add(1)
+	case errors.Is(err, ErrRetryExhausted):
+		s.exhausted.Add(1)
+	}
+}
+
+// snapshot returns the current Stats.
+func (s *stats) snapshot() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		InFlight:  s.inFlight.Load(),
+		Succeeded: s.succeeded.Load(),
+		Permanent: s.permanent.Load(),
+		Canceled:  s.canceled.Load(),
+		Exhausted: s.exhausted.Load(),
+	}
+}
+
+// Registry holds Backoffs registered under a name. This allows inspection of the configured Policy
+// and live statistics for each Backoff. Registry implements http.Handler so it can be served on a debug
+// endpoint, such as /debug/retries. Create with NewRegistry(). A Registry is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	backoffs map[string]*Backoff
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{backoffs: map[string]*Backoff{}}
+}
+
+// add adds a Backoff to the registry under name. It is an error to register the same name twice.
+func (r *Registry) add(name string, b *Backoff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.backoffs[name]; ok {
+		return fmt.Errorf("a Backoff named %q is already registered", name)
+	}
+	r.backoffs[name] = b
+	return nil
+}
+
+// Remove removes the Backoff registered under name. This is a no-op if the name is not registered.
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.backoffs, name)
+}
+
+// Get returns the Backoff registered under name.
+func (r *Registry) Get(name string) (*Backoff, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.backoffs[name]
+	return b, ok
+}
+
+// Names returns the sorted names of all registered Backoffs.
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.backoffs))
+	for name := range r.backoffs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegistryEntry is the state of a registered Backoff as output by Registry.ServeHTTP().
+type RegistryEntry struct {
+	// Name is the name the Backoff was registered under.
+	Name string
+	// Policy is the Policy the Backoff is using.
+	Policy Policy
+	// Stats are the statistics for the Backoff.
+	Stats Stats
+}
+
+// Entries returns the state of all registered Backoffs, sorted by name.
+func (r *Registry) Entries() []RegistryEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]RegistryEntry, 0, len(r.backoffs))
+	for name, b := range r.backoffs {
+		entries = append(entries, RegistryEntry{Name: name, Policy: b.currentPolicy(), Stats: b.stats.snapshot()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// ServeHTTP implements http.Handler. It writes the Entries() as JSON.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	if err := enc.Encode(r.Entries()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Stats returns the statistics for the Backoff. These are only collected if the Backoff was
+// created with WithName(), otherwise this returns the zero value.
+func (b *Backoff) Stats() Stats {
+	return b.stats.snapshot()
+}
+
+// WithName registers the Backoff under name in a Registry. This uses DefaultRegistry unless
+// WithRegistry() is also passed. Registering the same name twice in a Registry causes New() to return
+// an error. Use Registry.Remove() if the Backoff is no longer used.
+func WithName(name string) Option {
+	return func(b *Backoff) error {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("WithName() cannot have an empty name")
+		}
+		b.name = name
+		return nil
+	}
+}
+
+// WithRegistry sets the Registry to use with WithName(). If not set, DefaultRegistry is used.
+// This has no effect without WithName().
+func WithRegistry(r *Registry) Option {
+	return func(b *Backoff) error {
+		if r == nil {
+			return errors.New("WithRegistry() cannot be passed a nil Registry")
+		}
+		b.registry = r
+		return nil
+	}
+}
+
+// register registers the Backoff in its Registry if it was given a name.
+func (b *Backoff) register() error {
+	if b.name == "" {
+		return nil
+	}
+	if b.registry == nil {
+		b.registry = DefaultRegistry
+	}
+	b.stats = &stats{}
+	return b.registry.add(b.name, b)
+}
@@ -0,0 +1,65 @@
+package exponential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+
+	b, err := New(WithTesting(), WithName("backoff"), WithRegistry(reg))
+	if err != nil {
+		panic(err)
+	}
+	if _, err := New(WithName("backoff"), WithRegistry(reg)); err == nil {
+		t.Errorf("TestRegistry: registering the same name twice: got err == nil, want err != nil")
+	}
+	if _, err := New(WithName(" "), WithRegistry(reg)); err == nil {
+		t.Errorf("TestRegistry: registering an empty name: got err == nil, want err != nil")
+	}
+
+	if got, ok := reg.Get("backoff"); !ok || got != b {
+		t.Errorf("TestRegistry: Get() did not return the registered Backoff")
+	}
+
+	b.Retry(context.Background(), func(ctx context.Context, r Record) error { return nil })
+	b.Retry(context.Background(), func(ctx context.Context, r Record) error { return ErrPermanent })
+	b.Retry(context.Background(), func(ctx context.Context, r Record) error {
+		if r.Attempt == 1 {
+			return errors.New("transient error")
+		}
+		return nil
+	})
+
+	want := []RegistryEntry{
+		{
+			Name:   "backoff",
+			Policy: defaults(),
+			Stats:  Stats{Succeeded: 2, Permanent: 1},
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	reg.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/retries", nil))
+
+	got := []RegistryEntry{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("TestRegistry: could not unmarshal ServeHTTP() output: %s", err)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestRegistry: -want/+got:\n%s", diff)
+	}
+
+	reg.Remove("backoff")
+	if len(reg.Names()) != 0 {
+		t.Errorf("TestRegistry: Remove() did not remove the Backoff")
+	}
+}
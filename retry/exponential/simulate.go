@@ -0,0 +1,109 @@
+package exponential
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// SimulationEntry holds the percentiles of the total delay before an attempt starts.
+type SimulationEntry struct {
+	// Attempt is the attempt number that this entry is for.
+	Attempt int
+	// P50 is the median total delay before the attempt starts.
+	P50 time.Duration
+	// P90 is the 90th percentile total delay before the attempt starts.
+	P90 time.Duration
+	// P99 is the 99th percentile total delay before the attempt starts.
+	P99 time.Duration
+}
+
+// Simulation is the result of Policy.Simulate().
+type Simulation struct {
+	// Runs is the number of runs that were simulated.
+	Runs int
+	// Entries holds the percentiles for each attempt.
+	Entries []SimulationEntry
+}
+
+// String implements fmt.Stringer.
+func (s Simulation) String() string {
+	var b strings.Builder
+	w := table.NewWriter()
+	w.SetOutputMirror(&b)
+
+	b.WriteString("==============\n")
+	b.WriteString("= Simulation =\n")
+	b.WriteString("==============\n")
+
+	w.AppendHeader(table.Row{"Attempt", "P50", "P90", "P99"})
+	for _, e := range s.Entries {
+		w.AppendRow(table.Row{e.Attempt, e.P50, e.P90, e.P99})
+	}
+	w.AppendFooter(table.Row{"", "", "Runs", s.Runs})
+	w.Render()
+
+	return b.String()
+}
+
+// Simulate samples the randomized intervals of the Policy for the number of attempts, runs times, and
+// returns the percentiles of the total delay before each attempt starts. Where a TimeTable gives the
+// minimum and maximum, this gives what is likely, which is more useful for SLO planning. This does not
+// include the time spent in the Op. This should only be used in tools and testing.
+func (p Policy) Simulate(attempts, runs int) (Simulation, error) {
+	if attempts < 1 {
+		return Simulation{}, errors.New("attempts must be >= 1")
+	}
+	if runs < 1 {
+		return Simulation{}, errors.New("runs must be >= 1")
+	}
+	if err := p.validate(); err != nil {
+		return Simulation{}, err
+	}
+
+	// totals[i] holds the total delay before attempt i+1 for every run.
+	totals := make([][]time.Duration, attempts)
+	for i := range totals {
+		totals[i] = make([]time.Duration, runs)
+	}
+
+	for r := 0; r < runs; r++ {
+		var total time.Duration
+		interval := p.InitialInterval
+		for i := 1; i < attempts; i++ {
+			total += p.floor(randomInterval(p.RandomizationFactor, interval, rand.Int64N)) // #nosec
+			totals[i][r] = total
+
+			interval = time.Duration(float64(interval) * p.Multiplier)
+			if interval > p.MaxInterval {
+				interval = p.MaxInterval
+			}
+		}
+	}
+
+	sim := Simulation{Runs: runs, Entries: make([]SimulationEntry, attempts)}
+	for i, t := range totals {
+		slices.Sort(t)
+		sim.Entries[i] = SimulationEntry{
+			Attempt: i + 1,
+			P50:     percentile(t, 0.50),
+			P90:     percentile(t, 0.90),
+			P99:     percentile(t, 0.99),
+		}
+	}
+	return sim, nil
+}
+
+// percentile returns the nearest rank percentile pct (0 to 1) of sorted.
+func percentile(sorted []time.Duration, pct float64) time.Duration {
+	i := int(math.Ceil(pct*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
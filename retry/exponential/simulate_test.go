@@ -0,0 +1,50 @@
+package exponential
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+
+	p := defaults()
+	tt := p.TimeTable(6)
+
+	sim, err := p.Simulate(6, 1000)
+	if err != nil {
+		t.Fatalf("TestSimulate: got err == %s, want err == nil", err)
+	}
+	if sim.Runs != 1000 || len(sim.Entries) != 6 {
+		t.Fatalf("TestSimulate: got Runs == %d, len(Entries) == %d, want 1000 and 6", sim.Runs, len(sim.Entries))
+	}
+
+	var minTotal, maxTotal time.Duration
+	for i, e := range sim.Entries {
+		minTotal += tt.Entries[i].MinInterval
+		maxTotal += tt.Entries[i].MaxInterval
+
+		if e.Attempt != i+1 {
+			t.Errorf("TestSimulate(attempt %d): got Attempt == %d", i+1, e.Attempt)
+		}
+		if !(e.P50 <= e.P90 && e.P90 <= e.P99) {
+			t.Errorf("TestSimulate(attempt %d): percentiles are not ordered: %+v", i+1, e)
+		}
+		if e.P50 < minTotal || e.P99 > maxTotal {
+			t.Errorf("TestSimulate(attempt %d): got %+v, want between %v and %v", i+1, e, minTotal, maxTotal)
+		}
+	}
+	if sim.Entries[0] != (SimulationEntry{Attempt: 1}) {
+		t.Errorf("TestSimulate: first attempt should have no delay, got %+v", sim.Entries[0])
+	}
+
+	if _, err := p.Simulate(0, 1); err == nil {
+		t.Errorf("TestSimulate: Simulate(0, 1): got err == nil, want err != nil")
+	}
+	if _, err := p.Simulate(1, 0); err == nil {
+		t.Errorf("TestSimulate: Simulate(1, 0): got err == nil, want err != nil")
+	}
+	if _, err := (Policy{}).Simulate(1, 1); err == nil {
+		t.Errorf("TestSimulate: invalid Policy: got err == nil, want err != nil")
+	}
+}
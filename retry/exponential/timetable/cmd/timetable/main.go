@@ -0,0 +1,91 @@
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gostdlib/ops/retry/exponential/timetable"
+)
+
+var (
+	attempts = flag.Int("attempts", -1, "Number of attempts to make, defaults to -1 which is until MaxInterval is reached")
+	gostruct = flag.Bool("gostruct", false, "Print the Go struct for the time table instead of human readable")
+	policy   = flag.String("policy", "", "Path to a HuJSON or JSON policy file to use instead of the embedded settings.hujson")
+	stdin    = flag.Bool("stdin", false, "Read the HuJSON or JSON policy from stdin instead of the embedded settings.hujson")
+	compare  = flag.String("compare", "", "Path to a second HuJSON or JSON policy file to compare against, printed side by side")
+	format   = flag.String("format", "text", "Output format for the time table, one of text|json|csv|markdown")
+)
+
+//go:embed settings.hujson
+var settings []byte
+
+func main() {
+	flag.Parse()
+
+	f, err := timetable.ParseFormat(*format)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	data, err := readSettings()
+	if err != nil {
+		fmt.Println("Error reading settings:", err)
+		os.Exit(1)
+	}
+
+	if f == timetable.Text && !*gostruct {
+		fmt.Printf("Generating TimeTable for %d attempts and the following settings:\n%s\n\n", *attempts, string(data))
+	}
+
+	// hujson is a superset of JSON allowing comments.
+	p, err := timetable.Load(data)
+	if err != nil {
+		fmt.Println("Error reading settings:", err)
+		os.Exit(1)
+	}
+
+	if *gostruct {
+		tt := p.TimeTable(*attempts)
+		fmt.Println(tt.Litter())
+		return
+	}
+
+	if *compare != "" {
+		op, err := timetable.LoadFile(*compare)
+		if err != nil {
+			fmt.Println("Error reading compare settings:", err)
+			os.Exit(1)
+		}
+		if f == timetable.Text {
+			fmt.Printf("Comparing (A) to (B) from %s\n\n", *compare)
+		}
+		if err := timetable.RenderCompare(os.Stdout, p.TimeTable(*attempts), op.TimeTable(*attempts), f); err != nil {
+			fmt.Println("Error rendering TimeTable:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := timetable.Render(os.Stdout, p.TimeTable(*attempts), f); err != nil {
+		fmt.Println("Error rendering TimeTable:", err)
+		os.Exit(1)
+	}
+}
+
+// readSettings returns the policy settings from the file passed with -policy, from stdin if -stdin was
+// passed or the embedded settings.hujson.
+func readSettings() ([]byte, error) {
+	switch {
+	case *policy != "" && *stdin:
+		return nil, fmt.Errorf("cannot use -policy and -stdin together")
+	case *policy != "":
+		return os.ReadFile(*policy)
+	case *stdin:
+		return io.ReadAll(os.Stdin)
+	}
+	return settings, nil
+}
@@ -1,53 +1,202 @@
-package main
+/*
+Package timetable loads exponential.Policy settings and renders their exponential.TimeTable in a
+variety of formats. It is used by the timetable command in cmd/timetable and can be used by services
+to expose their effective retry schedules, such as on an admin endpoint.
+
+Example serving the TimeTable for a Policy on a debug endpoint:
+
+	policy, _ := exponential.NewAtomicPolicy(myPolicy)
+
+	http.Handle("/debug/retries/timetable", timetable.Handler(policy))
+
+The TimeTable can then be retrieved with a request such as "/debug/retries/timetable?format=markdown&attempts=5".
+*/
+package timetable
 
 import (
-	_ "embed"
 	"encoding/json"
-	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/gostdlib/ops/retry/exponential"
 
-	"github.com/tailscale/hujson"
+	"github.com/jedib0t/go-pretty/v6/table"
 )
 
-var (
-	attempts = flag.Int("attempts", -1, "Number of attempts to make, defaults to -1 which is until MaxInterval is reached")
-	gostruct = flag.Bool("gostruct", false, "Print the Go struct for the time table instead of human readable")
-)
+// Format is an output format for Render() and RenderCompare().
+type Format string
 
-//go:embed settings.hujson
-var settings []byte
+const (
+	// Text is a human readable table. This is the same as TimeTable.String().
+	Text Format = "text"
+	// JSON is the TimeTable encoded as JSON. Durations are an integer number of nanoseconds.
+	JSON Format = "json"
+	// CSV is comma separated values. Durations are in seconds so that spreadsheets can use them as numbers.
+	CSV Format = "csv"
+	// Markdown is a markdown table, useful in design docs.
+	Markdown Format = "markdown"
+)
 
-func main() {
-	flag.Parse()
+// Formats are all the supported Formats.
+var Formats = []Format{Text, JSON, CSV, Markdown}
 
-	fmt.Printf("Generating TimeTable for %d attempts and the following settings:\n%s\n\n", *attempts, string(settings))
+// ParseFormat returns the Format for s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("format must be one of text|json|csv|markdown, got %q", s)
+}
 
-	p := exponential.Policy{}
+// Load decodes a HuJSON or JSON policy, such as the settings.hujson file used by the timetable command,
+// and returns the validated Policy. This uses exponential.PolicyFromHuJSON().
+func Load(data []byte) (exponential.Policy, error) {
+	return exponential.PolicyFromHuJSON(data)
+}
 
-	// hujson is a superset of JSON allowing comments.
-	buff, err := hujson.Standardize(settings)
+// LoadFile reads the HuJSON or JSON policy at path and returns the validated Policy.
+func LoadFile(path string) (exponential.Policy, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Println("Error standardizing settings with hujson:", err)
-		os.Exit(1)
+		return exponential.Policy{}, err
 	}
-	if err := json.Unmarshal(buff, &p); err != nil {
-		fmt.Println("Error unmarshalling settings:", err)
-		os.Exit(1)
+	return Load(data)
+}
+
+// Render writes the TimeTable to w in the Format.
+func Render(w io.Writer, tt exponential.TimeTable, f Format) error {
+	switch f {
+	case Text:
+		_, err := fmt.Fprintln(w, tt)
+		return err
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(tt)
+	case CSV:
+		t := tableWriter(w)
+		t.AppendHeader(table.Row{"Attempt", "IntervalSeconds", "MinIntervalSeconds", "MaxIntervalSeconds"})
+		for _, e := range tt.Entries {
+			t.AppendRow(table.Row{e.Attempt, e.Interval.Seconds(), e.MinInterval.Seconds(), e.MaxInterval.Seconds()})
+		}
+		t.RenderCSV()
+		return nil
+	case Markdown:
+		t := tableWriter(w)
+		t.AppendHeader(table.Row{"Attempt", "Interval", "MinInterval", "MaxInterval"})
+		for _, e := range tt.Entries {
+			t.AppendRow(table.Row{e.Attempt, e.Interval, e.MinInterval, e.MaxInterval})
+		}
+		t.AppendFooter(table.Row{"", "Total", tt.MinTime, tt.MaxTime})
+		t.RenderMarkdown()
+		return nil
 	}
+	_, err := ParseFormat(string(f))
+	return err
+}
 
-	_, err = exponential.New(exponential.WithPolicy(p))
-	if err != nil {
-		fmt.Println("Error creating new policy:", err)
-		os.Exit(1)
+// Comparison is the JSON output of RenderCompare().
+type Comparison struct {
+	// Policy is the TimeTable for the first policy (A).
+	Policy exponential.TimeTable
+	// Compare is the TimeTable for the policy being compared against (B).
+	Compare exponential.TimeTable
+}
+
+// RenderCompare writes the TimeTables a and b side by side, aligned by attempt, to w in the Format.
+// Deltas are b minus a.
+func RenderCompare(w io.Writer, a, b exponential.TimeTable, f Format) error {
+	if f == JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(Comparison{Policy: a, Compare: b})
+	}
+
+	t := tableWriter(w)
+	t.AppendHeader(table.Row{"Attempt", "A Min", "A Max", "B Min", "B Max", "Delta Min", "Delta Max"})
+
+	n := max(len(a.Entries), len(b.Entries))
+	for i := 0; i < n; i++ {
+		row := table.Row{i + 1, "", "", "", "", "", ""}
+		if i < len(a.Entries) {
+			row[1], row[2] = a.Entries[i].MinInterval, a.Entries[i].MaxInterval
+		}
+		if i < len(b.Entries) {
+			row[3], row[4] = b.Entries[i].MinInterval, b.Entries[i].MaxInterval
+		}
+		if i < len(a.Entries) && i < len(b.Entries) {
+			row[5] = b.Entries[i].MinInterval - a.Entries[i].MinInterval
+			row[6] = b.Entries[i].MaxInterval - a.Entries[i].MaxInterval
+		}
+		t.AppendRow(row)
 	}
-	if *gostruct {
-		tt := p.TimeTable(*attempts)
-		fmt.Println(tt.Litter())
-		return
+	t.AppendFooter(table.Row{"Total", a.MinTime, a.MaxTime, b.MinTime, b.MaxTime, b.MinTime - a.MinTime, b.MaxTime - a.MaxTime})
+
+	switch f {
+	case Text:
+		t.Render()
+	case CSV:
+		t.RenderCSV()
+	case Markdown:
+		t.RenderMarkdown()
+	default:
+		_, err := ParseFormat(string(f))
+		return err
 	}
+	return nil
+}
+
+// maxHandlerAttempts is the maximum number of attempts that can be requested from Handler().
+const maxHandlerAttempts = 1000
+
+// Handler returns an http.Handler that renders the TimeTable for the Policy from the PolicyProvider.
+// The "format" query parameter sets the Format and defaults to JSON. The "attempts" query parameter is
+// passed to Policy.TimeTable() and defaults to -1.
+func Handler(p exponential.PolicyProvider) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		f := JSON
+		if s := q.Get("format"); s != "" {
+			var err error
+			if f, err = ParseFormat(s); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		attempts := -1
+		if s := q.Get("attempts"); s != "" {
+			var err error
+			if attempts, err = strconv.Atoi(s); err != nil || attempts > maxHandlerAttempts {
+				http.Error(w, fmt.Sprintf("attempts must be an integer <= %d", maxHandlerAttempts), http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch f {
+		case JSON:
+			w.Header().Set("Content-Type", "application/json")
+		case CSV:
+			w.Header().Set("Content-Type", "text/csv")
+		case Markdown:
+			w.Header().Set("Content-Type", "text/markdown")
+		default:
+			w.Header().Set("Content-Type", "text/plain")
+		}
+		if err := Render(w, p.Policy().TimeTable(attempts), f); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
 
-	fmt.Println(p.TimeTable(*attempts))
+// tableWriter returns a table.Writer that writes to w.
+func tableWriter(w io.Writer) table.Writer {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	return t
 }
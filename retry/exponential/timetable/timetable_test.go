@@ -0,0 +1,136 @@
+package timetable
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+var testPolicy = exponential.Policy{
+	InitialInterval:     time.Second,
+	Multiplier:          2,
+	RandomizationFactor: 0.5,
+	MaxInterval:         4 * time.Second,
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	p, err := Load([]byte(`{
+		// comment
+		"initialInterval": "1s",
+		"maxInterval": "4s",
+	}`))
+	if err != nil {
+		t.Fatalf("TestLoad: got err == %s, want err == nil", err)
+	}
+	if p.InitialInterval != time.Second || p.MaxInterval != 4*time.Second {
+		t.Errorf("TestLoad: got %+v", p)
+	}
+	if _, err := Load([]byte(`{"multiplier": 1}`)); err == nil {
+		t.Errorf("TestLoad(invalid policy): got err == nil, want err != nil")
+	}
+	if _, err := LoadFile("does/not/exist.hujson"); err == nil {
+		t.Errorf("TestLoad(missing file): got err == nil, want err != nil")
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tt := testPolicy.TimeTable(3)
+
+	tests := []struct {
+		format  Format
+		want    string
+		wantErr bool
+	}{
+		{format: Text, want: "= TimeTable ="},
+		{format: JSON, want: `"MaxTime": 4500000000`},
+		{format: CSV, want: "2,1,0.5,1.5\n"},
+		{format: Markdown, want: "| 2 | 1s | 500ms | 1.5s |"},
+		{format: Format("yaml"), wantErr: true},
+	}
+
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		err := Render(buf, tt, test.format)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestRender(%s): got err == nil, want err != nil", test.format)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestRender(%s): got err == %s, want err == nil", test.format, err)
+		case !strings.Contains(buf.String(), test.want):
+			t.Errorf("TestRender(%s): output did not contain %q:\n%s", test.format, test.want, buf.String())
+		}
+	}
+}
+
+func TestRenderCompare(t *testing.T) {
+	t.Parallel()
+
+	other := testPolicy
+	other.RandomizationFactor = 0
+
+	buf := &bytes.Buffer{}
+	if err := RenderCompare(buf, testPolicy.TimeTable(3), other.TimeTable(2), CSV); err != nil {
+		t.Fatalf("TestRenderCompare: got err == %s, want err == nil", err)
+	}
+	for _, want := range []string{"2,500ms,1.5s,1s,1s,500ms,-500ms\n", "3,1s,3s,,,,\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("TestRenderCompare: output did not contain %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := RenderCompare(buf, testPolicy.TimeTable(3), other.TimeTable(2), JSON); err != nil {
+		t.Fatalf("TestRenderCompare(JSON): got err == %s, want err == nil", err)
+	}
+	var c Comparison
+	if err := json.Unmarshal(buf.Bytes(), &c); err != nil {
+		t.Fatalf("TestRenderCompare(JSON): could not decode output: %s", err)
+	}
+	if len(c.Policy.Entries) != 3 || len(c.Compare.Entries) != 2 {
+		t.Errorf("TestRenderCompare(JSON): got %d and %d entries, want 3 and 2", len(c.Policy.Entries), len(c.Compare.Entries))
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	ap, err := exponential.NewAtomicPolicy(testPolicy)
+	if err != nil {
+		panic(err)
+	}
+	h := Handler(ap)
+
+	tests := []struct {
+		url      string
+		wantCode int
+		want     string
+	}{
+		{url: "/", wantCode: http.StatusOK, want: `"Attempt": 4`},
+		{url: "/?format=markdown&attempts=2", wantCode: http.StatusOK, want: "| 2 | 1s | 500ms | 1.5s |"},
+		{url: "/?format=yaml", wantCode: http.StatusBadRequest},
+		{url: "/?attempts=lots", wantCode: http.StatusBadRequest},
+		{url: "/?attempts=1000000", wantCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.url, nil))
+		if rec.Code != test.wantCode {
+			t.Errorf("TestHandler(%s): got code %d, want %d", test.url, rec.Code, test.wantCode)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), test.want) {
+			t.Errorf("TestHandler(%s): body did not contain %q:\n%s", test.url, test.want, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package exponential
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrInvalidResult is the error recorded when a Validator passed to RetryUntil() rejects the result of
+// an attempt. It will be in Record.Err for the next attempt and is wrapped by the error returned from
+// RetryUntil() if the result never became valid.
+var ErrInvalidResult = errors.New("result was not valid")
+
+// ValueOp is an Op that returns a value. It is used with RetryUntil().
+type ValueOp[T any] func(context.Context, Record) (T, error)
+
+// Validator reports if the value returned by a ValueOp is acceptable. It is only called if the ValueOp
+// did not return an error.
+type Validator[T any] func(v T) bool
+
+// RetryUntil calls op with Backoff b until it returns a value that valid accepts. This is the poll until
+// ready pattern, such as waiting for a resource to leave a Provisioning state, where the call succeeds but
+// the result is not what you need yet. An error from op is handled just like in Retry(). A result that is
+// rejected by valid is retried as if op returned ErrInvalidResult. The value returned is the last value
+// returned by op, even if it was not valid, so that the caller can report the last state it saw.
+func RetryUntil[T any](ctx context.Context, b *Backoff, op ValueOp[T], valid Validator[T], options ...RetryOption) (T, error) {
+	var v T
+	err := b.Retry(
+		ctx,
+		func(ctx context.Context, r Record) error {
+			var err error
+			v, err = op(ctx, r)
+			if err != nil {
+				return err
+			}
+			if !valid(v) {
+				return ErrInvalidResult
+			}
+			return nil
+		},
+		options...,
+	)
+	return v, err
+}
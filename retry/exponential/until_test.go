@@ -0,0 +1,64 @@
+package exponential
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryUntil(t *testing.T) {
+	t.Parallel()
+
+	b, err := New(WithTesting())
+	if err != nil {
+		panic(err)
+	}
+
+	states := []string{"Provisioning", "Provisioning", "Ready"}
+	var gotErrs []error
+	got, err := RetryUntil(
+		context.Background(),
+		b,
+		func(ctx context.Context, r Record) (string, error) {
+			gotErrs = append(gotErrs, r.Err)
+			if r.Attempt == 2 {
+				return "", errors.New("transient error")
+			}
+			return states[r.Attempt-1], nil
+		},
+		func(s string) bool { return s == "Ready" },
+	)
+	if err != nil {
+		t.Fatalf("TestRetryUntil: got err == %s, want err == nil", err)
+	}
+	if got != "Ready" {
+		t.Errorf("TestRetryUntil: got %q, want %q", got, "Ready")
+	}
+	if !errors.Is(gotErrs[1], ErrInvalidResult) {
+		t.Errorf("TestRetryUntil: got Record.Err == %v on attempt 2, want ErrInvalidResult", gotErrs[1])
+	}
+
+	// The result never becomes valid before the Context expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	b, err = New(WithPolicy(Policy{InitialInterval: 10 * time.Millisecond, Multiplier: 2, MaxInterval: 10 * time.Millisecond}))
+	if err != nil {
+		panic(err)
+	}
+	got, err = RetryUntil(
+		ctx,
+		b,
+		func(ctx context.Context, r Record) (string, error) {
+			return fmt.Sprintf("Provisioning-%d", r.Attempt), nil
+		},
+		func(s string) bool { return false },
+	)
+	if !errors.Is(err, ErrInvalidResult) || !errors.Is(err, ErrRetryCanceled) {
+		t.Errorf("TestRetryUntil(never valid): got err == %v, want ErrInvalidResult and ErrRetryCanceled", err)
+	}
+	if got == "" {
+		t.Errorf("TestRetryUntil(never valid): got empty value, want the last value")
+	}
+}
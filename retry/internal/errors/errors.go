@@ -18,6 +18,10 @@ var (
 	// wrapped in another error. You can determine if you have a permanent error with
 	// Is(err, ErrPermanent).
 	ErrPermanent = errors.New("permanent error")
+
+	// ErrRetryExhausted is an error that is returned when the retry budget set by Policy.MaxCumulativeInterval
+	// would be exceeded by waiting for another attempt.
+	ErrRetryExhausted = errors.New("retry exhausted")
 )
 
 // ErrRetryAfter can be used to wrap an error to indicate that the error can be retried after a certain time.
@@ -50,3 +54,13 @@ func Is(err error, target error) bool {
 func As(err error, target interface{}) bool {
 	return errors.As(err, target)
 }
+
+// Unwrap is a wrapper for errors.Unwrap.
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
+
+// New is a wrapper for errors.New.
+func New(text string) error {
+	return errors.New(text)
+}
@@ -0,0 +1,67 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrAwaitClosed is the error wrapped by Request.Err when the channel an Await() State is waiting on is closed.
+var ErrAwaitClosed = errors.New("await channel closed")
+
+/*
+Await returns a State that blocks until an event is received on ch and then calls route with the event
+to choose the next State. This supports machines driven by external events, such as an approval step or
+a webhook. route is executed as part of the returned State, so it sets Request.Next, Request.Data and
+Request.Err like any State.
+
+If Request.Ctx is done before an event is received, Request.Err is set to an error wrapping the
+Context's error. If ch is closed, Request.Err is set to an error wrapping ErrAwaitClosed. When tracing,
+OTEL events are recorded when the wait starts and when it ends. The time waited is measured with the
+Clock set with WithClock().
+
+Example:
+
+	func RequestApproval(req statemachine.Request[Data]) statemachine.Request[Data] {
+		req.Data.approvals = req.Data.approver.Request(req.Ctx, req.Data.change)
+		req.Next = statemachine.Await(req.Data.approvals, Approved)
+		return req
+	}
+
+	func Approved(req statemachine.Request[Data], a Approval) statemachine.Request[Data] {
+		if !a.Approved {
+			req.Err = fmt.Errorf("change rejected by %s", a.By)
+			return req
+		}
+		req.Next = ApplyChange
+		return req
+	}
+*/
+func Await[T, E any](ch <-chan E, route func(req Request[T], event E) Request[T]) State[T] {
+	return func(req Request[T]) Request[T] {
+		if ch == nil || route == nil {
+			req.Err = errors.New("Await() cannot be passed a nil channel or route")
+			return req
+		}
+
+		clock := req.conf.getClock()
+		start := clock.Now()
+		req.addEvent("statemachine await start")
+
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				req.Err = ErrAwaitClosed
+				req.addEvent("statemachine await end", attribute.String("error", req.Err.Error()))
+				return req
+			}
+			req.addEvent("statemachine await end", attribute.Int64("waited_ns", int64(clock.Now().Sub(start))))
+			return route(req, ev)
+		case <-req.Ctx.Done():
+			req.Err = fmt.Errorf("await interrupted: %w", req.Ctx.Err())
+			req.addEvent("statemachine await end", attribute.String("error", req.Err.Error()))
+			return req
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAwait(t *testing.T) {
+	t.Parallel()
+
+	route := func(req Request[data], n int) Request[data] {
+		req.Data.Num = n
+		req.Next = addTen
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		ch      func() chan int
+		cancel  bool
+		wantNum int
+		wantErr error
+	}{
+		{
+			name: "Event",
+			ch: func() chan int {
+				ch := make(chan int, 1)
+				ch <- 5
+				return ch
+			},
+			wantNum: 15,
+		},
+		{
+			name: "Closed",
+			ch: func() chan int {
+				ch := make(chan int)
+				close(ch)
+				return ch
+			},
+			wantNum: 1,
+			wantErr: ErrAwaitClosed,
+		},
+		{
+			name:    "Cancelled",
+			ch:      func() chan int { return make(chan int) },
+			cancel:  true,
+			wantNum: 1,
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		rec, ctx := newFakeRecorder()
+		ctx, cancel := context.WithCancel(ctx)
+		if test.cancel {
+			cancel()
+		}
+
+		req := Request[data]{Ctx: ctx, Next: Await(test.ch(), route), Data: data{Num: 1}}
+		got, err := Run("test", req)
+		cancel()
+
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestAwait(%s): got err == %v, want %v", test.name, err, test.wantErr)
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestAwait(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+
+		var sp *fakeSpan
+		for _, s := range rec.spans {
+			if s.event("statemachine await start") != nil {
+				sp = s
+			}
+		}
+		if sp == nil || sp.event("statemachine await end") == nil {
+			t.Errorf("TestAwait(%s): did not record await events", test.name)
+		}
+	}
+}
+
+// stepClock is a fakeClock that moves forward by step every time Now() is called.
+type stepClock struct {
+	*fakeClock
+	step time.Duration
+}
+
+func (c stepClock) Now() time.Time {
+	c.advance(c.step)
+	return c.fakeClock.Now()
+}
+
+func TestAwaitClock(t *testing.T) {
+	t.Parallel()
+
+	route := func(req Request[data], n int) Request[data] {
+		req.Next = nil
+		return req
+	}
+	ch := make(chan int, 1)
+	ch <- 1
+
+	rec, ctx := newFakeRecorder()
+	req := Request[data]{Ctx: ctx, Next: Await(ch, route), Data: data{Num: 1}}
+	if _, err := Run("test", req, WithClock[data](stepClock{fakeClock: newFakeClock(), step: time.Hour})); err != nil {
+		t.Fatalf("TestAwaitClock: got err == %s, want err == nil", err)
+	}
+
+	var ev *fakeEvent
+	for _, s := range rec.spans {
+		if e := s.event("statemachine await end"); e != nil {
+			ev = e
+		}
+	}
+	if ev == nil {
+		t.Fatalf("TestAwaitClock: did not record the await end event")
+	}
+	if got, want := ev.attrs["waited_ns"], "3600000000000"; got != want {
+		t.Errorf("TestAwaitClock: got waited_ns == %s, want %s", got, want)
+	}
+}
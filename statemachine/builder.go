@@ -0,0 +1,268 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidTransition is the error wrapped by Request.Err when a Machine routes to a State that was not
+// declared as a transition, or stops in a State that was not declared as terminal.
+var ErrInvalidTransition = errors.New("invalid transition")
+
+/*
+Builder declares a Machine as data: its named States, the transitions between them and the States that
+may stop the machine. This allows a machine to be validated, rendered as a diagram and defined from a
+table, while the States are still the State functions used with Run(). Create with NewBuilder().
+
+Example:
+
+	m, err := statemachine.NewBuilder[Data]().
+		State("start", Start).
+		State("randomAuthor", RandomAuthor).
+		State("randomQuote", RandomQuote).
+		Transition("start", "randomAuthor", "randomQuote").
+		Transition("randomAuthor", "randomQuote").
+		Terminal("randomQuote").
+		Build()
+	if err != nil {
+		// Handle error
+	}
+
+	req, err = m.Run("quotes", statemachine.Request[Data]{Ctx: ctx, Data: Data{Author: *author}})
+
+As States route by setting Request.Next to a State function, every State must be a distinct function or
+method. Closures created by the same function, such as those from Wait() or Retry(), share a name and
+cannot be told apart, so use a named State that routes to them instead.
+*/
+type Builder[T any] struct {
+	start       string
+	order       []string
+	states      map[string]State[T]
+	transitions map[string][]string
+	terminals   map[string]bool
+	// symbols are the names from methodName() of States added by BuilderFromMethods(), keyed by State
+	// name. These States are created with reflection, so methodName() can't name them.
+	symbols map[string]string
+	errs    []error
+}
+
+// NewBuilder creates a new Builder.
+func NewBuilder[T any]() *Builder[T] {
+	return &Builder[T]{
+		states:      map[string]State[T]{},
+		transitions: map[string][]string{},
+		terminals:   map[string]bool{},
+		symbols:     map[string]string{},
+	}
+}
+
+// State adds the State fn called name. The first State added is where the Machine starts, unless
+// Start() is called.
+func (b *Builder[T]) State(name string, fn State[T]) *Builder[T] {
+	switch {
+	case strings.TrimSpace(name) == "":
+		b.errs = append(b.errs, errors.New("Builder.State() cannot be passed an empty name"))
+		return b
+	case fn == nil:
+		b.errs = append(b.errs, fmt.Errorf("Builder.State(%s) cannot be passed a nil State", name))
+		return b
+	}
+	if _, ok := b.states[name]; ok {
+		b.errs = append(b.errs, fmt.Errorf("Builder.State(%s) was called twice", name))
+		return b
+	}
+	b.states[name] = fn
+	b.order = append(b.order, name)
+	if b.start == "" {
+		b.start = name
+	}
+	return b
+}
+
+// Start sets the State the Machine starts with.
+func (b *Builder[T]) Start(name string) *Builder[T] {
+	b.start = name
+	return b
+}
+
+// Transition declares that the State from may route to each State in to.
+func (b *Builder[T]) Transition(from string, to ...string) *Builder[T] {
+	for _, t := range to {
+		if !slices.Contains(b.transitions[from], t) {
+			b.transitions[from] = append(b.transitions[from], t)
+		}
+	}
+	return b
+}
+
+// Terminal declares that the States may stop the Machine by not setting Request.Next.
+func (b *Builder[T]) Terminal(names ...string) *Builder[T] {
+	for _, n := range names {
+		b.terminals[n] = true
+	}
+	return b
+}
+
+// Build validates the declaration and returns the Machine.
+func (b *Builder[T]) Build() (*Machine[T], error) {
+	errs := slices.Clone(b.errs)
+	if len(b.states) == 0 {
+		errs = append(errs, errors.New("Builder has no States"))
+	}
+
+	known := func(kind, name string) {
+		if _, ok := b.states[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s %s is not a State", kind, name))
+		}
+	}
+	if len(b.states) > 0 {
+		known("start", b.start)
+	}
+	for _, from := range b.order {
+		for _, to := range b.transitions[from] {
+			known(fmt.Sprintf("transition %s ->", from), to)
+		}
+	}
+	for _, from := range sortedKeys(b.transitions) {
+		known("transition from", from)
+	}
+	for _, n := range sortedKeys(b.terminals) {
+		known("terminal", n)
+	}
+
+	names := make(map[string]string, len(b.states))
+	for _, n := range b.order {
+		symbol, ok := b.symbols[n]
+		if !ok {
+			symbol = methodName(b.states[n])
+		}
+		if other, ok := names[symbol]; ok {
+			errs = append(errs, fmt.Errorf("States %s and %s are the same function %s", other, n, symbol))
+			continue
+		}
+		names[symbol] = n
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	m := &Machine[T]{
+		start:       b.start,
+		order:       slices.Clone(b.order),
+		states:      maps.Clone(b.states),
+		transitions: make(map[string][]string, len(b.transitions)),
+		terminals:   maps.Clone(b.terminals),
+		names:       names,
+	}
+	for k, v := range b.transitions {
+		m.transitions[k] = slices.Clone(v)
+	}
+	if _, ok := b.symbols[b.start]; ok {
+		m.reflected = m.runStart
+		m.names[methodName(m.reflected)] = b.start
+	}
+	return m, nil
+}
+
+// Machine is a declared state machine created with a Builder. A Machine is safe for concurrent use.
+type Machine[T any] struct {
+	start       string
+	order       []string
+	states      map[string]State[T]
+	transitions map[string][]string
+	terminals   map[string]bool
+	// names maps the methodName() of each State to its name.
+	names map[string]string
+	// reflected is runStart() if the start State was added by BuilderFromMethods(). Otherwise it is nil.
+	reflected State[T]
+}
+
+// Run runs the Machine like the package level Run(). If req.Next is nil, the Machine starts with its start
+// State. States are named with the names they were declared with. If a State routes to a State that was not
+// declared as one of its transitions, or stops the Machine without being declared terminal, Run() stops
+// with an error wrapping ErrInvalidTransition.
+func (m *Machine[T]) Run(name string, req Request[T], options ...Option[T]) (Request[T], error) {
+	if req.Next == nil {
+		req.Next = m.states[m.start]
+		if m.reflected != nil {
+			req.Next = m.reflected
+		}
+	}
+
+	opts := make([]Option[T], 0, len(options)+1)
+	opts = append(opts, m.option())
+	opts = append(opts, options...)
+	return Run(name, req, opts...)
+}
+
+// runStart executes the start State. It is used when the start State was created with reflection, which
+// methodName() can't name, so that it is named by the name of Machine.reflected in Machine.names.
+func (m *Machine[T]) runStart(req Request[T]) Request[T] {
+	return m.states[m.start](req)
+}
+
+// State returns the State called name.
+func (m *Machine[T]) State(name string) (State[T], bool) {
+	s, ok := m.states[name]
+	return s, ok
+}
+
+// Graph returns the Graph of the Machine, which can be rendered with Graph.DOT() or Graph.Mermaid().
+func (m *Machine[T]) Graph(name string) *Graph {
+	g := NewGraph(name).SetStart(m.start)
+	for _, n := range m.order {
+		g.AddTransition(n, m.transitions[n]...)
+		if m.terminals[n] {
+			g.AddTerminal(n)
+		}
+	}
+	return g
+}
+
+// option returns the Option that names the States and enforces the declared transitions. Names set by
+// earlier Options are kept, except for States of the Machine.
+func (m *Machine[T]) option() Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if _, ok := m.names[methodName(req.Next)]; !ok {
+			return req, fmt.Errorf("Request.Next is %s, which is not a State of the Machine", methodName(req.Next))
+		}
+
+		if req.conf.names == nil {
+			req.conf.names = make(map[string]string, len(m.names))
+		}
+		maps.Copy(req.conf.names, m.names)
+		req.conf.middleware = append(req.conf.middleware, m.enforce)
+		return req, nil
+	}
+}
+
+// enforce is a Middleware that checks the State made a declared transition.
+func (m *Machine[T]) enforce(stateName string, req Request[T], next State[T]) Request[T] {
+	req = next(req)
+	switch {
+	case req.Err != nil:
+	case req.Next == nil:
+		if !m.terminals[stateName] {
+			req.Err = fmt.Errorf("%w: state %s stopped the machine but is not terminal", ErrInvalidTransition, stateName)
+		}
+	default:
+		to := req.conf.stateName(req.Next)
+		if !slices.Contains(m.transitions[stateName], to) {
+			req.Err = fmt.Errorf("%w: state %s routed to %s", ErrInvalidTransition, stateName, to)
+		}
+	}
+	return req
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewBuilder[data]().
+		State("steer", steer).
+		State("addTen", addTen).
+		State("addErr", addErr).
+		Transition("steer", "addTen", "addErr").
+		Terminal("steer", "addTen").
+		Build()
+	if err != nil {
+		t.Fatalf("TestBuilder: Build(): got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		num     int
+		wantNum int
+		wantErr bool
+	}{
+		{name: "Terminal start", num: 0, wantNum: 0},
+		{name: "Transition", num: 1, wantNum: 11},
+		{name: "State error", num: math.MaxInt, wantNum: math.MaxInt, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := m.Run("test", Request[data]{Ctx: context.Background(), Data: data{Num: test.num}})
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestBuilder(%s): got err == %s, want err == nil", test.name, err)
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestBuilder(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+	}
+
+	want := `stateDiagram-v2
+	state "steer" as s0
+	state "addTen" as s1
+	state "addErr" as s2
+	[*] --> s0
+	s0 --> s1
+	s0 --> s2
+	s0 --> [*]
+	s1 --> [*]
+`
+	if got := m.Graph("test").Mermaid(); got != want {
+		t.Errorf("TestBuilder: Graph(): got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMachineOption(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewBuilder[data]().State("steer", steer).Terminal("steer").Build()
+	if err != nil {
+		t.Fatalf("TestMachineOption: Build(): got err == %s, want err == nil", err)
+	}
+
+	req := Request[data]{Next: steer, conf: &runConfig[data]{}}
+	req, err = WithStateName(addTen, "ten")(req)
+	if err != nil {
+		t.Fatalf("TestMachineOption: WithStateName(): got err == %s, want err == nil", err)
+	}
+	req, err = m.option()(req)
+	if err != nil {
+		t.Fatalf("TestMachineOption: option(): got err == %s, want err == nil", err)
+	}
+
+	if got := req.conf.stateName(addTen); got != "ten" {
+		t.Errorf("TestMachineOption: got name of addTen %q, want %q", got, "ten")
+	}
+	if got := req.conf.stateName(steer); got != "steer" {
+		t.Errorf("TestMachineOption: got name of steer %q, want %q", got, "steer")
+	}
+}
+
+func TestBuilderEnforce(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		b       *Builder[data]
+		num     int
+		wantErr string
+	}{
+		{
+			name: "Undeclared transition",
+			b: NewBuilder[data]().
+				State("steer", steer).
+				State("addTen", addTen).
+				Terminal("steer", "addTen"),
+			num:     1,
+			wantErr: "state steer routed to addTen",
+		},
+		{
+			name: "Not terminal",
+			b: NewBuilder[data]().
+				State("steer", steer).
+				State("addTen", addTen).
+				Transition("steer", "addTen"),
+			num:     1,
+			wantErr: "state addTen stopped the machine but is not terminal",
+		},
+	}
+
+	for _, test := range tests {
+		m, err := test.b.Build()
+		if err != nil {
+			t.Fatalf("TestBuilderEnforce(%s): Build(): got err == %s, want err == nil", test.name, err)
+		}
+		_, err = m.Run("test", Request[data]{Ctx: context.Background(), Data: data{Num: test.num}})
+		if !errors.Is(err, ErrInvalidTransition) || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("TestBuilderEnforce(%s): got err == %v, want ErrInvalidTransition containing %q", test.name, err, test.wantErr)
+		}
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		b    *Builder[data]
+	}{
+		{name: "No states", b: NewBuilder[data]()},
+		{name: "Empty name", b: NewBuilder[data]().State("", steer)},
+		{name: "Nil State", b: NewBuilder[data]().State("steer", nil)},
+		{name: "Duplicate name", b: NewBuilder[data]().State("steer", steer).State("steer", addTen)},
+		{name: "Duplicate function", b: NewBuilder[data]().State("a", steer).State("b", steer)},
+		{name: "Unknown start", b: NewBuilder[data]().State("steer", steer).Start("nope")},
+		{name: "Unknown transition", b: NewBuilder[data]().State("steer", steer).Transition("steer", "nope")},
+		{name: "Unknown from", b: NewBuilder[data]().State("steer", steer).Transition("nope", "steer")},
+		{name: "Unknown terminal", b: NewBuilder[data]().State("steer", steer).Terminal("nope")},
+	}
+
+	for _, test := range tests {
+		if _, err := test.b.Build(); err == nil {
+			t.Errorf("TestBuilderErrors(%s): got err == nil, want err != nil", test.name)
+		}
+	}
+}
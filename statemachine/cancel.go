@@ -0,0 +1,35 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCanceled is returned by Run() when WithContextCheck() is set and Request.Ctx is done before a state
+// is executed. The returned error also wraps the Context's error, so errors.Is(err, context.Canceled)
+// or errors.Is(err, context.DeadlineExceeded) can be used to find the cause.
+var ErrCanceled = errors.New("statemachine stopped by Context")
+
+// WithContextCheck causes Run() to check Request.Ctx before executing each state. If the Context is done,
+// the machine stops with an error wrapping ErrCanceled that names the last state executed and the state
+// that would have been executed next. Request.Next is left set to that state, even if finalizers run.
+// Without this, every State must check the Context itself.
+func WithContextCheck[T any]() Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		req.conf.ctxCheck = true
+		return req, nil
+	}
+}
+
+// checkContext returns an error if context checking is on and ctx is done. last is the name of the last state
+// executed and next is the state about to be executed. c may be nil.
+func (c *runConfig[T]) checkContext(ctx context.Context, last string, next State[T]) error {
+	if c == nil || !c.ctxCheck || ctx.Err() == nil {
+		return nil
+	}
+	if last == "" {
+		last = "<none>"
+	}
+	return fmt.Errorf("%w before executing state %s, last state executed %s: %w", ErrCanceled, c.displayName(methodName(next)), last, ctx.Err())
+}
@@ -0,0 +1,101 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithContextCheck(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// cancelInSteer is canceled while executing steer.
+	cancelInSteer, cancelSteer := context.WithCancel(context.Background())
+	defer cancelSteer()
+	cancelMW := func(stateName string, req Request[data], next State[data]) Request[data] {
+		req = next(req)
+		if stateName == "github.com/gostdlib/ops/statemachine.steer" {
+			cancelSteer()
+		}
+		return req
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		options   []Option[data]
+		wantErr   bool
+		wantNum   int
+		wantNext  bool
+		wantInErr string
+	}{
+		{
+			name:    "No check, canceled Context",
+			ctx:     canceled,
+			wantNum: 11,
+		},
+		{
+			name:    "Check, Context not canceled",
+			ctx:     context.Background(),
+			options: []Option[data]{WithContextCheck[data]()},
+			wantNum: 11,
+		},
+		{
+			name:      "Check, canceled before first state",
+			ctx:       canceled,
+			options:   []Option[data]{WithContextCheck[data]()},
+			wantErr:   true,
+			wantNum:   1,
+			wantNext:  true,
+			wantInErr: "before executing state github.com/gostdlib/ops/statemachine.steer, last state executed <none>",
+		},
+		{
+			name:      "Check, canceled during a state",
+			ctx:       cancelInSteer,
+			options:   []Option[data]{WithContextCheck[data](), WithMiddleware(cancelMW)},
+			wantErr:   true,
+			wantNum:   1,
+			wantNext:  true,
+			wantInErr: "before executing state github.com/gostdlib/ops/statemachine.addTen, last state executed github.com/gostdlib/ops/statemachine.steer",
+		},
+		{
+			name:      "Check, canceled with a finalizer",
+			ctx:       canceled,
+			options:   []Option[data]{WithContextCheck[data](), WithFinalizer(addTen)},
+			wantErr:   true,
+			wantNum:   11,
+			wantNext:  true,
+			wantInErr: "before executing state github.com/gostdlib/ops/statemachine.steer, last state executed <none>",
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: test.ctx, Next: steer, Data: data{Num: 1}}
+		got, err := Run("test", req, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestWithContextCheck(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestWithContextCheck(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+				t.Errorf("TestWithContextCheck(%s): got err == %s, want it to wrap ErrCanceled and context.Canceled", test.name, err)
+			}
+			if !strings.Contains(err.Error(), test.wantInErr) {
+				t.Errorf("TestWithContextCheck(%s): got err == %s, want it to contain %q", test.name, err, test.wantInErr)
+			}
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestWithContextCheck(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+		if (got.Next != nil) != test.wantNext {
+			t.Errorf("TestWithContextCheck(%s): got Next set == %v, want %v", test.name, got.Next != nil, test.wantNext)
+		}
+	}
+}
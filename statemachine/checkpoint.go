@@ -0,0 +1,166 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoCheckpoint is returned by Checkpointer.Load() when there is no Checkpoint for an id.
+var ErrNoCheckpoint = errors.New("no checkpoint")
+
+// Checkpoint is the progress of a machine saved by RunResumable().
+type Checkpoint[T any] struct {
+	// Machine is the name of the machine that saved the Checkpoint.
+	Machine string
+	// Version is the version of the machine definition that saved the Checkpoint. It is set by a
+	// Checkpointer created with NewVersionedCheckpointer() and is 0 otherwise.
+	Version int
+	// State is the name of the next State to execute. If empty, the machine finished.
+	State string
+	// Data is the Request.Data after the last State that was executed.
+	Data T
+}
+
+// Checkpointer saves and loads Checkpoints for RunResumable(). Implementations store Checkpoints in
+// durable storage, such as a database or blob store, serializing Data in whatever form suits the
+// storage. A Checkpointer must be safe for concurrent use if multiple machines share it.
+type Checkpointer[T any] interface {
+	// Save saves the Checkpoint for the machine run identified by id, replacing any existing Checkpoint.
+	Save(ctx context.Context, id string, cp Checkpoint[T]) error
+	// Load loads the Checkpoint for the machine run identified by id. If there is no Checkpoint,
+	// it must return an error wrapping ErrNoCheckpoint.
+	Load(ctx context.Context, id string) (Checkpoint[T], error)
+}
+
+/*
+RunResumable runs the state machine like Run(), but saves a Checkpoint with cp after every State that
+succeeds. id identifies this run of the machine in cp. If a Checkpoint exists for id when RunResumable
+is called, the machine resumes from it instead of from req.Next, with req.Data replaced by the saved Data.
+This allows a long running machine to continue after a crash or a redeploy. If the Checkpoint shows
+the machine finished, RunResumable returns the saved Data without executing any State.
+
+If a State fails, the Checkpoint is not updated, so a resumed machine starts with the State that failed.
+
+As the next State is saved by name, every State the machine can route to must be passed in states.
+States must be functions or methods, not closures, as closures created by the same function share a name.
+If a Checkpoint names a State that is not in states, such as one saved before a State was renamed, an error
+wrapping ErrMigration is returned. Use NewVersionedCheckpointer() to map the names to the new definition.
+A Checkpoint saved by a machine with a different name returns an error. The branches of a Parallel() State
+do not save Checkpoints, so a resumed machine runs the Parallel() State again.
+*/
+func RunResumable[T any](name, id string, req Request[T], cp Checkpointer[T], states []State[T], options ...Option[T]) (Request[T], error) {
+	if cp == nil {
+		return req, errors.New("RunResumable() cannot be passed a nil Checkpointer")
+	}
+	if req.Ctx == nil {
+		req.Next = nil
+		return req, ctxNilErr
+	}
+
+	byName := make(map[string]State[T], len(states))
+	for _, s := range states {
+		byName[methodName(s)] = s
+	}
+
+	saved, err := cp.Load(req.Ctx, id)
+	switch {
+	case err == nil:
+		if saved.Machine != "" && saved.Machine != name {
+			return req, fmt.Errorf("checkpoint for %s was saved by machine %s, not %s", id, saved.Machine, name)
+		}
+		req.Data = saved.Data
+		if saved.State == "" {
+			req.Next = nil
+			return req, nil
+		}
+		next, ok := byName[saved.State]
+		if !ok {
+			return req, fmt.Errorf("%w: checkpoint for %s (version %d) has state %s, which was not passed to RunResumable()", ErrMigration, id, saved.Version, saved.State)
+		}
+		req.Next = next
+	case !errors.Is(err, ErrNoCheckpoint):
+		return req, fmt.Errorf("could not load checkpoint for %s: %w", id, err)
+	}
+
+	save := func(stateName string, req Request[T], next State[T]) Request[T] {
+		req = next(req)
+		if req.Err != nil || req.conf.branch {
+			return req
+		}
+
+		c := Checkpoint[T]{Machine: name, Data: req.Data}
+		if req.Next != nil {
+			c.State = methodName(req.Next)
+			if _, ok := byName[c.State]; !ok {
+				req.Err = fmt.Errorf("state %s routed to state %s, which was not passed to RunResumable()", stateName, c.State)
+				return req
+			}
+		}
+		if err := cp.Save(req.Ctx, id, c); err != nil {
+			req.Err = fmt.Errorf("could not save checkpoint after state %s: %w", stateName, err)
+		}
+		return req
+	}
+
+	return Run(name, req, append([]Option[T]{WithMiddleware(save)}, options...)...)
+}
+
+// MemCheckpointer is an in memory Checkpointer. Data is stored JSON encoded, so it behaves like a
+// Checkpointer that uses durable storage. This is useful for tests and for machines that only
+// need to resume after a State fails. Create with NewMemCheckpointer().
+type MemCheckpointer[T any] struct {
+	mu          sync.Mutex
+	checkpoints map[string]memCheckpoint
+}
+
+// memCheckpoint is a Checkpoint stored in a MemCheckpointer.
+type memCheckpoint struct {
+	machine string
+	version int
+	state   string
+	data    []byte
+}
+
+// NewMemCheckpointer creates a new MemCheckpointer.
+func NewMemCheckpointer[T any]() *MemCheckpointer[T] {
+	return &MemCheckpointer[T]{checkpoints: map[string]memCheckpoint{}}
+}
+
+// Save implements Checkpointer.Save().
+func (m *MemCheckpointer[T]) Save(ctx context.Context, id string, cp Checkpoint[T]) error {
+	b, err := json.Marshal(cp.Data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checkpoints[id] = memCheckpoint{machine: cp.Machine, version: cp.Version, state: cp.State, data: b}
+	return nil
+}
+
+// Load implements Checkpointer.Load().
+func (m *MemCheckpointer[T]) Load(ctx context.Context, id string) (Checkpoint[T], error) {
+	m.mu.Lock()
+	mc, ok := m.checkpoints[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return Checkpoint[T]{}, fmt.Errorf("%s: %w", id, ErrNoCheckpoint)
+	}
+	cp := Checkpoint[T]{Machine: mc.machine, Version: mc.version, State: mc.state}
+	if err := json.Unmarshal(mc.data, &cp.Data); err != nil {
+		return Checkpoint[T]{}, err
+	}
+	return cp, nil
+}
+
+// Delete deletes the Checkpoint for id.
+func (m *MemCheckpointer[T]) Delete(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.checkpoints, id)
+}
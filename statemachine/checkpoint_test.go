@@ -0,0 +1,116 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// resumable is a machine with three states where step2 fails until fail is false.
+type resumable struct {
+	fail  bool
+	calls []string
+}
+
+func (m *resumable) step1(req Request[data]) Request[data] {
+	m.calls = append(m.calls, "step1")
+	req.Data.Num += 1
+	req.Next = m.step2
+	return req
+}
+
+func (m *resumable) step2(req Request[data]) Request[data] {
+	m.calls = append(m.calls, "step2")
+	if m.fail {
+		req.Err = errors.New("step2 failed")
+		return req
+	}
+	req.Data.Num += 10
+	req.Next = m.step3
+	return req
+}
+
+func (m *resumable) step3(req Request[data]) Request[data] {
+	m.calls = append(m.calls, "step3")
+	req.Data.Num += 100
+	return req
+}
+
+func TestRunResumable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := &resumable{fail: true}
+	cp := NewMemCheckpointer[data]()
+	states := []State[data]{m.step1, m.step2, m.step3}
+
+	check := func(desc string, wantNum int, wantCalls []string, wantErr bool) {
+		t.Helper()
+		m.calls = nil
+		got, err := RunResumable("test", "id", Request[data]{Ctx: ctx, Next: m.step1}, cp, states)
+		switch {
+		case err == nil && wantErr:
+			t.Errorf("TestRunResumable(%s): got err == nil, want err != nil", desc)
+		case err != nil && !wantErr:
+			t.Errorf("TestRunResumable(%s): got err == %s, want err == nil", desc, err)
+		}
+		if got.Data.Num != wantNum {
+			t.Errorf("TestRunResumable(%s): got Data.Num == %d, want %d", desc, got.Data.Num, wantNum)
+		}
+		if len(m.calls) != len(wantCalls) {
+			t.Errorf("TestRunResumable(%s): got calls %v, want %v", desc, m.calls, wantCalls)
+			return
+		}
+		for i := range wantCalls {
+			if m.calls[i] != wantCalls[i] {
+				t.Errorf("TestRunResumable(%s): got calls %v, want %v", desc, m.calls, wantCalls)
+				return
+			}
+		}
+	}
+
+	check("First run fails in step2", 1, []string{"step1", "step2"}, true)
+	saved, err := cp.Load(ctx, "id")
+	if err != nil {
+		t.Fatalf("TestRunResumable: Load(): got err == %s, want err == nil", err)
+	}
+	if saved.State != methodName(m.step2) || saved.Data.Num != 1 {
+		t.Errorf("TestRunResumable: got checkpoint %+v, want State %s and Data.Num 1", saved, methodName(m.step2))
+	}
+
+	m.fail = false
+	check("Resume at step2", 111, []string{"step2", "step3"}, false)
+	check("Already finished", 111, nil, false)
+
+	cp.Delete("id")
+	check("Start over", 111, []string{"step1", "step2", "step3"}, false)
+
+	// A state that routes to a state that isn't registered fails.
+	cp.Delete("id")
+	m.calls = nil
+	if _, err := RunResumable("test", "id", Request[data]{Ctx: ctx, Next: m.step1}, cp, states[:1]); err == nil {
+		t.Errorf("TestRunResumable(Unregistered state): got err == nil, want err != nil")
+	}
+}
+
+func TestMemCheckpointer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cp := NewMemCheckpointer[data]()
+
+	if _, err := cp.Load(ctx, "id"); !errors.Is(err, ErrNoCheckpoint) {
+		t.Errorf("TestMemCheckpointer: Load() of missing id: got err == %v, want ErrNoCheckpoint", err)
+	}
+	want := Checkpoint[data]{State: "state", Data: data{Num: 3}}
+	if err := cp.Save(ctx, "id", want); err != nil {
+		t.Fatalf("TestMemCheckpointer: Save(): got err == %s, want err == nil", err)
+	}
+	got, err := cp.Load(ctx, "id")
+	if err != nil {
+		t.Fatalf("TestMemCheckpointer: Load(): got err == %s, want err == nil", err)
+	}
+	if got != want {
+		t.Errorf("TestMemCheckpointer: got %+v, want %+v", got, want)
+	}
+}
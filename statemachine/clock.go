@@ -0,0 +1,104 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+// WithClock sets the Clock used by states such as Wait(). This is the same Clock interface used by
+// exponential.WithClock(), so a fake Clock lets tests of a machine control time instead of waiting on
+// real timers.
+func WithClock[T any](c exponential.Clock) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if c == nil {
+			return req, errors.New("WithClock() cannot be passed a nil Clock")
+		}
+		req.conf.clock = c
+		return req, nil
+	}
+}
+
+// getClock returns the Clock set with WithClock() or a Clock that uses the time package. c may be nil.
+func (c *runConfig[T]) getClock() exponential.Clock {
+	if c == nil || c.clock == nil {
+		return realClock{}
+	}
+	return c.clock
+}
+
+/*
+Wait returns a State that waits for d and then routes to next. This is used by machines that need to
+pause between steps, such as when polling a cloud API for an operation to finish:
+
+	func CheckVM(req statemachine.Request[Data]) statemachine.Request[Data] {
+		ready, err := req.Data.client.VMReady(req.Ctx, req.Data.vm)
+		if err != nil {
+			req.Err = err
+			return req
+		}
+		if !ready {
+			req.Next = statemachine.Wait(10*time.Second, CheckVM)
+			return req
+		}
+		req.Next = AttachDisk
+		return req
+	}
+
+The wait uses the Clock passed with WithClock(), so tests can advance a fake Clock. If Request.Ctx is
+done before d, Request.Err is set to an error wrapping the Context's error.
+*/
+func Wait[T any](d time.Duration, next State[T]) State[T] {
+	return func(req Request[T]) Request[T] {
+		t := req.conf.getClock().NewTimer(d)
+		defer t.Stop()
+
+		select {
+		case <-t.C():
+			req.Next = next
+		case <-req.Ctx.Done():
+			req.Err = fmt.Errorf("wait of %v interrupted: %w", d, req.Ctx.Err())
+		}
+		return req
+	}
+}
+
+// realClock is an exponential.Clock that uses the time package.
+type realClock struct{}
+
+// Now implements exponential.Clock.Now().
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer implements exponential.Clock.NewTimer().
+func (realClock) NewTimer(d time.Duration) exponential.Timer {
+	return realTimer{timer: time.NewTimer(d)}
+}
+
+// Until implements exponential.Clock.Until().
+func (realClock) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+
+// realTimer is an exponential.Timer backed by a time.Timer.
+type realTimer struct {
+	timer *time.Timer
+}
+
+// C implements exponential.Timer.C().
+func (t realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Stop implements exponential.Timer.Stop().
+func (t realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// Reset implements exponential.Timer.Reset().
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
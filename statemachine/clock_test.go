@@ -0,0 +1,137 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+// fakeClock is an exponential.Clock whose time only moves when advance() is called.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	created chan time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0), created: make(chan time.Duration, 10)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) exponential.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	c.created <- d
+	return t
+}
+
+func (c *fakeClock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+// advance moves the time forward by d, firing any timers that expire.
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if !t.stopped && !t.fired && !t.at.After(c.now) {
+			t.fired = true
+			t.ch <- c.now
+		}
+	}
+}
+
+// fakeTimer is an exponential.Timer created by a fakeClock.
+type fakeTimer struct {
+	clock   *fakeClock
+	at      time.Time
+	ch      chan time.Time
+	fired   bool
+	stopped bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := !t.fired && !t.stopped
+	t.stopped = true
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := !t.fired && !t.stopped
+	t.at, t.fired, t.stopped = t.clock.now.Add(d), false, false
+	return active
+}
+
+func TestWait(t *testing.T) {
+	t.Parallel()
+
+	clock := newFakeClock()
+	req := Request[data]{Ctx: context.Background(), Next: Wait(time.Hour, addTen), Data: data{Num: 1}}
+
+	type result struct {
+		req Request[data]
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		got, err := Run("test", req, WithClock[data](clock))
+		done <- result{got, err}
+	}()
+
+	if d := <-clock.created; d != time.Hour {
+		t.Fatalf("TestWait: got timer for %v, want %v", d, time.Hour)
+	}
+	clock.advance(time.Minute)
+	select {
+	case <-done:
+		t.Fatalf("TestWait: Run() returned before the wait finished")
+	default:
+	}
+	clock.advance(time.Hour)
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("TestWait: got err == %s, want err == nil", r.err)
+	}
+	if r.req.Data.Num != 11 {
+		t.Errorf("TestWait: got Data.Num == %d, want 11", r.req.Data.Num)
+	}
+}
+
+func TestWaitCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := Request[data]{Ctx: ctx, Next: Wait(time.Hour, addTen), Data: data{Num: 1}}
+	_, err := Run("test", req)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("TestWaitCancel: got err == %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,68 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCompensation is wrapped by the error returned by Run() when a Compensation fails.
+var ErrCompensation = errors.New("compensation failed")
+
+// Compensation undoes the work of a State that succeeded. data is the Request.Data when the machine
+// stopped with an error. ctx is not cancelled when Request.Ctx is, so undo work is not lost when the
+// machine stopped because of a cancellation.
+type Compensation[T any] func(ctx context.Context, data T) error
+
+/*
+Compensate adds a Compensation that is run if the machine stops with an error. Compensations are run in
+the reverse order they were added, before any finalizers, which allows a machine to be used as a saga
+that undoes side effects of earlier States when a later one fails. Call Compensate in a State after its
+side effect has succeeded, on the Request that the State returns:
+
+	func CreateVM(req statemachine.Request[Data]) statemachine.Request[Data] {
+		vm, err := req.Data.client.CreateVM(req.Ctx)
+		if err != nil {
+			req.Err = err
+			return req
+		}
+		req.Data.vm = vm
+		req.Compensate(func(ctx context.Context, d Data) error {
+			return d.client.DeleteVM(ctx, d.vm)
+		})
+		req.Next = AttachDisk
+		return req
+	}
+
+Every Compensation is run even if one fails. Errors from Compensations are joined with Request.Err and
+wrap ErrCompensation.
+*/
+func (r *Request[T]) Compensate(c Compensation[T]) {
+	if c == nil {
+		return
+	}
+	r.compensations = append(r.compensations, c)
+}
+
+// runCompensations runs the Compensations in req in reverse order if req.Err is set. It returns the Status
+// of the failure, which is 0 if the machine did not fail.
+func runCompensations[T any](req Request[T]) (Request[T], Status) {
+	if req.Err == nil {
+		req.compensations = nil
+		return req, 0
+	}
+
+	ctx := context.WithoutCancel(req.Ctx)
+	errs := []error{req.Err}
+	for i := len(req.compensations) - 1; i >= 0; i-- {
+		if err := req.compensations[i](ctx, req.Data); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrCompensation, err))
+		}
+	}
+	if len(errs) > 1 {
+		req.Err = errors.Join(errs...)
+	}
+	status := failedStatus(len(req.compensations), len(errs) > 1)
+	req.compensations = nil
+	return req, status
+}
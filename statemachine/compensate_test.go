@@ -0,0 +1,89 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestCompensate(t *testing.T) {
+	t.Parallel()
+
+	errStep := errors.New("step failed")
+	errUndo := errors.New("undo failed")
+
+	var undone []string
+	// step returns a State that registers a Compensation named id, then routes to next or fails if next is nil.
+	step := func(id string, undoErr error, next State[data]) State[data] {
+		return func(req Request[data]) Request[data] {
+			req.Data.Num++
+			req.Compensate(func(ctx context.Context, d data) error {
+				if ctx.Err() != nil {
+					t.Errorf("TestCompensate: Compensation got a cancelled Context")
+				}
+				undone = append(undone, id)
+				return undoErr
+			})
+			if next == nil {
+				req.Err = errStep
+				return req
+			}
+			req.Next = next
+			return req
+		}
+	}
+
+	tests := []struct {
+		name       string
+		start      State[data]
+		wantErrs   []error
+		wantUndone []string
+	}{
+		{
+			name:  "Success does not compensate",
+			start: step("a", nil, step("b", nil, addTen)),
+		},
+		{
+			name:       "Failure compensates in reverse",
+			start:      step("a", nil, step("b", nil, step("c", nil, nil))),
+			wantErrs:   []error{errStep},
+			wantUndone: []string{"c", "b", "a"},
+		},
+		{
+			name:       "Compensation failure",
+			start:      step("a", errUndo, step("b", nil, step("c", nil, nil))),
+			wantErrs:   []error{errStep, errUndo, ErrCompensation},
+			wantUndone: []string{"c", "b", "a"},
+		},
+	}
+
+	for _, test := range tests {
+		undone = nil
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req := Request[data]{Ctx: ctx, Next: test.start}
+		if test.wantErrs != nil {
+			// Compensations must run even if the Context is cancelled.
+			cancel()
+		}
+		got, err := Run("test", req)
+		cancel()
+
+		if err == nil && test.wantErrs != nil {
+			t.Errorf("TestCompensate(%s): got err == nil, want err != nil", test.name)
+		}
+		for _, want := range test.wantErrs {
+			if !errors.Is(err, want) {
+				t.Errorf("TestCompensate(%s): got err == %v, want it to wrap %v", test.name, err, want)
+			}
+		}
+		if got.compensations != nil {
+			t.Errorf("TestCompensate(%s): got Request.compensations != nil, want nil", test.name)
+		}
+		if diff := pretty.Compare(test.wantUndone, undone); diff != "" {
+			t.Errorf("TestCompensate(%s): compensations -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrStopped is returned by a machine run with RunAsync() when Controller.Stop() is called. The returned
+// error wraps ErrStopped and names the State that would have been executed next, which Request.Next is
+// left set to.
+var ErrStopped = errors.New("statemachine stopped by Controller")
+
+// control pauses and stops a machine at state boundaries.
+type control struct {
+	mu      sync.Mutex
+	paused  bool
+	stopped bool
+	// changed is closed and replaced when paused or stopped changes.
+	changed chan struct{}
+}
+
+func newControl() *control {
+	return &control{changed: make(chan struct{})}
+}
+
+// set updates the control with f and wakes any machine waiting at a state boundary.
+func (c *control) set(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f()
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// boundary is called before each state. It blocks while the machine is paused and returns an error if
+// the machine was stopped or ctx is done while paused.
+func (c *control) boundary(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		stopped, paused, changed := c.stopped, c.paused, c.changed
+		c.mu.Unlock()
+
+		switch {
+		case stopped:
+			return ErrStopped
+		case !paused:
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w while paused: %w", ErrCanceled, ctx.Err())
+		case <-changed:
+		}
+	}
+}
+
+// checkControl returns an error if the machine was stopped by a Controller. If the machine is paused, it blocks
+// until it is resumed. next is the state about to be executed. c may be nil.
+func (c *runConfig[T]) checkControl(ctx context.Context, next State[T]) error {
+	if c == nil || c.control == nil {
+		return nil
+	}
+	if err := c.control.boundary(ctx); err != nil {
+		return fmt.Errorf("before executing state %s: %w", c.displayName(methodName(next)), err)
+	}
+	return nil
+}
+
+// Controller controls a machine started with RunAsync(). Pause(), Resume() and Stop() take effect before
+// the next State is executed, the State that is executing is not interrupted.
+type Controller[T any] struct {
+	c    *control
+	done chan struct{}
+
+	req Request[T]
+	err error
+}
+
+/*
+RunAsync runs the state machine like Run() in a new goroutine and returns a Controller to pause, resume or
+stop it. This allows an operator to halt a misbehaving long running machine without stopping the process.
+The result is returned by Controller.Wait().
+
+	ctl := statemachine.RunAsync("provision", req)
+	...
+	ctl.Pause()
+	...
+	ctl.Resume()
+	req, err := ctl.Wait(ctx)
+*/
+func RunAsync[T any](name string, req Request[T], options ...Option[T]) *Controller[T] {
+	ctl := &Controller[T]{c: newControl(), done: make(chan struct{})}
+
+	withControl := func(req Request[T]) (Request[T], error) {
+		req.conf.control = ctl.c
+		return req, nil
+	}
+	go func() {
+		defer close(ctl.done)
+		ctl.req, ctl.err = Run(name, req, append(options[:len(options):len(options)], withControl)...)
+	}()
+	return ctl
+}
+
+// Pause pauses the machine before the next State is executed. While paused, the machine stops if
+// Request.Ctx is done.
+func (c *Controller[T]) Pause() {
+	c.c.set(func() { c.c.paused = true })
+}
+
+// Resume resumes a paused machine.
+func (c *Controller[T]) Resume() {
+	c.c.set(func() { c.c.paused = false })
+}
+
+// Stop stops the machine before the next State is executed, with an error wrapping ErrStopped. This also
+// stops a paused machine. Finalizers and Compensations are run as they would be for any error.
+func (c *Controller[T]) Stop() {
+	c.c.set(func() { c.c.stopped = true })
+}
+
+// Paused reports if Pause() was called without a following Resume().
+func (c *Controller[T]) Paused() bool {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+	return c.c.paused
+}
+
+// Done returns a channel that is closed when the machine finishes.
+func (c *Controller[T]) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait waits for the machine to finish and returns the results of Run(). If ctx is done first,
+// it returns ctx.Err() and the machine continues.
+func (c *Controller[T]) Wait(ctx context.Context) (Request[T], error) {
+	select {
+	case <-ctx.Done():
+		return Request[T]{}, ctx.Err()
+	case <-c.done:
+		return c.req, c.err
+	}
+}
@@ -0,0 +1,160 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// gated is a machine whose step State signals when it is entered and waits to be released.
+type gated struct {
+	enter   chan int
+	release chan struct{}
+}
+
+func newGated() *gated {
+	return &gated{enter: make(chan int), release: make(chan struct{})}
+}
+
+func (g *gated) step(req Request[data]) Request[data] {
+	g.enter <- req.Data.Num
+	<-g.release
+	req.Data.Num++
+	if req.Data.Num < 3 {
+		req.Next = g.step
+	}
+	return req
+}
+
+// next releases the executing step and waits for the next step to be entered.
+func (g *gated) next(t *testing.T, want int) {
+	t.Helper()
+	g.release <- struct{}{}
+	select {
+	case got := <-g.enter:
+		if got != want {
+			t.Fatalf("entered step with Data.Num == %d, want %d", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("step was not entered")
+	}
+}
+
+func TestControllerPause(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	g := newGated()
+	ctl := RunAsync("test", Request[data]{Ctx: ctx, Next: g.step})
+	<-g.enter
+
+	ctl.Pause()
+	if !ctl.Paused() {
+		t.Errorf("TestControllerPause: got Paused() == false, want true")
+	}
+	g.release <- struct{}{}
+	select {
+	case <-g.enter:
+		t.Fatalf("TestControllerPause: step entered while paused")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	ctl.Resume()
+	if ctl.Paused() {
+		t.Errorf("TestControllerPause: got Paused() == true, want false")
+	}
+	<-g.enter
+	g.next(t, 2)
+	g.release <- struct{}{}
+
+	got, err := ctl.Wait(ctx)
+	if err != nil {
+		t.Fatalf("TestControllerPause: got err == %s, want err == nil", err)
+	}
+	if got.Data.Num != 3 {
+		t.Errorf("TestControllerPause: got Data.Num == %d, want 3", got.Data.Num)
+	}
+	select {
+	case <-ctl.Done():
+	default:
+		t.Errorf("TestControllerPause: Done() not closed after Wait() returned")
+	}
+}
+
+func TestControllerStop(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	finalizer := func(req Request[data]) Request[data] { return req }
+
+	tests := []struct {
+		name    string
+		pause   bool
+		options []Option[data]
+	}{
+		{name: "Running"},
+		{name: "Paused", pause: true},
+		{name: "With finalizer", options: []Option[data]{WithFinalizer(finalizer)}},
+	}
+
+	for _, test := range tests {
+		g := newGated()
+		ctl := RunAsync("test", Request[data]{Ctx: ctx, Next: g.step}, test.options...)
+		<-g.enter
+		if test.pause {
+			ctl.Pause()
+			g.release <- struct{}{}
+			ctl.Stop()
+		} else {
+			ctl.Stop()
+			g.release <- struct{}{}
+		}
+
+		got, err := ctl.Wait(ctx)
+		if !errors.Is(err, ErrStopped) {
+			t.Errorf("TestControllerStop(%s): got err == %v, want ErrStopped", test.name, err)
+		}
+		if got.Data.Num != 1 || got.Next == nil {
+			t.Errorf("TestControllerStop(%s): got Data.Num == %d, Next set == %v, want 1, true", test.name, got.Data.Num, got.Next != nil)
+		}
+	}
+}
+
+func TestControllerCancelWhilePaused(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	g := newGated()
+	ctl := RunAsync("test", Request[data]{Ctx: ctx, Next: g.step})
+	<-g.enter
+	ctl.Pause()
+	g.release <- struct{}{}
+	cancel()
+
+	_, err := ctl.Wait(context.Background())
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("TestControllerCancelWhilePaused: got err == %v, want ErrCanceled and context.Canceled", err)
+	}
+}
+
+func TestControllerWaitCtx(t *testing.T) {
+	t.Parallel()
+
+	g := newGated()
+	ctl := RunAsync("test", Request[data]{Ctx: context.Background(), Next: g.step})
+	<-g.enter
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := ctl.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TestControllerWaitCtx: got err == %v, want context.DeadlineExceeded", err)
+	}
+
+	ctl.Stop()
+	g.release <- struct{}{}
+	<-ctl.Done()
+}
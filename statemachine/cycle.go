@@ -0,0 +1,34 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCycle is returned by Run() when cyclic state detection is on and a state is visited more times
+// than allowed. The returned error wraps ErrCycle and contains the call trace of the states executed.
+var ErrCycle = errors.New("cyclic state detected")
+
+// WithCycleDetection causes Run() to stop with an error wrapping ErrCycle when a state is visited a
+// second time. This finds routing bugs that would otherwise loop forever. The error contains the call
+// trace of the states that were executed.
+func WithCycleDetection[T any]() Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		req.conf.cycles = true
+		return req, nil
+	}
+}
+
+// WithAllowedRevisits turns on cyclic state detection, like WithCycleDetection(), but allows each state
+// to be revisited n times before Run() stops with an error wrapping ErrCycle. This is for machines that
+// legitimately loop a bounded number of times, such as a state that polls for a result.
+func WithAllowedRevisits[T any](n int) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if n < 0 {
+			return req, fmt.Errorf("WithAllowedRevisits(%d) must be >= 0", n)
+		}
+		req.conf.cycles = true
+		req.conf.revisits = n
+		return req, nil
+	}
+}
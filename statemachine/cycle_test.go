@@ -0,0 +1,92 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// loop is a State that routes to itself until Data.Num reaches 0.
+func loop(req Request[data]) Request[data] {
+	if req.Data.Num > 0 {
+		req.Data.Num--
+		req.Next = loop
+	}
+	return req
+}
+
+func TestCycleDetection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		num     int
+		options []Option[data]
+		wantErr bool
+	}{
+		{
+			name: "No detection",
+			num:  5,
+		},
+		{
+			name:    "Detection, no loop",
+			num:     0,
+			options: []Option[data]{WithCycleDetection[data]()},
+		},
+		{
+			name:    "Detection, loop",
+			num:     1,
+			options: []Option[data]{WithCycleDetection[data]()},
+			wantErr: true,
+		},
+		{
+			name:    "Revisits within limit",
+			num:     3,
+			options: []Option[data]{WithAllowedRevisits[data](3)},
+		},
+		{
+			name:    "Revisits over limit",
+			num:     4,
+			options: []Option[data]{WithAllowedRevisits[data](3)},
+			wantErr: true,
+		},
+		{
+			name:    "Negative revisits",
+			num:     0,
+			options: []Option[data]{WithAllowedRevisits[data](-1)},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: loop, Data: data{Num: test.num}}
+		got, err := Run("test", req, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestCycleDetection(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestCycleDetection(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		if got.seenStages != nil {
+			t.Errorf("TestCycleDetection(%s): got Request.seenStages != nil, want nil", test.name)
+		}
+	}
+}
+
+func TestCycleDetectionError(t *testing.T) {
+	t.Parallel()
+
+	req := Request[data]{Ctx: context.Background(), Next: loop, Data: data{Num: 2}}
+	_, err := Run("test", req, WithAllowedRevisits[data](1))
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("TestCycleDetectionError: got err == %v, want ErrCycle", err)
+	}
+	const name = "github.com/gostdlib/ops/statemachine.loop"
+	wantTrace := strings.Join([]string{name, name, name}, " -> ")
+	if !strings.Contains(err.Error(), wantTrace) {
+		t.Errorf("TestCycleDetectionError: got err == %s, want it to contain call trace %s", err, wantTrace)
+	}
+}
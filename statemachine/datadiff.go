@@ -0,0 +1,117 @@
+package statemachine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// diffJSON returns the structural differences between the JSON documents a and b, one per line, or the empty
+// string if they are the same. Each line starts with the path of the value that differs, such as
+// ".Items[2].Name", followed by "old -> new" for a changed value, "+ new" for an added value or "- old" for
+// a removed value. If a or b is not valid JSON, the whole documents are compared.
+func diffJSON(a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	va, erra := decodeJSON(a)
+	vb, errb := decodeJSON(b)
+	if erra != nil || errb != nil {
+		return fmt.Sprintf(".: %s -> %s", a, b)
+	}
+
+	out := &strings.Builder{}
+	diffValues(".", va, vb, out)
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// decodeJSON decodes s, keeping numbers as json.Number so they are not changed by float conversion.
+func decodeJSON(s string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// diffValues writes the differences between a and b at path to out.
+func diffValues(path string, a, b any, out *strings.Builder) {
+	switch ta := a.(type) {
+	case map[string]any:
+		tb, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(ta)+len(tb))
+		for k := range ta {
+			keys = append(keys, k)
+		}
+		for k := range tb {
+			if _, ok := ta[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			p := joinPath(path, k)
+			va, oka := ta[k]
+			vb, okb := tb[k]
+			switch {
+			case !oka:
+				fmt.Fprintf(out, "%s: + %s\n", p, encodeJSON(vb))
+			case !okb:
+				fmt.Fprintf(out, "%s: - %s\n", p, encodeJSON(va))
+			default:
+				diffValues(p, va, vb, out)
+			}
+		}
+		return
+	case []any:
+		tb, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(ta) || i < len(tb); i++ {
+			p := fmt.Sprintf("%s[%d]", strings.TrimSuffix(path, "."), i)
+			switch {
+			case i >= len(ta):
+				fmt.Fprintf(out, "%s: + %s\n", p, encodeJSON(tb[i]))
+			case i >= len(tb):
+				fmt.Fprintf(out, "%s: - %s\n", p, encodeJSON(ta[i]))
+			default:
+				diffValues(p, ta[i], tb[i], out)
+			}
+		}
+		return
+	}
+
+	ea, eb := encodeJSON(a), encodeJSON(b)
+	if ea != eb {
+		fmt.Fprintf(out, "%s: %s -> %s\n", path, ea, eb)
+	}
+}
+
+// joinPath returns the path of key in the object at path.
+func joinPath(path, key string) string {
+	if path == "." {
+		return "." + key
+	}
+	return path + "." + key
+}
+
+// encodeJSON encodes a decoded JSON value.
+func encodeJSON(v any) string {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
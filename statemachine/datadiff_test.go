@@ -0,0 +1,106 @@
+package statemachine
+
+import "testing"
+
+func TestDiffJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "Same",
+			a:    `{"Num":1}`,
+			b:    `{"Num":1}`,
+		},
+		{
+			name: "Same after decoding",
+			a:    `{"A":1,"B":2}`,
+			b:    `{"B":2, "A":1}`,
+		},
+		{
+			name: "Changed field",
+			a:    `{"Num":1,"Name":"a"}`,
+			b:    `{"Num":11,"Name":"a"}`,
+			want: `.Num: 1 -> 11`,
+		},
+		{
+			name: "Added and removed fields",
+			a:    `{"Old":true,"Keep":1}`,
+			b:    `{"New":"<x>","Keep":1}`,
+			want: ".New: + \"<x>\"\n.Old: - true",
+		},
+		{
+			name: "Nested",
+			a:    `{"VM":{"Disks":[{"Name":"a"},{"Name":"b"}]}}`,
+			b:    `{"VM":{"Disks":[{"Name":"a"},{"Name":"c"},{"Name":"d"}]}}`,
+			want: ".VM.Disks[1].Name: \"b\" -> \"c\"\n.VM.Disks[2]: + {\"Name\":\"d\"}",
+		},
+		{
+			name: "Root array",
+			a:    `[1,2]`,
+			b:    `[1]`,
+			want: `[1]: - 2`,
+		},
+		{
+			name: "Type change",
+			a:    `{"V":{"A":1}}`,
+			b:    `{"V":[1]}`,
+			want: `.V: {"A":1} -> [1]`,
+		},
+		{
+			name: "Large numbers are exact",
+			a:    `{"N":9007199254740993}`,
+			b:    `{"N":9007199254740992}`,
+			want: `.N: 9007199254740993 -> 9007199254740992`,
+		},
+		{
+			name: "Not JSON",
+			a:    `Error marshaling data`,
+			b:    `{"Num":1}`,
+			want: `.: Error marshaling data -> {"Num":1}`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := diffJSON(test.a, test.b); got != test.want {
+			t.Errorf("TestDiffJSON(%s): got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestStateDataDiffEvent(t *testing.T) {
+	t.Parallel()
+
+	rec, ctx := newFakeRecorder()
+	req := Request[data]{Ctx: ctx, Next: steer, Data: data{Num: 1}}
+	if _, err := Run("test", req); err != nil {
+		t.Fatalf("TestStateDataDiffEvent: got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{state: "github.com/gostdlib/ops/statemachine.steer"},
+		{state: "github.com/gostdlib/ops/statemachine.addTen", want: ".Num: 1 -> 11"},
+	}
+	for _, test := range tests {
+		s := rec.span("State(" + test.state + ")")
+		if s == nil {
+			t.Fatalf("TestStateDataDiffEvent(%s): span not found", test.state)
+		}
+		var got string
+		for _, e := range s.events {
+			if v, ok := e.attrs["data_diff"]; ok {
+				got = v
+			}
+		}
+		if got != test.want {
+			t.Errorf("TestStateDataDiffEvent(%s): got data_diff %q, want %q", test.state, got, test.want)
+		}
+	}
+}
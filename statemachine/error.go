@@ -0,0 +1,60 @@
+package statemachine
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error is the error returned by Run() when a machine fails. It wraps the error that stopped the machine,
+// so errors.Is() and errors.As() work with the State's error, and tells which State failed without parsing
+// the error text. Errors in the arguments to Run() are not returned as an *Error.
+type Error struct {
+	machine   string
+	state     string
+	callTrace []string
+	elapsed   time.Duration
+	err       error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.state == "" {
+		return fmt.Sprintf("statemachine(%s): %s", e.machine, e.err)
+	}
+	return fmt.Sprintf("statemachine(%s): state %s: %s", e.machine, e.state, e.err)
+}
+
+// Unwrap returns the error that stopped the machine.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Machine returns the name of the machine passed to Run().
+func (e *Error) Machine() string {
+	return e.machine
+}
+
+// State returns the name of the last State executed, which is the State that failed unless the machine
+// was stopped between States, such as by WithContextCheck(). This is empty if no State was executed.
+func (e *Error) State() string {
+	return e.state
+}
+
+// CallTrace returns the names of the States executed, in order. With WithCycleDetection() or
+// WithAllowedRevisits() this is every State executed, otherwise it is the last 5 States.
+func (e *Error) CallTrace() []string {
+	return e.callTrace
+}
+
+// Elapsed returns how long the machine ran before it stopped.
+func (e *Error) Elapsed() time.Duration {
+	return e.elapsed
+}
+
+// newError returns err wrapped in an *Error. If err is nil, nil is returned.
+func newError(machine, state string, callTrace []string, elapsed time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{machine: machine, state: state, callTrace: callTrace, elapsed: elapsed, err: err}
+}
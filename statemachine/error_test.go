@@ -0,0 +1,119 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	const (
+		steerName  = "github.com/gostdlib/ops/statemachine.steer"
+		addErrName = "github.com/gostdlib/ops/statemachine.addErr"
+		loopName   = "github.com/gostdlib/ops/statemachine.loop"
+	)
+
+	tests := []struct {
+		name          string
+		req           Request[data]
+		options       []Option[data]
+		wantState     string
+		wantCallTrace []string
+		wantIs        error
+	}{
+		{
+			name:          "State error",
+			req:           Request[data]{Next: steer, Data: data{Num: math.MaxInt}},
+			wantState:     addErrName,
+			wantCallTrace: []string{steerName, addErrName},
+		},
+		{
+			name:          "Last 5 states",
+			req:           Request[data]{Next: loop, Data: data{Num: 10}},
+			options:       []Option[data]{WithMaxTransitions[data](7)},
+			wantState:     loopName,
+			wantCallTrace: []string{loopName, loopName, loopName, loopName, loopName},
+			wantIs:        ErrMaxTransitions,
+		},
+		{
+			name:          "Full trace with cycle detection",
+			req:           Request[data]{Next: loop, Data: data{Num: 10}},
+			options:       []Option[data]{WithAllowedRevisits[data](6)},
+			wantState:     loopName,
+			wantCallTrace: []string{loopName, loopName, loopName, loopName, loopName, loopName, loopName, loopName},
+			wantIs:        ErrCycle,
+		},
+	}
+
+	for _, test := range tests {
+		test.req.Ctx = context.Background()
+		_, err := Run("test", test.req, test.options...)
+
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("TestError(%s): got err == %v, want *Error", test.name, err)
+			continue
+		}
+		if test.wantIs != nil && !errors.Is(err, test.wantIs) {
+			t.Errorf("TestError(%s): got err == %s, want it to wrap %s", test.name, err, test.wantIs)
+		}
+		if e.Machine() != "test" {
+			t.Errorf("TestError(%s): got Machine() == %s, want test", test.name, e.Machine())
+		}
+		if e.State() != test.wantState {
+			t.Errorf("TestError(%s): got State() == %s, want %s", test.name, e.State(), test.wantState)
+		}
+		if diff := pretty.Compare(test.wantCallTrace, e.CallTrace()); diff != "" {
+			t.Errorf("TestError(%s): CallTrace() -want/+got:\n%s", test.name, diff)
+		}
+		if e.Elapsed() <= 0 {
+			t.Errorf("TestError(%s): got Elapsed() == %v, want > 0", test.name, e.Elapsed())
+		}
+	}
+
+	// Errors in the arguments are not an *Error.
+	_, err := Run("", Request[data]{Ctx: context.Background(), Next: steer})
+	var e *Error
+	if errors.As(err, &e) {
+		t.Errorf("TestError: got *Error for an argument error, want a plain error")
+	}
+}
+
+func TestErrorString(t *testing.T) {
+	t.Parallel()
+
+	errBase := errors.New("base")
+	tests := []struct {
+		name string
+		err  *Error
+		want string
+	}{
+		{
+			name: "With state",
+			err:  &Error{machine: "m", state: "s", err: errBase},
+			want: "statemachine(m): state s: base",
+		},
+		{
+			name: "Without state",
+			err:  &Error{machine: "m", err: errBase},
+			want: "statemachine(m): base",
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("TestErrorString(%s): got %q, want %q", test.name, got, test.want)
+		}
+		if !errors.Is(test.err, errBase) {
+			t.Errorf("TestErrorString(%s): errors.Is() failed to find the wrapped error", test.name)
+		}
+	}
+	if newError("m", "s", nil, 0, nil) != nil {
+		t.Errorf("TestErrorString: newError(nil): got != nil, want nil")
+	}
+}
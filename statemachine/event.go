@@ -0,0 +1,76 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// TransitionKind is the kind of a Transition.
+type TransitionKind uint8
+
+const (
+	// Entered indicates a State is about to be executed.
+	Entered TransitionKind = 1
+	// Exited indicates a State has finished executing.
+	Exited TransitionKind = 2
+)
+
+// String implements fmt.Stringer.
+func (k TransitionKind) String() string {
+	switch k {
+	case Entered:
+		return "Entered"
+	case Exited:
+		return "Exited"
+	}
+	return "Unknown"
+}
+
+// Transition is an event sent by WithEventChannel() when a State is entered or exited.
+type Transition[T any] struct {
+	// Kind is if the State was entered or exited.
+	Kind TransitionKind
+	// Machine is the name of the machine passed to Run().
+	Machine string
+	// State is the name of the State.
+	State string
+	// Data is Request.Data when the event was sent. If T holds pointers, the values they point to
+	// may change after the event is sent.
+	Data T
+	// Err is the error the State returned. Only set when Kind is Exited.
+	Err error
+	// Time is when the event occurred.
+	Time time.Time
+	// Duration is how long the State executed for. Only set when Kind is Exited.
+	Duration time.Duration
+}
+
+// WithEventChannel sends a Transition on ch when each State is entered and exited. This allows progress
+// of long running machines to be followed without OTEL, such as for a dashboard or progress bar. Sending
+// blocks the machine until ch is read or Request.Ctx is done, in which case the event is dropped, so ch
+// should be buffered and read promptly. ch is not closed by Run().
+func WithEventChannel[T any](ch chan<- Transition[T]) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if ch == nil {
+			return req, errors.New("WithEventChannel() cannot be passed a nil channel")
+		}
+		return WithObserver[T](channelObserver[T]{ch: ch})(req)
+	}
+}
+
+// channelObserver is an Observer that sends Transitions on a channel.
+type channelObserver[T any] struct {
+	ch chan<- Transition[T]
+}
+
+func (channelObserver[T]) OnStart(string, Request[T]) {}
+
+func (o channelObserver[T]) OnTransition(ctx context.Context, t Transition[T]) {
+	select {
+	case <-ctx.Done():
+	case o.ch <- t:
+	}
+}
+
+func (channelObserver[T]) OnEnd(string, Request[T], time.Duration) {}
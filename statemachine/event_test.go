@@ -0,0 +1,70 @@
+package statemachine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestWithEventChannel(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan Transition[data], 10)
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}}
+	if _, err := Run("test", req, WithEventChannel(ch)); err != nil {
+		t.Fatalf("TestWithEventChannel: got err == %s, want err == nil", err)
+	}
+	close(ch)
+
+	const (
+		steerName  = "github.com/gostdlib/ops/statemachine.steer"
+		addTenName = "github.com/gostdlib/ops/statemachine.addTen"
+	)
+	want := []Transition[data]{
+		{Kind: Entered, Machine: "test", State: steerName, Data: data{Num: 1}},
+		{Kind: Exited, Machine: "test", State: steerName, Data: data{Num: 1}},
+		{Kind: Entered, Machine: "test", State: addTenName, Data: data{Num: 1}},
+		{Kind: Exited, Machine: "test", State: addTenName, Data: data{Num: 11}},
+	}
+
+	var got []Transition[data]
+	for tr := range ch {
+		if tr.Time.IsZero() {
+			t.Errorf("TestWithEventChannel: got Transition with zero Time: %+v", tr)
+		}
+		if tr.Kind == Exited && tr.Duration <= 0 {
+			t.Errorf("TestWithEventChannel: got exit Transition with Duration <= 0: %+v", tr)
+		}
+		tr.Time, tr.Duration = time.Time{}, 0
+		got = append(got, tr)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestWithEventChannel: -want/+got:\n%s", diff)
+	}
+}
+
+func TestWithEventChannelErrors(t *testing.T) {
+	t.Parallel()
+
+	req := Request[data]{Ctx: context.Background(), Next: steer}
+	if _, err := Run("test", req, WithEventChannel[data](nil)); err == nil {
+		t.Errorf("TestWithEventChannelErrors: WithEventChannel(nil): got err == nil, want err != nil")
+	}
+
+	// An unread channel does not block a machine with a done Context.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = Request[data]{Ctx: ctx, Next: addErr}
+	_, err := Run("test", req, WithEventChannel(make(chan Transition[data])))
+	if err == nil {
+		t.Errorf("TestWithEventChannelErrors: got err == nil, want the state error")
+	}
+
+	for k, want := range map[TransitionKind]string{Entered: "Entered", Exited: "Exited", 0: "Unknown"} {
+		if got := k.String(); got != want {
+			t.Errorf("TestWithEventChannelErrors: TransitionKind(%d).String(): got %s, want %s", k, got, want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package statemachine
+
+import "errors"
+
+// Defer adds a finalizer state that is run when the machine stops, whether that is because a State
+// did not set Request.Next, a State set Request.Err or the Context was cancelled. Finalizers are run
+// in the reverse order they were added, like the defer statement, and each receives the final Request,
+// including Request.Err. Request.Next is nil for finalizers and is restored after they run, so Run()
+// returns the Request.Next the machine stopped with. A finalizer can change Request.Data and Request.Err,
+// which are returned by Run().
+//
+// Call Defer in a State on the Request that the State returns:
+//
+//	func CreateVM(req statemachine.Request[Data]) statemachine.Request[Data] {
+//		vm, err := req.Data.client.CreateVM(req.Ctx)
+//		if err != nil {
+//			req.Err = err
+//			return req
+//		}
+//		req.Data.vm = vm
+//		req.Defer(ReleaseLease)
+//		req.Next = AttachDisk
+//		return req
+//	}
+//
+// As Request.Ctx may be cancelled when a finalizer runs, finalizers that need to make calls should
+// use context.WithoutCancel().
+func (r *Request[T]) Defer(state State[T]) {
+	if state == nil {
+		return
+	}
+	r.deferred = append(r.deferred, state)
+}
+
+// WithFinalizer adds a finalizer state that is always run when the machine stops. This is the same as
+// calling Request.Defer() before Run(). Finalizers added with WithFinalizer are added before any added by
+// a State, so they run after them.
+func WithFinalizer[T any](state State[T]) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if state == nil {
+			return req, errors.New("WithFinalizer() cannot be passed a nil State")
+		}
+		req.Defer(state)
+		return req, nil
+	}
+}
+
+// runFinalizers runs the finalizers in req in reverse order and returns the final Request with
+// Request.Next restored.
+func runFinalizers[T any](req Request[T]) Request[T] {
+	if len(req.deferred) == 0 {
+		return req
+	}
+
+	next := req.Next
+	for len(req.deferred) > 0 {
+		last := len(req.deferred) - 1
+		state := req.deferred[last]
+		req.deferred = req.deferred[:last]
+
+		req.Next = nil
+		deferred := req.deferred
+		req = state(req)
+		// A finalizer cannot add finalizers or route to another state.
+		req.deferred = deferred
+		req.Next = nil
+	}
+	req.deferred = nil
+	req.Next = next
+	return req
+}
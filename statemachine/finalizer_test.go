@@ -0,0 +1,86 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestFinalizers(t *testing.T) {
+	t.Parallel()
+
+	errState := errors.New("state failed")
+
+	var calls []string
+	var gotErrs []error
+	cleanup := func(id string) State[data] {
+		return func(req Request[data]) Request[data] {
+			calls = append(calls, id)
+			gotErrs = append(gotErrs, req.Err)
+			req.Next = addTen // Ignored.
+			return req
+		}
+	}
+
+	// work adds a finalizer and routes to addTen, or fails if Data.Num is negative.
+	work := func(req Request[data]) Request[data] {
+		req.Defer(cleanup("state"))
+		if req.Data.Num < 0 {
+			req.Err = errState
+			return req
+		}
+		req.Next = addTen
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		num     int
+		wantErr error
+		wantNum int
+	}{
+		{name: "Success", num: 1, wantNum: 11},
+		{name: "Error", num: -1, wantErr: errState, wantNum: -1},
+	}
+
+	for _, test := range tests {
+		calls, gotErrs = nil, nil
+
+		req := Request[data]{Ctx: context.Background(), Next: work, Data: data{Num: test.num}}
+		got, err := Run("test", req, WithFinalizer(cleanup("option1")), WithFinalizer(cleanup("option2")))
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestFinalizers(%s): got err == %v, want %v", test.name, err, test.wantErr)
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestFinalizers(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+		if got.Next != nil || got.deferred != nil {
+			t.Errorf("TestFinalizers(%s): got Request.Next or Request.deferred != nil, want nil", test.name)
+		}
+		if diff := pretty.Compare([]string{"state", "option2", "option1"}, calls); diff != "" {
+			t.Errorf("TestFinalizers(%s): call order -want/+got:\n%s", test.name, diff)
+		}
+		for _, e := range gotErrs {
+			if !errors.Is(e, test.wantErr) {
+				t.Errorf("TestFinalizers(%s): finalizer got Request.Err == %v, want %v", test.name, e, test.wantErr)
+			}
+		}
+	}
+}
+
+func TestFinalizerSetsErr(t *testing.T) {
+	t.Parallel()
+
+	errCleanup := errors.New("cleanup failed")
+	cleanup := func(req Request[data]) Request[data] {
+		req.Err = errCleanup
+		return req
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: addTen}
+	if _, err := Run("test", req, WithFinalizer(cleanup)); !errors.Is(err, errCleanup) {
+		t.Errorf("TestFinalizerSetsErr: got err == %v, want %v", err, errCleanup)
+	}
+}
@@ -0,0 +1,223 @@
+package statemachine
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+Graph is the transition graph of a machine, which can be rendered for design reviews and documentation.
+States in a Graph are identified by name, which for a State is StateName(state).
+
+A Graph can be declared by adding each transition, or discovered by running the machine with
+RecordGraph(), such as in tests that exercise every path. Example:
+
+	g := statemachine.NewGraph("quotes")
+
+	for _, test := range tests {
+		req, err := statemachine.Run("quotes", test.req, statemachine.RecordGraph[Data](g))
+		...
+	}
+	fmt.Println(g.DOT())
+	fmt.Println(g.Mermaid())
+
+A Graph is safe for concurrent use.
+*/
+type Graph struct {
+	name string
+
+	mu        sync.Mutex
+	start     string
+	nodes     []string
+	edges     map[string][]string
+	terminals map[string]bool
+}
+
+// NewGraph creates a new Graph for the machine called name.
+func NewGraph(name string) *Graph {
+	return &Graph{name: name, edges: map[string][]string{}, terminals: map[string]bool{}}
+}
+
+// StateName returns the name of state, as used in a Graph, traces and errors, when it does not
+// have a custom name set with WithStateName().
+func StateName[T any](state State[T]) string {
+	return methodName(state)
+}
+
+// SetStart sets the state the machine starts with.
+func (g *Graph) SetStart(state string) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addNode(state)
+	g.start = state
+	return g
+}
+
+// AddTransition adds transitions from the state from to each state in to.
+func (g *Graph) AddTransition(from string, to ...string) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.addNode(from)
+	for _, t := range to {
+		g.addNode(t)
+		if !slices.Contains(g.edges[from], t) {
+			g.edges[from] = append(g.edges[from], t)
+		}
+	}
+	return g
+}
+
+// AddTerminal marks the states as ones that can stop the machine by not setting Request.Next.
+func (g *Graph) AddTerminal(states ...string) *Graph {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, s := range states {
+		g.addNode(s)
+		g.terminals[s] = true
+	}
+	return g
+}
+
+// addNode adds state to the nodes if it does not exist. g.mu must be held.
+func (g *Graph) addNode(state string) {
+	if _, ok := g.edges[state]; ok {
+		return
+	}
+	g.edges[state] = nil
+	g.nodes = append(g.nodes, state)
+}
+
+// RecordGraph adds every transition made during Run() to g. The first state executed is set as the start
+// of g if g does not have one.
+func RecordGraph[T any](g *Graph) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if g == nil {
+			return req, fmt.Errorf("RecordGraph() cannot be passed a nil Graph")
+		}
+
+		first := true
+		mw := func(stateName string, req Request[T], next State[T]) Request[T] {
+			if first {
+				first = false
+				g.mu.Lock()
+				if g.start == "" {
+					g.addNode(stateName)
+					g.start = stateName
+				}
+				g.mu.Unlock()
+			}
+
+			req = next(req)
+			switch {
+			case req.Err != nil:
+			case req.Next == nil:
+				g.AddTerminal(stateName)
+			default:
+				g.AddTransition(stateName, req.conf.stateName(req.Next))
+			}
+			return req
+		}
+		req.conf.middleware = append(req.conf.middleware, mw)
+		return req, nil
+	}
+}
+
+// DOT returns the Graph in the Graphviz DOT language. Nodes are labeled with the state name without
+// its package path. The start is marked by an arrow from a point and terminal states have a double border.
+func (g *Graph) DOT() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	out := strings.Builder{}
+	fmt.Fprintf(&out, "digraph %s {\n", strconv.Quote(g.name))
+	if g.start != "" {
+		out.WriteString("\t\"__start\" [shape=point];\n")
+		fmt.Fprintf(&out, "\t\"__start\" -> %s;\n", strconv.Quote(g.start))
+	}
+	for _, n := range g.nodes {
+		shape := "box"
+		if g.terminals[n] {
+			shape = "doubleoctagon"
+		}
+		fmt.Fprintf(&out, "\t%s [label=%s, shape=%s];\n", strconv.Quote(n), strconv.Quote(shortName(n)), shape)
+	}
+	for _, n := range g.nodes {
+		for _, t := range g.edges[n] {
+			fmt.Fprintf(&out, "\t%s -> %s;\n", strconv.Quote(n), strconv.Quote(t))
+		}
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// Mermaid returns the Graph as a Mermaid state diagram, which can be embedded in Markdown that supports
+// Mermaid, such as on GitHub. States are labeled with the state name without its package path.
+func (g *Graph) Mermaid() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := make(map[string]string, len(g.nodes))
+	out := strings.Builder{}
+	out.WriteString("stateDiagram-v2\n")
+	for i, n := range g.nodes {
+		ids[n] = fmt.Sprintf("s%d", i)
+		label := strings.ReplaceAll(shortName(n), `"`, "#quot;")
+		fmt.Fprintf(&out, "\tstate \"%s\" as %s\n", label, ids[n])
+	}
+	if g.start != "" {
+		fmt.Fprintf(&out, "\t[*] --> %s\n", ids[g.start])
+	}
+	for _, n := range g.nodes {
+		for _, t := range g.edges[n] {
+			fmt.Fprintf(&out, "\t%s --> %s\n", ids[n], ids[t])
+		}
+		if g.terminals[n] {
+			fmt.Fprintf(&out, "\t%s --> [*]\n", ids[n])
+		}
+	}
+	return out.String()
+}
+
+// MarshalJSON implements json.Marshaler. The Graph is encoded as an object with the machine name, the
+// start state, the states, the transitions from each state and the terminal states. A state without
+// transitions has an empty list.
+func (g *Graph) MarshalJSON() ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	transitions := make(map[string][]string, len(g.edges))
+	for n, to := range g.edges {
+		if to == nil {
+			to = []string{}
+		}
+		transitions[n] = to
+	}
+
+	terminals := make([]string, 0, len(g.terminals))
+	for _, n := range g.nodes {
+		if g.terminals[n] {
+			terminals = append(terminals, n)
+		}
+	}
+	return json.Marshal(
+		struct {
+			Name        string              `json:"name"`
+			Start       string              `json:"start,omitempty"`
+			States      []string            `json:"states"`
+			Transitions map[string][]string `json:"transitions"`
+			Terminals   []string            `json:"terminals"`
+		}{g.name, g.start, g.nodes, transitions, terminals},
+	)
+}
+
+// shortName returns the state name without its package path.
+func shortName(state string) string {
+	return state[strings.LastIndex(state, "/")+1:]
+}
@@ -0,0 +1,80 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+func TestGraphDOT(t *testing.T) {
+	t.Parallel()
+
+	g := NewGraph("test")
+	for _, num := range []int{0, 1} {
+		req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: num}}
+		if _, err := Run("test", req, RecordGraph[data](g)); err != nil {
+			t.Fatalf("TestGraphDOT: got err == %s, want err == nil", err)
+		}
+	}
+
+	want := `digraph "test" {
+	"__start" [shape=point];
+	"__start" -> "github.com/gostdlib/ops/statemachine.steer";
+	"github.com/gostdlib/ops/statemachine.steer" [label="statemachine.steer", shape=doubleoctagon];
+	"github.com/gostdlib/ops/statemachine.addTen" [label="statemachine.addTen", shape=doubleoctagon];
+	"github.com/gostdlib/ops/statemachine.steer" -> "github.com/gostdlib/ops/statemachine.addTen";
+}
+`
+	if got := g.DOT(); got != want {
+		t.Errorf("TestGraphDOT: -want/+got:\n%s", diff.Diff(want, got))
+	}
+
+	// A declared Graph renders the same way.
+	declared := NewGraph("test").
+		SetStart(StateName(steer)).
+		AddTransition(StateName(steer), StateName(addTen)).
+		AddTerminal(StateName(steer), StateName(addTen))
+	if got := declared.DOT(); got != want {
+		t.Errorf("TestGraphDOT(declared): -want/+got:\n%s", diff.Diff(want, got))
+	}
+}
+
+func TestGraphMermaid(t *testing.T) {
+	t.Parallel()
+
+	g := NewGraph("test").
+		SetStart(StateName(steer)).
+		AddTransition(StateName(steer), StateName(addTen), StateName(addErr)).
+		AddTerminal(StateName(steer), StateName(addTen))
+
+	want := `stateDiagram-v2
+	state "statemachine.steer" as s0
+	state "statemachine.addTen" as s1
+	state "statemachine.addErr" as s2
+	[*] --> s0
+	s0 --> s1
+	s0 --> s2
+	s0 --> [*]
+	s1 --> [*]
+`
+	if got := g.Mermaid(); got != want {
+		t.Errorf("TestGraphMermaid: -want/+got:\n%s", diff.Diff(want, got))
+	}
+}
+
+func TestGraphMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	g := NewGraph("test").SetStart("steer").AddTransition("steer", "addTen").AddTerminal("addTen")
+	got, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("TestGraphMarshalJSON: got err == %s, want err == nil", err)
+	}
+
+	want := `{"name":"test","start":"steer","states":["steer","addTen"],"transitions":{"addTen":[],"steer":["addTen"]},"terminals":["addTen"]}`
+	if string(got) != want {
+		t.Errorf("TestGraphMarshalJSON: got %s, want %s", got, want)
+	}
+}
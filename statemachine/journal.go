@@ -0,0 +1,330 @@
+package statemachine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrJournalMismatch is returned by RunJournaled() when a machine is resumed with a State whose input
+// differs from the input recorded in the Journal.
+var ErrJournalMismatch = errors.New("journal input mismatch")
+
+// JournalKind is the kind of a JournalEntry.
+type JournalKind uint8
+
+const (
+	// JournalStarted indicates a State is about to be executed. It is written before the State executes.
+	JournalStarted JournalKind = 1
+	// JournalCompleted indicates a State executed without an error.
+	JournalCompleted JournalKind = 2
+	// JournalFailed indicates a State returned an error.
+	JournalFailed JournalKind = 3
+)
+
+// String implements fmt.Stringer.
+func (k JournalKind) String() string {
+	switch k {
+	case JournalStarted:
+		return "Started"
+	case JournalCompleted:
+		return "Completed"
+	case JournalFailed:
+		return "Failed"
+	}
+	return "Unknown"
+}
+
+// JournalEntry is a record in a Journal.
+type JournalEntry struct {
+	// Seq is the position of the entry in the Journal for a run, starting at 0.
+	Seq int `json:"seq"`
+	// Kind is the kind of entry.
+	Kind JournalKind `json:"kind"`
+	// Machine is the name of the machine.
+	Machine string `json:"machine"`
+	// State is the name of the State.
+	State string `json:"state"`
+	// InputHash is the hex encoded SHA-256 hash of the JSON encoding of the Request.Data passed to the State.
+	InputHash string `json:"inputHash"`
+	// Next is the name of the State the machine routed to. Only set when Kind is JournalCompleted.
+	// If empty, the machine finished.
+	Next string `json:"next,omitempty"`
+	// Data is the JSON encoding of Request.Data after the State. Only set when Kind is JournalCompleted.
+	Data json.RawMessage `json:"data,omitempty"`
+	// Err is the error the State returned. Only set when Kind is JournalFailed.
+	Err string `json:"err,omitempty"`
+	// Time is when the entry was written.
+	Time time.Time `json:"time"`
+}
+
+// Journal is an append only log of the States executed by machine runs, used by RunJournaled().
+// Implementations store entries in durable storage, such as a file, a SQL table or a blob store.
+// A Journal must be safe for concurrent use if multiple machines share it.
+type Journal interface {
+	// Append appends e to the journal for the machine run identified by id. It must not return until
+	// e is durably stored.
+	Append(ctx context.Context, id string, e JournalEntry) error
+	// Entries returns the entries for the machine run identified by id in the order they were appended.
+	// If there are none, it returns an empty slice and no error.
+	Entries(ctx context.Context, id string) ([]JournalEntry, error)
+}
+
+/*
+RunJournaled runs the state machine like Run(), but writes every transition to j before and after the
+State executes. id identifies this run of the machine in j. The Journal is an audit trail of the run,
+recording the hash of the input to each State, where it routed and the data it produced.
+
+If j has entries for id when RunJournaled is called, the machine resumes after the last State that
+completed, with req.Data replaced by the Data it produced. A State that completed is never executed again,
+so a machine resumed after a crash or a redeploy continues exactly where it stopped. If the machine
+finished, RunJournaled returns the journaled Data without executing any State.
+
+A State that was started but did not complete, such as when the process crashed while it executed, is
+executed again, so it should be idempotent. Its input must hash to the recorded InputHash, otherwise an
+error wrapping ErrJournalMismatch is returned. This also detects a caller resuming a run with different data.
+
+Data is JSON encoded, so T must be JSON serializable. As with RunResumable(), every State the machine can
+route to must be passed in states and States must be functions or methods, not closures. The branches of
+a Parallel() State are not journaled, so a resumed machine runs the Parallel() State again unless the State
+that ran it completed.
+*/
+func RunJournaled[T any](name, id string, req Request[T], j Journal, states []State[T], options ...Option[T]) (Request[T], error) {
+	if j == nil {
+		return req, errors.New("RunJournaled() cannot be passed a nil Journal")
+	}
+	if req.Ctx == nil {
+		req.Next = nil
+		return req, ctxNilErr
+	}
+
+	byName := make(map[string]State[T], len(states))
+	for _, s := range states {
+		byName[methodName(s)] = s
+	}
+
+	entries, err := j.Entries(req.Ctx, id)
+	if err != nil {
+		return req, fmt.Errorf("could not read journal for %s: %w", id, err)
+	}
+	if len(entries) > 0 {
+		if entries[0].Machine != name {
+			return req, fmt.Errorf("journal for %s was written by machine %s, not %s", id, entries[0].Machine, name)
+		}
+		req, err = resumeJournal(id, req, entries, byName)
+		if err != nil || req.Next == nil {
+			return req, err
+		}
+	}
+
+	seq := len(entries)
+	write := func(e JournalEntry) error {
+		e.Seq, e.Machine, e.Time = seq, name, time.Now()
+		seq++
+		if err := j.Append(context.WithoutCancel(req.Ctx), id, e); err != nil {
+			return fmt.Errorf("could not append to journal for %s: %w", id, err)
+		}
+		return nil
+	}
+
+	journal := func(stateName string, req Request[T], next State[T]) Request[T] {
+		if req.conf.branch {
+			return next(req)
+		}
+
+		in, err := json.Marshal(req.Data)
+		if err != nil {
+			req.Err = fmt.Errorf("could not journal input to state %s: %w", stateName, err)
+			return req
+		}
+		symbol := methodName(next)
+		hash := hashInput(in)
+		if err := write(JournalEntry{Kind: JournalStarted, State: symbol, InputHash: hash}); err != nil {
+			req.Err = err
+			return req
+		}
+
+		req = next(req)
+		if req.Err != nil {
+			if err := write(JournalEntry{Kind: JournalFailed, State: symbol, InputHash: hash, Err: req.Err.Error()}); err != nil {
+				req.Err = errors.Join(req.Err, err)
+			}
+			return req
+		}
+
+		e := JournalEntry{Kind: JournalCompleted, State: symbol, InputHash: hash}
+		if req.Next != nil {
+			e.Next = methodName(req.Next)
+			if _, ok := byName[e.Next]; !ok {
+				req.Err = fmt.Errorf("state %s routed to state %s, which was not passed to RunJournaled()", stateName, e.Next)
+				return req
+			}
+		}
+		if e.Data, err = json.Marshal(req.Data); err != nil {
+			req.Err = fmt.Errorf("could not journal output of state %s: %w", stateName, err)
+			return req
+		}
+		if err := write(e); err != nil {
+			req.Err = err
+		}
+		return req
+	}
+
+	return Run(name, req, append([]Option[T]{WithMiddleware(journal)}, options...)...)
+}
+
+// resumeJournal returns req set to resume the run journaled in entries. If the machine finished, req.Next is nil.
+func resumeJournal[T any](id string, req Request[T], entries []JournalEntry, byName map[string]State[T]) (Request[T], error) {
+	last := -1
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Kind == JournalCompleted {
+			last = i
+			break
+		}
+	}
+
+	if last >= 0 {
+		c := entries[last]
+		if err := json.Unmarshal(c.Data, &req.Data); err != nil {
+			return req, fmt.Errorf("could not decode journaled data for %s: %w", id, err)
+		}
+		if c.Next == "" {
+			req.Next = nil
+			return req, nil
+		}
+		next, ok := byName[c.Next]
+		if !ok {
+			return req, fmt.Errorf("journal for %s has state %s, which was not passed to RunJournaled()", id, c.Next)
+		}
+		req.Next = next
+	}
+
+	if e := entries[len(entries)-1]; e.Kind == JournalStarted {
+		in, err := json.Marshal(req.Data)
+		if err != nil {
+			return req, fmt.Errorf("could not hash input to state %s: %w", e.State, err)
+		}
+		if hashInput(in) != e.InputHash || methodName(req.Next) != e.State {
+			return req, fmt.Errorf("%w: journal for %s started state %s with different input", ErrJournalMismatch, id, e.State)
+		}
+	}
+	return req, nil
+}
+
+// hashInput returns the hex encoded SHA-256 hash of b.
+func hashInput(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// MemJournal is an in memory Journal. This is useful for tests. Create with NewMemJournal().
+type MemJournal struct {
+	mu      sync.Mutex
+	entries map[string][]JournalEntry
+}
+
+// NewMemJournal creates a new MemJournal.
+func NewMemJournal() *MemJournal {
+	return &MemJournal{entries: map[string][]JournalEntry{}}
+}
+
+// Append implements Journal.Append().
+func (m *MemJournal) Append(ctx context.Context, id string, e JournalEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[id] = append(m.entries[id], e)
+	return nil
+}
+
+// Entries implements Journal.Entries().
+func (m *MemJournal) Entries(ctx context.Context, id string) ([]JournalEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.entries[id]), nil
+}
+
+// FileJournal is a Journal that stores the entries for each run in a file in a directory, one JSON
+// encoded entry per line. Create with NewFileJournal().
+type FileJournal struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileJournal creates a FileJournal that stores files in dir, which is created if it does not exist.
+func NewFileJournal(dir string) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileJournal{dir: dir}, nil
+}
+
+// path returns the path of the file for id.
+func (f *FileJournal) path(id string) string {
+	return filepath.Join(f.dir, url.PathEscape(id)+".journal")
+}
+
+// Append implements Journal.Append(). The file is synced before Append returns.
+func (f *FileJournal) Append(ctx context.Context, id string, e JournalEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path(id), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(b); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Entries implements Journal.Entries(). A partially written last line, such as from a crash during
+// Append(), is ignored.
+func (f *FileJournal) Entries(ctx context.Context, id string) ([]JournalEntry, error) {
+	f.mu.Lock()
+	b, err := os.ReadFile(f.path(id))
+	f.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []JournalEntry{}, nil
+		}
+		return nil, err
+	}
+
+	entries := []JournalEntry{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	s.Buffer(nil, len(b)+1)
+	for s.Scan() {
+		var e JournalEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			if !bytes.HasSuffix(b, []byte("\n")) && bytes.HasSuffix(b, s.Bytes()) {
+				break
+			}
+			return nil, fmt.Errorf("journal for %s has a corrupt entry: %w", id, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, s.Err()
+}
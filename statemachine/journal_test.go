@@ -0,0 +1,251 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRunJournaled(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := &resumable{fail: true}
+	j := NewMemJournal()
+	states := []State[data]{m.step1, m.step2, m.step3}
+
+	check := func(desc string, wantNum int, wantCalls []string, wantErr bool) {
+		t.Helper()
+		m.calls = nil
+		got, err := RunJournaled("test", "id", Request[data]{Ctx: ctx, Next: m.step1}, j, states)
+		switch {
+		case err == nil && wantErr:
+			t.Errorf("TestRunJournaled(%s): got err == nil, want err != nil", desc)
+		case err != nil && !wantErr:
+			t.Errorf("TestRunJournaled(%s): got err == %s, want err == nil", desc, err)
+		}
+		if got.Data.Num != wantNum {
+			t.Errorf("TestRunJournaled(%s): got Data.Num == %d, want %d", desc, got.Data.Num, wantNum)
+		}
+		if diff := pretty.Compare(wantCalls, m.calls); diff != "" {
+			t.Errorf("TestRunJournaled(%s): calls -want/+got:\n%s", desc, diff)
+		}
+	}
+
+	check("First run fails in step2", 1, []string{"step1", "step2"}, true)
+	m.fail = false
+	check("Resume at step2", 111, []string{"step2", "step3"}, false)
+	check("Finished", 111, nil, false)
+
+	entries, _ := j.Entries(ctx, "id")
+	var kinds []string
+	for i, e := range entries {
+		if e.Seq != i || e.Machine != "test" {
+			t.Errorf("TestRunJournaled: entry %d: got Seq %d, Machine %s, want %d, test", i, e.Seq, e.Machine, i)
+		}
+		kinds = append(kinds, e.Kind.String()+" "+shortName(e.State))
+	}
+	wantKinds := []string{
+		"Started statemachine.(*resumable).step1",
+		"Completed statemachine.(*resumable).step1",
+		"Started statemachine.(*resumable).step2",
+		"Failed statemachine.(*resumable).step2",
+		"Started statemachine.(*resumable).step2",
+		"Completed statemachine.(*resumable).step2",
+		"Started statemachine.(*resumable).step3",
+		"Completed statemachine.(*resumable).step3",
+	}
+	if diff := pretty.Compare(wantKinds, kinds); diff != "" {
+		t.Errorf("TestRunJournaled: entries -want/+got:\n%s", diff)
+	}
+	if entries[1].Next != methodName(m.step2) || string(entries[1].Data) != `{"Num":1}` {
+		t.Errorf("TestRunJournaled: got Completed entry %+v, want Next step2 and Data {\"Num\":1}", entries[1])
+	}
+	if entries[2].InputHash != entries[4].InputHash {
+		t.Errorf("TestRunJournaled: step2 input hashes differ between runs")
+	}
+
+	if _, err := RunJournaled("other", "id", Request[data]{Ctx: ctx, Next: m.step1}, j, states); err == nil {
+		t.Errorf("TestRunJournaled: different machine: got err == nil, want err != nil")
+	}
+	if _, err := RunJournaled("test", "id", Request[data]{Ctx: ctx, Next: m.step1}, nil, states); err == nil {
+		t.Errorf("TestRunJournaled: nil Journal: got err == nil, want err != nil")
+	}
+}
+
+func TestRunJournaledInDoubt(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := &resumable{}
+	states := []State[data]{m.step1, m.step2, m.step3}
+	in, _ := json.Marshal(data{Num: 5})
+
+	tests := []struct {
+		name      string
+		hash      string
+		wantCalls []string
+		wantErr   error
+	}{
+		{name: "Same input is executed again", hash: hashInput(in), wantCalls: []string{"step1", "step2", "step3"}},
+		{name: "Different input", hash: "bad", wantErr: ErrJournalMismatch},
+	}
+
+	for _, test := range tests {
+		m.calls = nil
+		// The process crashed while step1 executed.
+		j := NewMemJournal()
+		j.Append(ctx, "id", JournalEntry{Kind: JournalStarted, Machine: "test", State: methodName(m.step1), InputHash: test.hash})
+
+		got, err := RunJournaled("test", "id", Request[data]{Ctx: ctx, Next: m.step1, Data: data{Num: 5}}, j, states)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestRunJournaledInDoubt(%s): got err == %v, want %v", test.name, err, test.wantErr)
+		}
+		if test.wantErr != nil {
+			continue
+		}
+		if got.Data.Num != 116 {
+			t.Errorf("TestRunJournaledInDoubt(%s): got Data.Num == %d, want 116", test.name, got.Data.Num)
+		}
+		if diff := pretty.Compare(test.wantCalls, m.calls); diff != "" {
+			t.Errorf("TestRunJournaledInDoubt(%s): calls -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+// fanOut is a machine with a State that runs branches with Parallel().
+type fanOut struct {
+	fail     bool
+	calls    []string
+	branches atomic.Int32
+}
+
+func (m *fanOut) fan(req Request[data]) Request[data] {
+	m.calls = append(m.calls, "fan")
+	join := func(req Request[data], results []Result[data]) Request[data] {
+		for _, r := range results {
+			req.Data.Num += r.Request.Data.Num
+		}
+		req.Next = m.finish
+		return req
+	}
+	return Parallel(join, m.branch, m.branch)(req)
+}
+
+func (m *fanOut) branch(req Request[data]) Request[data] {
+	m.branches.Add(1)
+	req.Data.Num += 10
+	req.Next = nil
+	return req
+}
+
+func (m *fanOut) finish(req Request[data]) Request[data] {
+	m.calls = append(m.calls, "finish")
+	if m.fail {
+		req.Err = errors.New("finish failed")
+		return req
+	}
+	req.Data.Num += 100
+	return req
+}
+
+func TestRunJournaledParallel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := &fanOut{fail: true}
+	j := NewMemJournal()
+	states := []State[data]{m.fan, m.finish}
+
+	if _, err := RunJournaled("test", "id", Request[data]{Ctx: ctx, Next: m.fan, Data: data{Num: 1}}, j, states); err == nil {
+		t.Fatalf("TestRunJournaledParallel: first run: got err == nil, want err != nil")
+	}
+
+	entries, _ := j.Entries(ctx, "id")
+	var kinds []string
+	for _, e := range entries {
+		kinds = append(kinds, e.Kind.String()+" "+shortName(e.State))
+	}
+	wantKinds := []string{
+		"Started statemachine.(*fanOut).fan",
+		"Completed statemachine.(*fanOut).fan",
+		"Started statemachine.(*fanOut).finish",
+		"Failed statemachine.(*fanOut).finish",
+	}
+	if diff := pretty.Compare(wantKinds, kinds); diff != "" {
+		t.Errorf("TestRunJournaledParallel: entries -want/+got:\n%s", diff)
+	}
+
+	// The branches are not run again, as the State that ran them completed.
+	m.fail, m.calls = false, nil
+	got, err := RunJournaled("test", "id", Request[data]{Ctx: ctx, Next: m.fan, Data: data{Num: 1}}, j, states)
+	if err != nil {
+		t.Fatalf("TestRunJournaledParallel: resume: got err == %s, want err == nil", err)
+	}
+	if got.Data.Num != 1+11+11+100 {
+		t.Errorf("TestRunJournaledParallel: got Data.Num == %d, want %d", got.Data.Num, 1+11+11+100)
+	}
+	if diff := pretty.Compare([]string{"finish"}, m.calls); diff != "" {
+		t.Errorf("TestRunJournaledParallel: calls -want/+got:\n%s", diff)
+	}
+	if got := m.branches.Load(); got != 2 {
+		t.Errorf("TestRunJournaledParallel: got %d branch runs, want 2", got)
+	}
+}
+
+func TestFileJournal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "journals")
+	j, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("TestFileJournal: NewFileJournal(): got err == %s, want err == nil", err)
+	}
+
+	got, err := j.Entries(ctx, "a/b")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("TestFileJournal: Entries() with no file: got %v, %v, want no entries and err == nil", got, err)
+	}
+
+	want := []JournalEntry{
+		{Seq: 0, Kind: JournalStarted, Machine: "m", State: "s", InputHash: "h", Time: time.Unix(1, 0).UTC()},
+		{Seq: 1, Kind: JournalCompleted, Machine: "m", State: "s", InputHash: "h", Data: json.RawMessage(`{"Num":1}`), Time: time.Unix(2, 0).UTC()},
+	}
+	for _, e := range want {
+		if err := j.Append(ctx, "a/b", e); err != nil {
+			t.Fatalf("TestFileJournal: Append(): got err == %s, want err == nil", err)
+		}
+	}
+	got, err = j.Entries(ctx, "a/b")
+	if err != nil {
+		t.Fatalf("TestFileJournal: Entries(): got err == %s, want err == nil", err)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestFileJournal: -want/+got:\n%s", diff)
+	}
+
+	// A crash during Append() leaves a partial last line, which is ignored.
+	f, err := os.OpenFile(j.path("a/b"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":2,"ki`)
+	f.Close()
+	if got, err := j.Entries(ctx, "a/b"); err != nil || len(got) != 2 {
+		t.Errorf("TestFileJournal: Entries() with partial line: got %d entries, err == %v, want 2 entries, err == nil", len(got), err)
+	}
+
+	// A corrupt line that is not the last is an error.
+	os.WriteFile(j.path("corrupt"), []byte("garbage\n{}\n"), 0o600)
+	if _, err := j.Entries(ctx, "corrupt"); err == nil {
+		t.Errorf("TestFileJournal: Entries() with corrupt line: got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,92 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// NamedState is a State discovered by StatesFromMethods().
+type NamedState[T any] struct {
+	// Name is the name of the method.
+	Name string
+	// Symbol is the name of the State as returned by StateName() when the method is passed directly,
+	// such as StateName(q.Start). This is the name used in traces, errors and Checkpoints.
+	Symbol string
+	// State executes the method. As it is created with reflection, StateName(State) does not return
+	// Symbol, so route to the method directly instead of to State.
+	State State[T]
+}
+
+/*
+StatesFromMethods returns every exported method of v that is a State, which is a method with the signature
+func(Request[T]) Request[T], in method name order. Methods that do not have that signature are ignored.
+v is usually a pointer to the struct the methods are declared on.
+*/
+func StatesFromMethods[T any](v any) ([]NamedState[T], error) {
+	if v == nil {
+		return nil, errors.New("cannot discover States from a nil value")
+	}
+
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	stateType := reflect.TypeOf(State[T](nil))
+
+	var states []NamedState[T]
+	for i := 0; i < rt.NumMethod(); i++ {
+		mv := rv.Method(i)
+		if !mv.Type().ConvertibleTo(stateType) {
+			continue
+		}
+		m := rt.Method(i)
+		states = append(
+			states,
+			NamedState[T]{Name: m.Name, Symbol: symbolOf(rt, m), State: mv.Convert(stateType).Interface().(State[T])},
+		)
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("%s has no exported methods that are States", rt)
+	}
+	return states, nil
+}
+
+/*
+BuilderFromMethods returns a Builder with a State for every exported method of v that is a State, as
+returned by StatesFromMethods(), named with the method name. Machines implemented as methods on a struct
+only need to declare their start, transitions and terminal States:
+
+	m, err := statemachine.BuilderFromMethods[Data](&quotes{client: client}).
+		Start("Start").
+		Transition("Start", "RandomAuthor", "RandomQuote").
+		Transition("RandomAuthor", "RandomQuote").
+		Terminal("RandomQuote").
+		Build()
+
+If Start() is not called, the Machine starts with the first State in method name order.
+*/
+func BuilderFromMethods[T any](v any) *Builder[T] {
+	b := NewBuilder[T]()
+	states, err := StatesFromMethods[T](v)
+	if err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	for _, s := range states {
+		b.State(s.Name, s.State)
+		b.symbols[s.Name] = s.Symbol
+	}
+	return b
+}
+
+// symbolOf returns the name of method m of t, as methodName() names it when the method is passed directly.
+// A method with a value receiver that is called through a pointer is named for the value receiver.
+func symbolOf(t reflect.Type, m reflect.Method) string {
+	if t.Kind() == reflect.Pointer {
+		if em, ok := t.Elem().MethodByName(m.Name); ok {
+			m = em
+		}
+	}
+	return strings.TrimSuffix(runtime.FuncForPC(m.Func.Pointer()).Name(), "[...]")
+}
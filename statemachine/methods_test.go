@@ -0,0 +1,112 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// quotes is a machine implemented as methods.
+type quotes struct {
+	calls []string
+}
+
+func (q *quotes) Start(req Request[data]) Request[data] {
+	q.calls = append(q.calls, "Start")
+	req.Data.Num++
+	req.Next = q.Author
+	return req
+}
+
+func (q *quotes) Author(req Request[data]) Request[data] {
+	q.calls = append(q.calls, "Author")
+	req.Data.Num += 10
+	req.Next = q.Quote
+	return req
+}
+
+// Quote has a value receiver.
+func (q quotes) Quote(req Request[data]) Request[data] {
+	req.Data.Num += 100
+	return req
+}
+
+// Helper is not a State.
+func (q *quotes) Helper(n int) int {
+	return n
+}
+
+func TestStatesFromMethods(t *testing.T) {
+	t.Parallel()
+
+	q := &quotes{}
+	states, err := StatesFromMethods[data](q)
+	if err != nil {
+		t.Fatalf("TestStatesFromMethods: got err == %s, want err == nil", err)
+	}
+
+	var names, symbols []string
+	for _, s := range states {
+		names = append(names, s.Name)
+		symbols = append(symbols, s.Symbol)
+	}
+	if diff := pretty.Compare([]string{"Author", "Quote", "Start"}, names); diff != "" {
+		t.Errorf("TestStatesFromMethods: names -want/+got:\n%s", diff)
+	}
+	want := []string{StateName(q.Author), StateName(q.Quote), StateName(q.Start)}
+	if diff := pretty.Compare(want, symbols); diff != "" {
+		t.Errorf("TestStatesFromMethods: symbols -want/+got:\n%s", diff)
+	}
+
+	req := states[2].State(Request[data]{Ctx: context.Background()})
+	if req.Data.Num != 1 || StateName(req.Next) != StateName(q.Author) {
+		t.Errorf("TestStatesFromMethods: Start: got Data.Num == %d, Next == %s, want 1, %s", req.Data.Num, StateName(req.Next), StateName(q.Author))
+	}
+
+	if _, err := StatesFromMethods[data](nil); err == nil {
+		t.Errorf("TestStatesFromMethods: nil: got err == nil, want err != nil")
+	}
+	if _, err := StatesFromMethods[data](errors.New("")); err == nil {
+		t.Errorf("TestStatesFromMethods: no States: got err == nil, want err != nil")
+	}
+}
+
+func TestBuilderFromMethods(t *testing.T) {
+	t.Parallel()
+
+	q := &quotes{}
+	m, err := BuilderFromMethods[data](q).
+		Start("Start").
+		Transition("Start", "Author").
+		Transition("Author", "Quote").
+		Terminal("Quote").
+		Build()
+	if err != nil {
+		t.Fatalf("TestBuilderFromMethods: Build(): got err == %s, want err == nil", err)
+	}
+
+	var names []string
+	record := func(stateName string, req Request[data], next State[data]) Request[data] {
+		names = append(names, stateName)
+		return next(req)
+	}
+	req, err := m.Run("quotes", Request[data]{Ctx: context.Background()}, WithMiddleware(record))
+	if err != nil {
+		t.Fatalf("TestBuilderFromMethods: Run(): got err == %s, want err == nil", err)
+	}
+	if req.Data.Num != 111 {
+		t.Errorf("TestBuilderFromMethods: got Data.Num == %d, want 111", req.Data.Num)
+	}
+	if diff := pretty.Compare([]string{"Start", "Author", "Quote"}, names); diff != "" {
+		t.Errorf("TestBuilderFromMethods: state names -want/+got:\n%s", diff)
+	}
+	if diff := pretty.Compare([]string{"Start", "Author"}, q.calls); diff != "" {
+		t.Errorf("TestBuilderFromMethods: calls -want/+got:\n%s", diff)
+	}
+
+	if _, err := BuilderFromMethods[data](nil).Build(); err == nil {
+		t.Errorf("TestBuilderFromMethods: nil: got err == nil, want err != nil")
+	}
+}
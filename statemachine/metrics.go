@@ -0,0 +1,49 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Metrics receives per state measurements from Run(), such as to record latency histograms and error
+// counters in a metrics system. machine is the name passed to Run() and state is the name of the State.
+// Implementations must be safe for concurrent use if they are used by concurrent Run() calls.
+type Metrics interface {
+	// StateStarted is called before a State is executed.
+	StateStarted(machine, state string)
+	// StateDuration is called after a State is executed with the time it took.
+	StateDuration(machine, state string, d time.Duration)
+	// StateError is called after a State is executed if it set Request.Err.
+	StateError(machine, state string, err error)
+}
+
+// WithMetrics records per state measurements to m.
+func WithMetrics[T any](m Metrics) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if m == nil {
+			return req, errors.New("WithMetrics() cannot be passed a nil Metrics")
+		}
+		return WithObserver[T](metricsObserver[T]{m: m})(req)
+	}
+}
+
+// metricsObserver is an Observer that records to Metrics.
+type metricsObserver[T any] struct {
+	m Metrics
+}
+
+func (metricsObserver[T]) OnStart(string, Request[T]) {}
+
+func (o metricsObserver[T]) OnTransition(_ context.Context, t Transition[T]) {
+	if t.Kind == Entered {
+		o.m.StateStarted(t.Machine, t.State)
+		return
+	}
+	o.m.StateDuration(t.Machine, t.State, t.Duration)
+	if t.Err != nil {
+		o.m.StateError(t.Machine, t.State, t.Err)
+	}
+}
+
+func (metricsObserver[T]) OnEnd(string, Request[T], time.Duration) {}
@@ -0,0 +1,73 @@
+package statemachine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// fakeMetrics records the calls made to it.
+type fakeMetrics struct {
+	calls []string
+}
+
+func (f *fakeMetrics) StateStarted(machine, state string) {
+	f.calls = append(f.calls, fmt.Sprintf("started %s %s", machine, shortName(state)))
+}
+
+func (f *fakeMetrics) StateDuration(machine, state string, d time.Duration) {
+	if d < 0 {
+		panic("negative duration")
+	}
+	f.calls = append(f.calls, fmt.Sprintf("duration %s %s", machine, shortName(state)))
+}
+
+func (f *fakeMetrics) StateError(machine, state string, err error) {
+	f.calls = append(f.calls, fmt.Sprintf("error %s %s %s", machine, shortName(state), err))
+}
+
+func TestWithMetrics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		num  int
+		want []string
+	}{
+		{
+			name: "Success",
+			num:  1,
+			want: []string{
+				"started machine statemachine.steer",
+				"duration machine statemachine.steer",
+				"started machine statemachine.addTen",
+				"duration machine statemachine.addTen",
+			},
+		},
+		{
+			name: "Error",
+			num:  math.MaxInt,
+			want: []string{
+				"started machine statemachine.steer",
+				"duration machine statemachine.steer",
+				"started machine statemachine.addErr",
+				"duration machine statemachine.addErr",
+				"error machine statemachine.addErr addErr",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		m := &fakeMetrics{}
+		req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: test.num}}
+		Run("machine", req, WithMetrics[data](m))
+
+		if diff := pretty.Compare(test.want, m.calls); diff != "" {
+			t.Errorf("TestWithMetrics(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
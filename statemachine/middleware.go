@@ -0,0 +1,64 @@
+package statemachine
+
+import "errors"
+
+/*
+Middleware wraps the execution of every State in a Run() call. stateName is the name of the State
+that is about to be executed and next executes it (or the next Middleware in the chain). A Middleware
+can act before and after the State by doing work before and after calling next. It may also skip the
+State by not calling next, such as when an authorization check fails and it sets Request.Err.
+
+This allows cross cutting concerns such as authorization, logging, metrics and timing to be written once
+instead of in every State. Example that logs the time every State takes:
+
+	func timing[T any](stateName string, req statemachine.Request[T], next statemachine.State[T]) statemachine.Request[T] {
+		start := time.Now()
+		req = next(req)
+		log.Printf("state %s took %v", stateName, time.Since(start))
+		return req
+	}
+
+	req, err := statemachine.Run("machine", req, statemachine.WithMiddleware(timing[Data]))
+*/
+type Middleware[T any] func(stateName string, req Request[T], next State[T]) Request[T]
+
+// WithMiddleware adds Middleware that wraps every State executed by Run(). The first Middleware passed
+// is the outermost, so it is the first to see the Request before a State and the last to see it after.
+// WithMiddleware can be passed multiple times, each call adds to the chain.
+func WithMiddleware[T any](mw ...Middleware[T]) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		for _, m := range mw {
+			if m == nil {
+				return req, errors.New("WithMiddleware() cannot be passed a nil Middleware")
+			}
+		}
+		req.conf.middleware = append(req.conf.middleware, mw...)
+		return req, nil
+	}
+}
+
+// exec executes state with req, applying any panic recovery and state timeout, wrapped in any Middleware. symbol is the
+// name of state from methodName() and stateName is its name from displayName(). c may be nil.
+func (c *runConfig[T]) exec(symbol, stateName string, state State[T], req Request[T]) Request[T] {
+	if c == nil {
+		return state(req)
+	}
+	if c.recoverPanics {
+		state = withRecovery(stateName, state)
+	}
+	if d := c.timeout(symbol); d > 0 {
+		state = withTimeout(stateName, state, d)
+	}
+	if len(c.middleware) == 0 {
+		return state(req)
+	}
+
+	next := state
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		mw, inner := c.middleware[i], next
+		next = func(req Request[T]) Request[T] {
+			return mw(stateName, req, inner)
+		}
+	}
+	return next(req)
+}
@@ -0,0 +1,80 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	record := func(id string) Middleware[data] {
+		return func(stateName string, req Request[data], next State[data]) Request[data] {
+			calls = append(calls, id+" before "+stateName)
+			req = next(req)
+			calls = append(calls, id+" after "+stateName)
+			return req
+		}
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}}
+	got, err := Run("test", req, WithMiddleware(record("a"), record("b")), WithMiddleware(record("c")))
+	if err != nil {
+		t.Fatalf("TestWithMiddleware: got err == %s, want err == nil", err)
+	}
+	if got.Data.Num != 11 {
+		t.Errorf("TestWithMiddleware: got Data.Num == %d, want 11", got.Data.Num)
+	}
+
+	const (
+		steerName  = "github.com/gostdlib/ops/statemachine.steer"
+		addTenName = "github.com/gostdlib/ops/statemachine.addTen"
+	)
+	want := []string{
+		"a before " + steerName,
+		"b before " + steerName,
+		"c before " + steerName,
+		"c after " + steerName,
+		"b after " + steerName,
+		"a after " + steerName,
+		"a before " + addTenName,
+		"b before " + addTenName,
+		"c before " + addTenName,
+		"c after " + addTenName,
+		"b after " + addTenName,
+		"a after " + addTenName,
+	}
+	if diff := pretty.Compare(want, calls); diff != "" {
+		t.Errorf("TestWithMiddleware: call order -want/+got:\n%s", diff)
+	}
+}
+
+func TestWithMiddlewareSkip(t *testing.T) {
+	t.Parallel()
+
+	errDenied := errors.New("denied")
+	deny := func(stateName string, req Request[data], next State[data]) Request[data] {
+		if stateName == "github.com/gostdlib/ops/statemachine.addTen" {
+			req.Err = errDenied
+			return req
+		}
+		return next(req)
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}}
+	got, err := Run("test", req, WithMiddleware(deny))
+	if !errors.Is(err, errDenied) {
+		t.Errorf("TestWithMiddlewareSkip: got err == %v, want %v", err, errDenied)
+	}
+	if got.Data.Num != 1 {
+		t.Errorf("TestWithMiddlewareSkip: got Data.Num == %d, want 1", got.Data.Num)
+	}
+
+	if _, err := Run("test", req, WithMiddleware[data](nil)); err == nil {
+		t.Errorf("TestWithMiddlewareSkip: WithMiddleware(nil): got err == nil, want err != nil")
+	}
+}
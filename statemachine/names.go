@@ -0,0 +1,42 @@
+package statemachine
+
+import (
+	"errors"
+	"strings"
+)
+
+// WithStateName sets the name used for state in traces, errors, cycle reports, logs, metrics and recorded
+// Graphs to name, instead of the name of the function or method, such as
+// "github.com/org/repo/pkg.(*machine).provisionDisk". Closures created by the same function share a name,
+// so naming one names them all.
+func WithStateName[T any](state State[T], name string) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if state == nil {
+			return req, errors.New("WithStateName() cannot be passed a nil State")
+		}
+		if strings.TrimSpace(name) == "" {
+			return req, errors.New("WithStateName() cannot be passed an empty name")
+		}
+		if req.conf.names == nil {
+			req.conf.names = map[string]string{}
+		}
+		req.conf.names[methodName(state)] = name
+		return req, nil
+	}
+}
+
+// stateName returns the name of state. c may be nil.
+func (c *runConfig[T]) stateName(state State[T]) string {
+	return c.displayName(methodName(state))
+}
+
+// displayName returns the custom name for the state with symbol, its name from methodName(), or symbol
+// if it does not have one. c may be nil.
+func (c *runConfig[T]) displayName(symbol string) string {
+	if c != nil {
+		if n, ok := c.names[symbol]; ok {
+			return n
+		}
+	}
+	return symbol
+}
@@ -0,0 +1,53 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestWithStateName(t *testing.T) {
+	t.Parallel()
+
+	var names []string
+	record := func(stateName string, req Request[data], next State[data]) Request[data] {
+		names = append(names, stateName)
+		return next(req)
+	}
+
+	g := NewGraph("test")
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: math.MaxInt}}
+	_, err := Run(
+		"test",
+		req,
+		WithStateName(steer, "Steer"),
+		WithStateName(addErr, "AddErr"),
+		WithMiddleware(record),
+		RecordGraph[data](g),
+		WithStateTimeoutFor(addErr, 0),
+	)
+	if err == nil {
+		t.Fatalf("TestWithStateName: got err == nil, want err != nil")
+	}
+	if diff := pretty.Compare([]string{"Steer", "AddErr"}, names); diff != "" {
+		t.Errorf("TestWithStateName: state names -want/+got:\n%s", diff)
+	}
+	if !strings.Contains(g.DOT(), `"Steer" -> "AddErr"`) {
+		t.Errorf("TestWithStateName: Graph did not use custom names:\n%s", g.DOT())
+	}
+
+	// Cycle errors use the custom name.
+	req = Request[data]{Ctx: context.Background(), Next: loop, Data: data{Num: 1}}
+	_, err = Run("test", req, WithStateName(loop, "Loop"), WithCycleDetection[data]())
+	if !errors.Is(err, ErrCycle) || !strings.Contains(err.Error(), "Loop -> Loop") {
+		t.Errorf("TestWithStateName: got err == %v, want cycle error with custom names", err)
+	}
+
+	if _, err := Run("test", req, WithStateName(loop, " ")); err == nil {
+		t.Errorf("TestWithStateName: empty name: got err == nil, want err != nil")
+	}
+}
@@ -0,0 +1,79 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/*
+Observer observes the execution of a machine. It is the extension point that WithMetrics(), WithSlog()
+and WithEventChannel() are built on, and can be implemented to integrate other systems. Methods are
+called synchronously by Run(), so they should return quickly. An Observer must be safe for concurrent
+use if it is used by concurrent Run() calls.
+*/
+type Observer[T any] interface {
+	// OnStart is called before the first State is executed. machine is the name passed to Run().
+	OnStart(machine string, req Request[T])
+	// OnTransition is called when a State is entered and when it is exited. ctx is the Request.Ctx
+	// passed to the State.
+	OnTransition(ctx context.Context, t Transition[T])
+	// OnEnd is called when the machine finishes, after any Compensations and finalizers have run, with
+	// the Request that Run() returns and how long the machine ran for.
+	OnEnd(machine string, req Request[T], elapsed time.Duration)
+}
+
+// WithObserver adds o to observe the machine. WithObserver can be passed multiple times, Observers are
+// called in the order they were added.
+func WithObserver[T any](o Observer[T]) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if o == nil {
+			return req, errors.New("WithObserver() cannot be passed a nil Observer")
+		}
+
+		mw := func(stateName string, req Request[T], next State[T]) Request[T] {
+			start := time.Now()
+			o.OnTransition(req.Ctx, Transition[T]{Kind: Entered, Machine: req.conf.name, State: stateName, Data: req.Data, Time: start})
+			req = next(req)
+			end := time.Now()
+			o.OnTransition(
+				req.Ctx,
+				Transition[T]{
+					Kind:     Exited,
+					Machine:  req.conf.name,
+					State:    stateName,
+					Data:     req.Data,
+					Err:      req.Err,
+					Time:     end,
+					Duration: end.Sub(start),
+				},
+			)
+			return req
+		}
+
+		req.conf.middleware = append(req.conf.middleware, mw)
+		req.conf.observers = append(req.conf.observers, o)
+		return req, nil
+	}
+}
+
+// observeStart calls OnStart() on all Observers. c may be nil. The branches of a Parallel() State are not
+// observed, so they are not counted as runs of the machine.
+func (c *runConfig[T]) observeStart(req Request[T]) {
+	if c == nil || c.branch {
+		return
+	}
+	for _, o := range c.observers {
+		o.OnStart(c.name, req)
+	}
+}
+
+// observeEnd calls OnEnd() on all Observers. c may be nil.
+func (c *runConfig[T]) observeEnd(req Request[T], elapsed time.Duration) {
+	if c == nil || c.branch {
+		return
+	}
+	for _, o := range c.observers {
+		o.OnEnd(c.name, req, elapsed)
+	}
+}
@@ -0,0 +1,78 @@
+package statemachine
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// recordObserver records the calls to it as strings.
+type recordObserver struct {
+	id    string
+	calls *[]string
+}
+
+func (o recordObserver) OnStart(machine string, req Request[data]) {
+	*o.calls = append(*o.calls, fmt.Sprintf("%s start %s %d", o.id, machine, req.Data.Num))
+}
+
+func (o recordObserver) OnTransition(ctx context.Context, t Transition[data]) {
+	*o.calls = append(*o.calls, fmt.Sprintf("%s %s %s %d", o.id, t.Kind, shortName(t.State), t.Data.Num))
+}
+
+func (o recordObserver) OnEnd(machine string, req Request[data], elapsed time.Duration) {
+	if elapsed <= 0 {
+		*o.calls = append(*o.calls, "elapsed <= 0")
+	}
+	*o.calls = append(*o.calls, fmt.Sprintf("%s end %s %d %v", o.id, machine, req.Data.Num, req.Err != nil))
+}
+
+func TestWithObserver(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}}
+	_, err := Run(
+		"test",
+		req,
+		WithObserver[data](recordObserver{id: "a", calls: &calls}),
+		WithObserver[data](recordObserver{id: "b", calls: &calls}),
+	)
+	if err != nil {
+		t.Fatalf("TestWithObserver: got err == %s, want err == nil", err)
+	}
+
+	want := []string{
+		"a start test 1",
+		"b start test 1",
+		"a Entered statemachine.steer 1",
+		"b Entered statemachine.steer 1",
+		"b Exited statemachine.steer 1",
+		"a Exited statemachine.steer 1",
+		"a Entered statemachine.addTen 1",
+		"b Entered statemachine.addTen 1",
+		"b Exited statemachine.addTen 11",
+		"a Exited statemachine.addTen 11",
+		"a end test 11 false",
+		"b end test 11 false",
+	}
+	if diff := pretty.Compare(want, calls); diff != "" {
+		t.Errorf("TestWithObserver: -want/+got:\n%s", diff)
+	}
+
+	calls = nil
+	req = Request[data]{Ctx: context.Background(), Next: addErr}
+	if _, err := Run("test", req, WithObserver[data](recordObserver{id: "a", calls: &calls})); err == nil {
+		t.Fatalf("TestWithObserver: got err == nil, want err != nil")
+	}
+	if got, want := calls[len(calls)-1], "a end test 0 true"; got != want {
+		t.Errorf("TestWithObserver: got last call %q, want %q", got, want)
+	}
+
+	if _, err := Run("test", req, WithObserver[data](nil)); err == nil {
+		t.Errorf("TestWithObserver: WithObserver(nil): got err == nil, want err != nil")
+	}
+}
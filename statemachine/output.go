@@ -0,0 +1,31 @@
+package statemachine
+
+import "errors"
+
+/*
+RunOutput runs the state machine like Run(), but returns the result of output called with the final
+Request.Data instead of the Request. This is used when the Data a machine works on holds things the caller
+should not see, such as clients or intermediate buffers, and the result is a different type:
+
+	type work struct {
+		client *http.Client
+		buf    []byte
+		VM     VM
+	}
+
+	vm, err := statemachine.RunOutput("createVM", req, func(w work) VM { return w.VM })
+
+OTEL span events record the result of output instead of Request.Data, with any field tagged with
+`redact:"true"` redacted, unless WithSpanDataFunc() is also passed. If the machine fails, the output
+of the Data when it stopped is returned with the error.
+*/
+func RunOutput[T, O any](name string, req Request[T], output func(data T) O, options ...Option[T]) (O, error) {
+	if output == nil {
+		var o O
+		return o, errors.New("RunOutput() cannot be passed a nil output func")
+	}
+
+	spanData := WithSpanDataFunc(func(d T) any { return redact(output(d)) })
+	req, err := Run(name, req, append([]Option[T]{spanData}, options...)...)
+	return output(req.Data), err
+}
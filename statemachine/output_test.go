@@ -0,0 +1,53 @@
+package statemachine
+
+import "testing"
+
+// scratch is Data with a field that must not be returned.
+type scratch struct {
+	buf []byte
+	Num int
+}
+
+type result struct {
+	Num    int
+	Secret string `redact:"true"`
+}
+
+func scratchState(req Request[scratch]) Request[scratch] {
+	req.Data.buf = append(req.Data.buf, 'x')
+	req.Data.Num++
+	return req
+}
+
+func toResult(s scratch) result {
+	return result{Num: s.Num, Secret: string(s.buf)}
+}
+
+func TestRunOutput(t *testing.T) {
+	t.Parallel()
+
+	rec, ctx := newFakeRecorder()
+	req := Request[scratch]{Ctx: ctx, Next: scratchState}
+	got, err := RunOutput("test", req, toResult)
+	if err != nil {
+		t.Fatalf("TestRunOutput: got err == %s, want err == nil", err)
+	}
+	if want := (result{Num: 1, Secret: "x"}); got != want {
+		t.Errorf("TestRunOutput: got %+v, want %+v", got, want)
+	}
+
+	e := rec.span("statemachine(test)").event("statemachine processing end")
+	if e == nil {
+		t.Fatalf("TestRunOutput: no end event recorded")
+	}
+	if want := `{"Num":1,"Secret":"[REDACTED]"}`; e.attrs["data"] != want {
+		t.Errorf("TestRunOutput: got span data %s, want %s", e.attrs["data"], want)
+	}
+
+	if _, err := RunOutput[scratch, result]("test", req, nil); err == nil {
+		t.Errorf("TestRunOutput: nil output: got err == nil, want err != nil")
+	}
+	if _, err := RunOutput("", req, toResult); err == nil {
+		t.Errorf("TestRunOutput: empty name: got err == nil, want err != nil")
+	}
+}
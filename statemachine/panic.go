@@ -0,0 +1,53 @@
+package statemachine
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is the Request.Err set when a State panics and WithPanicRecovery() was passed to Run().
+type PanicError struct {
+	// State is the name of the State that panicked.
+	State string
+	// Value is the value passed to panic().
+	Value any
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("state %s panicked: %v\n%s", e.State, e.Value, e.Stack)
+}
+
+// Unwrap returns the value passed to panic() if it is an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// WithPanicRecovery recovers a panic in a State and converts it to a Request.Err that is a *PanicError
+// holding the state name and stack trace. The OTEL span for the State is ended with an error status and
+// Run() returns the error instead of the panic taking down the process. Panics in Middleware, finalizers
+// and Compensations are not recovered.
+func WithPanicRecovery[T any]() Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		req.conf.recoverPanics = true
+		return req, nil
+	}
+}
+
+// withRecovery returns a State that executes state and converts a panic into Request.Err.
+func withRecovery[T any](stateName string, state State[T]) State[T] {
+	return func(req Request[T]) (out Request[T]) {
+		defer func() {
+			if v := recover(); v != nil {
+				out = req
+				out.Err = &PanicError{State: stateName, Value: v, Stack: debug.Stack()}
+			}
+		}()
+		return state(req)
+	}
+}
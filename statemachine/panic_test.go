@@ -0,0 +1,58 @@
+package statemachine
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+var errPanic = errors.New("panic value")
+
+func panicState(req Request[data]) Request[data] {
+	req.Data.Num = 100
+	panic(errPanic)
+}
+
+func TestWithPanicRecovery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []Option[data]
+	}{
+		{name: "Recovery", options: []Option[data]{WithPanicRecovery[data]()}},
+		{name: "Recovery with timeout", options: []Option[data]{WithPanicRecovery[data](), WithStateTimeout[data](time.Minute)}},
+	}
+
+	for _, test := range tests {
+		rec, ctx := newFakeRecorder()
+		req := Request[data]{Ctx: ctx, Next: panicState, Data: data{Num: 1}}
+		got, err := Run("test", req, test.options...)
+
+		var perr *PanicError
+		if !errors.As(err, &perr) {
+			t.Errorf("TestWithPanicRecovery(%s): got err == %v, want *PanicError", test.name, err)
+			continue
+		}
+		if perr.State != StateName(panicState) {
+			t.Errorf("TestWithPanicRecovery(%s): got State == %s, want %s", test.name, perr.State, StateName(panicState))
+		}
+		if !strings.Contains(string(perr.Stack), "panicState") {
+			t.Errorf("TestWithPanicRecovery(%s): stack does not contain the panicking state:\n%s", test.name, perr.Stack)
+		}
+		if !errors.Is(err, errPanic) {
+			t.Errorf("TestWithPanicRecovery(%s): got err that does not wrap the panic value", test.name)
+		}
+		if got.Data.Num != 1 {
+			t.Errorf("TestWithPanicRecovery(%s): got Data.Num == %d, want 1", test.name, got.Data.Num)
+		}
+
+		sp := rec.span("State(" + StateName(panicState) + ")")
+		if sp == nil || !sp.ended || sp.status != codes.Error {
+			t.Errorf("TestWithPanicRecovery(%s): state span was not ended with an error status", test.name)
+		}
+	}
+}
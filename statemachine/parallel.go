@@ -0,0 +1,137 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Join merges the Results of the branches of a Parallel() State into req, which is the Request passed
+// to the Parallel() State. results are in the same order as the branches. It must set req.Next to route
+// the machine, or set req.Err.
+type Join[T any] func(req Request[T], results []Result[T]) Request[T]
+
+/*
+Parallel returns a State that runs each branch concurrently as a sub-machine and merges them with join.
+Each branch is run with Run() starting at the branch, with a copy of the Request. If Data holds pointers,
+branches share the values they point to and must not change the same values. A branch can work on its own
+section of Data by having Data hold a slice or map of sections that each branch indexes.
+
+As branches run at the same time, they only have the settings of the machine that are safe for concurrent
+use: WithClock(), WithStateTimeout(), WithStateTimeoutFor(), WithPanicRecovery(), WithStateName(),
+WithContextCheck(), WithCycleDetection(), WithAllowedRevisits(), WithMaxTransitions(), span settings and
+the Controller of RunAsync(). Middleware and Observers, such as those added by WithTraceRecorder(),
+WithTimings(), WithRegistry(), RunResumable() and RunJournaled(), only see the Parallel() State itself.
+The deadline of WithRunTimeout() applies to the Context of the branches.
+
+If a branch fails, the Contexts of the other branches are canceled and Request.Err is set to the error
+once they return, without calling join. Use ParallelAll() to have join decide what to do with errors.
+
+A branch that fails runs its own Compensations and finalizers. Those of a branch that succeeds are added
+to the Request of the machine, so they run when the machine stops and a later failure undoes the branch.
+
+Example that creates a VM and a disk at the same time and then attaches the disk:
+
+	func Create(req statemachine.Request[Data]) statemachine.Request[Data] {
+		req.Next = statemachine.Parallel(joinVMDisk, CreateVM, CreateDisk)
+		return req
+	}
+
+	func joinVMDisk(req statemachine.Request[Data], results []statemachine.Result[Data]) statemachine.Request[Data] {
+		req.Data.VM = results[0].Request.Data.VM
+		req.Data.Disk = results[1].Request.Data.Disk
+		req.Next = AttachDisk
+		return req
+	}
+*/
+func Parallel[T any](join Join[T], branches ...State[T]) State[T] {
+	return parallel(join, true, branches)
+}
+
+// ParallelAll is like Parallel(), except that a failed branch does not stop the others and join is
+// always called with all the Results, including any with an error.
+func ParallelAll[T any](join Join[T], branches ...State[T]) State[T] {
+	return parallel(join, false, branches)
+}
+
+// parallel implements Parallel() and ParallelAll(). If firstErr is set, the first branch error cancels
+// the other branches and is returned without calling join.
+func parallel[T any](join Join[T], firstErr bool, branches []State[T]) State[T] {
+	return func(req Request[T]) Request[T] {
+		if join == nil {
+			req.Err = errors.New("Parallel() and ParallelAll() require a non-nil Join")
+			return req
+		}
+
+		ctx, cancel := context.WithCancel(req.Ctx)
+		defer cancel()
+
+		var (
+			wg      sync.WaitGroup
+			once    sync.Once
+			err     error
+			results = make([]Result[T], len(branches))
+		)
+		for i, branch := range branches {
+			i, branch := i, branch
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				r := Request[T]{Ctx: ctx, Next: branch, Data: req.Data}
+				r, rerr := Run(fmt.Sprintf("%s.branch[%d]", req.machine, i), r, branchConfig(req.conf))
+				r.Ctx, r.conf = req.Ctx, nil
+				results[i] = Result[T]{Request: r, Err: rerr}
+				if rerr != nil && firstErr {
+					once.Do(func() {
+						err = fmt.Errorf("parallel branch %d failed: %w", i, rerr)
+						cancel()
+					})
+				}
+			}()
+		}
+		wg.Wait()
+
+		for i := range results {
+			r := &results[i].Request
+			req.compensations = append(req.compensations, r.compensations...)
+			req.deferred = append(req.deferred, r.deferred...)
+			r.compensations, r.deferred = nil, nil
+		}
+
+		if err != nil {
+			req.Err = err
+			return req
+		}
+		return join(req, results)
+	}
+}
+
+// branchConfig returns an Option that gives a branch of a Parallel() State the settings in parent that are
+// safe for concurrent use. parent is nil if the machine was run without Options.
+func branchConfig[T any](parent *runConfig[T]) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if parent != nil {
+			*req.conf = runConfig[T]{
+				name:           req.conf.name,
+				cycles:         parent.cycles,
+				revisits:       parent.revisits,
+				maxTransitions: parent.maxTransitions,
+				stateTimeout:   parent.stateTimeout,
+				stateTimeouts:  parent.stateTimeouts,
+				clock:          parent.clock,
+				recoverPanics:  parent.recoverPanics,
+				names:          parent.names,
+				spanData:       parent.spanData,
+				dataRate:       parent.dataRate,
+				dataOnError:    parent.dataOnError,
+				spanAttrs:      parent.spanAttrs,
+				ctxCheck:       parent.ctxCheck,
+				control:        parent.control,
+			}
+		}
+		req.conf.branch = true
+		return req, nil
+	}
+}
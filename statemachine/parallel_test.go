@@ -0,0 +1,224 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// sum adds the Data.Num of every successful branch to Data.Num and stops the machine.
+func sum(req Request[data], results []Result[data]) Request[data] {
+	for _, r := range results {
+		if r.Err == nil {
+			req.Data.Num += r.Request.Data.Num
+		}
+	}
+	req.Next = nil
+	return req
+}
+
+// waitCancel is a State that blocks until Request.Ctx is done.
+func waitCancel(req Request[data]) Request[data] {
+	select {
+	case <-req.Ctx.Done():
+		req.Err = req.Ctx.Err()
+	case <-time.After(10 * time.Second):
+	}
+	return req
+}
+
+func TestParallel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		state     State[data]
+		wantNum   int
+		wantErr   bool
+		wantInErr string
+	}{
+		{
+			name:    "Error: nil Join",
+			state:   Parallel[data](nil, addTen),
+			wantErr: true,
+		},
+		{
+			name: "Success",
+			// steer on Data.Num == 1 routes to addTen, so each branch returns 11.
+			state:   Parallel(sum, steer, addTen, addTen),
+			wantNum: 1 + 11 + 11 + 11,
+		},
+		{
+			name:      "First error cancels other branches",
+			state:     Parallel(sum, waitCancel, addTen, addErr),
+			wantErr:   true,
+			wantInErr: "parallel branch 2 failed",
+		},
+		{
+			name:    "Collect all",
+			state:   ParallelAll(sum, addTen, addErr, addTen),
+			wantNum: 1 + 11 + 11,
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: test.state, Data: data{Num: 1}}
+		got, err := Run("test", req)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestParallel(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestParallel(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			if !strings.Contains(err.Error(), test.wantInErr) {
+				t.Errorf("TestParallel(%s): got err == %s, want it to contain %q", test.name, err, test.wantInErr)
+			}
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestParallel(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+	}
+}
+
+func TestParallelAllResults(t *testing.T) {
+	t.Parallel()
+
+	var got []Result[data]
+	join := func(req Request[data], results []Result[data]) Request[data] {
+		got = results
+		req.Next = nil
+		return req
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: ParallelAll(join, addErr, addTen), Data: data{Num: 1}}
+	if _, err := Run("test", req); err != nil {
+		t.Fatalf("TestParallelAllResults: got err == %s, want err == nil", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("TestParallelAllResults: got %d results, want 2", len(got))
+	}
+
+	var e *Error
+	if !errors.As(got[0].Err, &e) || e.Machine() != "test.branch[0]" {
+		t.Errorf("TestParallelAllResults: got Results[0].Err == %v, want *Error from machine test.branch[0]", got[0].Err)
+	}
+	if got[1].Err != nil || got[1].Request.Data.Num != 11 {
+		t.Errorf("TestParallelAllResults: got Results[1] == %+v, want Data.Num == 11 and no error", got[1])
+	}
+	if got[1].Request.Ctx != req.Ctx {
+		t.Errorf("TestParallelAllResults: got Results[1].Request.Ctx that is not the parent Context")
+	}
+}
+
+func TestParallelBranchOptions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu  sync.Mutex
+		log []string
+	)
+	record := func(s string) {
+		mu.Lock()
+		defer mu.Unlock()
+		log = append(log, s)
+	}
+	branch := func(req Request[data]) Request[data] {
+		req.Compensate(func(ctx context.Context, d data) error {
+			record("compensate")
+			return nil
+		})
+		req.Defer(func(req Request[data]) Request[data] {
+			record("defer")
+			return req
+		})
+		req.Next = nil
+		return req
+	}
+	join := func(req Request[data], results []Result[data]) Request[data] {
+		for _, r := range results {
+			if r.Request.compensations != nil || r.Request.deferred != nil {
+				req.Err = errors.New("branch Result has compensations or finalizers")
+				return req
+			}
+		}
+		req.Next = addErr
+		return req
+	}
+
+	var calls atomic.Int32
+	mw := func(stateName string, req Request[data], next State[data]) Request[data] {
+		calls.Add(1)
+		return next(req)
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: Parallel(join, branch, branch), Data: data{Num: 1}}
+	_, err := Run("test", req, WithMiddleware(mw))
+	if err == nil || !strings.Contains(err.Error(), "addErr") {
+		t.Fatalf("TestParallelBranchOptions: got err == %v, want the error from addErr", err)
+	}
+
+	// Middleware is not run for the branches, only for the Parallel() State and addErr.
+	if got := calls.Load(); got != 2 {
+		t.Errorf("TestParallelBranchOptions: got %d middleware calls, want 2", got)
+	}
+	want := []string{"compensate", "compensate", "defer", "defer"}
+	if diff := pretty.Compare(want, log); diff != "" {
+		t.Errorf("TestParallelBranchOptions: -want/+got:\n%s", diff)
+	}
+}
+
+// TestParallelBranchSettings is run with -race to check that Options that are not safe for concurrent use
+// are not used by branches.
+func TestParallelBranchSettings(t *testing.T) {
+	t.Parallel()
+
+	panics := func(req Request[data]) Request[data] {
+		panic("branch")
+	}
+	var got []Result[data]
+	join := func(req Request[data], results []Result[data]) Request[data] {
+		got = results
+		req.Next = nil
+		return req
+	}
+
+	var (
+		tr       Trace
+		timings  Timings
+		reg      = NewRegistry()
+		branches = []State[data]{addTen, panics, addTen, addTen}
+	)
+	options := []Option[data]{
+		WithPanicRecovery[data](),
+		WithTraceRecorder[data](&tr),
+		WithTimings[data](&timings),
+		WithRegistry[data](reg),
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: ParallelAll(join, branches...), Data: data{Num: 1}}
+	if _, err := Run("test", req, options...); err != nil {
+		t.Fatalf("TestParallelBranchSettings: Run(): got err == %s, want err == nil", err)
+	}
+	var pe *PanicError
+	if !errors.As(got[1].Err, &pe) {
+		t.Errorf("TestParallelBranchSettings: got branch err == %v, want *PanicError from WithPanicRecovery()", got[1].Err)
+	}
+	if len(tr.Steps) != 1 {
+		t.Errorf("TestParallelBranchSettings: got %d Trace steps, want 1", len(tr.Steps))
+	}
+	if len(timings.States) != 1 {
+		t.Errorf("TestParallelBranchSettings: got Timings for %d States, want 1", len(timings.States))
+	}
+	if m, _ := reg.Machine("test"); m.Runs != 1 {
+		t.Errorf("TestParallelBranchSettings: got %d Registry runs, want 1", m.Runs)
+	}
+}
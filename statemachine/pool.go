@@ -0,0 +1,225 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Pool.Submit() after Pool.Close() has been called.
+var ErrPoolClosed = errors.New("statemachine Pool is closed")
+
+// Result is the result of a Request run by a Pool.
+type Result[T any] struct {
+	// Request is the Request returned by Run().
+	Request Request[T]
+	// Err is the error returned by Run().
+	Err error
+}
+
+// PoolOption is an option for NewPool().
+type PoolOption[T any] func(*Pool[T]) error
+
+// WithWorkers sets the number of goroutines that run Requests. Defaults to runtime.GOMAXPROCS(0).
+func WithWorkers[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) error {
+		if n < 1 {
+			return fmt.Errorf("WithWorkers(%d) must be > 0", n)
+		}
+		p.workers = n
+		return nil
+	}
+}
+
+// WithQueueSize sets the number of submitted Requests that can wait for a worker before Submit() blocks.
+// Defaults to the number of workers.
+func WithQueueSize[T any](n int) PoolOption[T] {
+	return func(p *Pool[T]) error {
+		if n < 0 {
+			return fmt.Errorf("WithQueueSize(%d) must be >= 0", n)
+		}
+		p.queue = n
+		return nil
+	}
+}
+
+// WithResultHandler sets a function that is called by the worker with the Result of every Request. When set,
+// Pool.Results() returns nil. The handler is called concurrently by all workers.
+func WithResultHandler[T any](h func(Result[T])) PoolOption[T] {
+	return func(p *Pool[T]) error {
+		if h == nil {
+			return errors.New("WithResultHandler() cannot be passed a nil handler")
+		}
+		p.handler = h
+		return nil
+	}
+}
+
+// WithRunOptions sets the Options passed to Run() for every Request.
+func WithRunOptions[T any](options ...Option[T]) PoolOption[T] {
+	return func(p *Pool[T]) error {
+		p.options = append(p.options, options...)
+		return nil
+	}
+}
+
+/*
+Pool runs Requests across a bounded set of workers. This is used when running a large number of small
+machines, where starting a goroutine per machine is wasteful. Each worker reuses the memory Run() needs to
+hold Options between Requests.
+
+Results are read from Results() or passed to a handler set with WithResultHandler(). When using Results(),
+it must be read until it is closed or the workers will block.
+
+Example:
+
+	p, err := statemachine.NewPool[Data]("machine", statemachine.WithWorkers[Data](10))
+	if err != nil {
+		// Do something
+	}
+
+	go func() {
+		defer p.Close(ctx)
+		for _, d := range items {
+			if err := p.Submit(ctx, statemachine.Request[Data]{Ctx: ctx, Next: Start, Data: d}); err != nil {
+				// Do something
+			}
+		}
+	}()
+
+	for r := range p.Results() {
+		if r.Err != nil {
+			// Do something
+		}
+	}
+*/
+type Pool[T any] struct {
+	name    string
+	workers int
+	queue   int
+	handler func(Result[T])
+	options []Option[T]
+
+	in   chan Request[T]
+	out  chan Result[T]
+	done chan struct{}
+	// closing is closed by Close() to unblock calls to Submit().
+	closing chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+	// submits tracks calls to Submit() that may send on in, which is closed once they return.
+	submits sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+// NewPool creates a new Pool that runs Requests with Run(name, req, options...), where options are set
+// with WithRunOptions(). The workers are started immediately.
+func NewPool[T any](name string, options ...PoolOption[T]) (*Pool[T], error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, nameEmptyErr
+	}
+
+	p := &Pool[T]{name: name, workers: runtime.GOMAXPROCS(0), queue: -1}
+	for _, o := range options {
+		if err := o(p); err != nil {
+			return nil, err
+		}
+	}
+	if p.queue < 0 {
+		p.queue = p.workers
+	}
+
+	p.in = make(chan Request[T], p.queue)
+	p.done = make(chan struct{})
+	p.closing = make(chan struct{})
+	if p.handler == nil {
+		p.out = make(chan Result[T], p.workers)
+	}
+
+	p.wg.Add(p.workers)
+	for i := 0; i < p.workers; i++ {
+		go p.worker()
+	}
+	go func() {
+		p.wg.Wait()
+		if p.out != nil {
+			close(p.out)
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Submit queues req to be run. It blocks until req is queued or ctx is done. If ctx is done, ctx.Err() is
+// returned. If Close() has been called, or is called while Submit() is blocked, ErrPoolClosed is returned.
+func (p *Pool[T]) Submit(ctx context.Context, req Request[T]) error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrPoolClosed
+	}
+	p.submits.Add(1)
+	p.mu.RUnlock()
+	defer p.submits.Done()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.closing:
+		return ErrPoolClosed
+	case p.in <- req:
+		return nil
+	}
+}
+
+// Results returns the channel Results are sent on. It is closed after Close() is called and all Requests
+// have been run. If WithResultHandler() was set, this returns nil.
+func (p *Pool[T]) Results() <-chan Result[T] {
+	return p.out
+}
+
+// Close stops the Pool from accepting Requests and waits for all submitted Requests to be run. If ctx is
+// done before then, ctx.Err() is returned, but the remaining Requests are still run. Close can be called
+// multiple times.
+func (p *Pool[T]) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.closing)
+		go func() {
+			p.submits.Wait()
+			close(p.in)
+		}()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return nil
+	}
+}
+
+// worker runs Requests until the Pool is closed.
+func (p *Pool[T]) worker() {
+	defer p.wg.Done()
+
+	conf := &runConfig[T]{}
+	for req := range p.in {
+		req, err := run(p.name, req, p.options, conf)
+		// conf is reused by the next Request, so the Result cannot hold it.
+		req.conf = nil
+
+		r := Result[T]{Request: req, Err: err}
+		if p.handler != nil {
+			p.handler(r)
+			continue
+		}
+		p.out <- r
+	}
+}
@@ -0,0 +1,269 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestNewPool(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pname   string
+		options []PoolOption[data]
+		wantErr bool
+	}{
+		{
+			name:    "Error: empty name",
+			wantErr: true,
+		},
+		{
+			name:    "Error: workers < 1",
+			pname:   "test",
+			options: []PoolOption[data]{WithWorkers[data](0)},
+			wantErr: true,
+		},
+		{
+			name:    "Error: queue size < 0",
+			pname:   "test",
+			options: []PoolOption[data]{WithQueueSize[data](-1)},
+			wantErr: true,
+		},
+		{
+			name:    "Error: nil handler",
+			pname:   "test",
+			options: []PoolOption[data]{WithResultHandler[data](nil)},
+			wantErr: true,
+		},
+		{
+			name:  "Success",
+			pname: "test",
+			options: []PoolOption[data]{
+				WithWorkers[data](2),
+				WithQueueSize[data](0),
+				WithRunOptions(WithMaxTransitions[data](10)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		p, err := NewPool(test.pname, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNewPool(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNewPool(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if err := p.Close(context.Background()); err != nil {
+			t.Errorf("TestNewPool(%s): Close(): got err == %s, want err == nil", test.name, err)
+		}
+	}
+}
+
+func TestPoolResults(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var calls atomic.Int64
+	count := func(stateName string, req Request[data], next State[data]) Request[data] {
+		calls.Add(1)
+		return next(req)
+	}
+
+	p, err := NewPool("test", WithWorkers[data](4), WithRunOptions(WithMiddleware(count)))
+	if err != nil {
+		t.Fatalf("TestPoolResults: NewPool(): got err == %s, want err == nil", err)
+	}
+
+	const n = 100
+	go func() {
+		defer p.Close(ctx)
+		for i := 0; i < n; i++ {
+			if err := p.Submit(ctx, Request[data]{Ctx: ctx, Next: steer, Data: data{Num: i}}); err != nil {
+				t.Errorf("TestPoolResults: Submit(): got err == %s, want err == nil", err)
+			}
+		}
+	}()
+
+	var got, want []int
+	for i := 0; i < n; i++ {
+		want = append(want, i)
+		if i != 0 {
+			want[i] += 10
+		}
+	}
+	for r := range p.Results() {
+		if r.Err != nil {
+			t.Errorf("TestPoolResults: got Result.Err == %s, want nil", r.Err)
+		}
+		if r.Request.conf != nil {
+			t.Errorf("TestPoolResults: got Result.Request.conf != nil, want nil")
+		}
+		got = append(got, r.Request.Data.Num)
+	}
+	sort.Ints(got)
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestPoolResults: -want/+got:\n%s", diff)
+	}
+	// Data.Num == 0 only executes steer, all others execute steer and addTen.
+	if got := calls.Load(); got != 2*n-1 {
+		t.Errorf("TestPoolResults: middleware called %d times, want %d", got, 2*n-1)
+	}
+}
+
+func TestPoolHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var errs int
+	p, err := NewPool(
+		"test",
+		WithResultHandler(func(r Result[data]) {
+			mu.Lock()
+			defer mu.Unlock()
+			if r.Err != nil {
+				errs++
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("TestPoolHandler: NewPool(): got err == %s, want err == nil", err)
+	}
+	if p.Results() != nil {
+		t.Errorf("TestPoolHandler: got Results() != nil, want nil")
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := p.Submit(ctx, Request[data]{Ctx: ctx, Next: addErr}); err != nil {
+			t.Fatalf("TestPoolHandler: Submit(): got err == %s, want err == nil", err)
+		}
+	}
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("TestPoolHandler: Close(): got err == %s, want err == nil", err)
+	}
+	if errs != 10 {
+		t.Errorf("TestPoolHandler: got %d errors, want 10", errs)
+	}
+
+	if err := p.Submit(ctx, Request[data]{Ctx: ctx, Next: steer}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("TestPoolHandler: Submit() after Close(): got err == %v, want ErrPoolClosed", err)
+	}
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("TestPoolHandler: second Close(): got err == %s, want err == nil", err)
+	}
+}
+
+func TestPoolSubmitCtx(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	wait := func(req Request[data]) Request[data] {
+		<-release
+		return req
+	}
+
+	p, err := NewPool("test", WithWorkers[data](1), WithQueueSize[data](0), WithResultHandler(func(Result[data]) {}))
+	if err != nil {
+		t.Fatalf("TestPoolSubmitCtx: NewPool(): got err == %s, want err == nil", err)
+	}
+	if err := p.Submit(ctx, Request[data]{Ctx: ctx, Next: wait}); err != nil {
+		t.Fatalf("TestPoolSubmitCtx: Submit(): got err == %s, want err == nil", err)
+	}
+
+	// The only worker is blocked and there is no queue, so this cannot be submitted.
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(tctx, Request[data]{Ctx: ctx, Next: wait}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TestPoolSubmitCtx: Submit(): got err == %v, want context.DeadlineExceeded", err)
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := p.Close(cctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("TestPoolSubmitCtx: Close(): got err == %v, want context.Canceled", err)
+	}
+	close(release)
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("TestPoolSubmitCtx: Close(): got err == %s, want err == nil", err)
+	}
+}
+
+func TestPoolCloseWhileSubmitting(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	wait := func(req Request[data]) Request[data] {
+		<-release
+		return req
+	}
+
+	var ran atomic.Int32
+	p, err := NewPool("test", WithWorkers[data](1), WithQueueSize[data](0), WithResultHandler(func(Result[data]) { ran.Add(1) }))
+	if err != nil {
+		t.Fatalf("TestPoolCloseWhileSubmitting: NewPool(): got err == %s, want err == nil", err)
+	}
+	if err := p.Submit(ctx, Request[data]{Ctx: ctx, Next: wait}); err != nil {
+		t.Fatalf("TestPoolCloseWhileSubmitting: Submit(): got err == %s, want err == nil", err)
+	}
+
+	// The only worker is blocked and there is no queue, so this blocks until Close() is called.
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- p.Submit(ctx, Request[data]{Ctx: ctx, Next: wait})
+	}()
+
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := p.Close(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TestPoolCloseWhileSubmitting: Close(): got err == %v, want context.DeadlineExceeded", err)
+	}
+	if err := <-submitted; !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("TestPoolCloseWhileSubmitting: Submit(): got err == %v, want ErrPoolClosed", err)
+	}
+
+	close(release)
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("TestPoolCloseWhileSubmitting: Close(): got err == %s, want err == nil", err)
+	}
+	if got := ran.Load(); got != 1 {
+		t.Errorf("TestPoolCloseWhileSubmitting: got %d Requests run, want 1", got)
+	}
+}
+
+func TestRunConfigReset(t *testing.T) {
+	t.Parallel()
+
+	c := &runConfig[data]{
+		name:          "old",
+		cycles:        true,
+		middleware:    []Middleware[data]{func(string, Request[data], State[data]) Request[data] { return Request[data]{} }},
+		stateTimeouts: map[string]time.Duration{"a": time.Second},
+		names:         map[string]string{"a": "b"},
+	}
+	got := c.reset("new")
+	if got != c {
+		t.Fatalf("TestRunConfigReset: reset() did not return the same *runConfig")
+	}
+	if c.name != "new" || c.cycles || len(c.middleware) != 0 || len(c.stateTimeouts) != 0 || len(c.names) != 0 {
+		t.Errorf("TestRunConfigReset: got %+v, want a zero runConfig named new", c)
+	}
+}
@@ -0,0 +1,214 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+Registry records the machines in a program, their Graphs and how many runs of each are in progress,
+so they can be inspected in production with Registry.Handler(). Example:
+
+	reg := statemachine.NewRegistry()
+	if err := reg.Register("quotes", graph); err != nil {
+		...
+	}
+	http.Handle("/debug/statemachines/", http.StripPrefix("/debug/statemachines", reg.Handler()))
+
+	req, err := statemachine.Run("quotes", req, statemachine.WithRegistry[Data](reg))
+
+A Registry is safe for concurrent use.
+*/
+type Registry struct {
+	mu       sync.Mutex
+	machines map[string]*registered
+}
+
+// registered is a machine in a Registry.
+type registered struct {
+	graph    *Graph
+	running  atomic.Int64
+	runs     atomic.Int64
+	failures atomic.Int64
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{machines: map[string]*registered{}}
+}
+
+// Register registers the machine called name. g is the Graph of the machine and may be nil. It is an
+// error to register a name twice.
+func (r *Registry) Register(name string, g *Graph) error {
+	if name == "" {
+		return errors.New("Registry.Register() cannot be passed an empty name")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.machines[name]; ok {
+		return fmt.Errorf("machine %s is already registered", name)
+	}
+	r.machines[name] = &registered{graph: g}
+	return nil
+}
+
+// get returns the machine called name. ok is false if it is not registered.
+func (r *Registry) get(name string) (m *registered, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok = r.machines[name]
+	return m, ok
+}
+
+// getOrRegister returns the machine called name, registering it without a Graph if it was not registered.
+func (r *Registry) getOrRegister(name string) *registered {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.machines[name]
+	if !ok {
+		m = &registered{}
+		r.machines[name] = m
+	}
+	return m
+}
+
+// MachineInfo is information about a machine in a Registry.
+type MachineInfo struct {
+	// Name is the name of the machine.
+	Name string `json:"name"`
+	// Running is the number of runs of the machine in progress.
+	Running int64 `json:"running"`
+	// Runs is the number of runs of the machine that finished.
+	Runs int64 `json:"runs"`
+	// Failures is the number of runs of the machine that finished with an error.
+	Failures int64 `json:"failures"`
+	// Graph is the Graph the machine was registered with. It may be nil.
+	Graph *Graph `json:"graph,omitempty"`
+}
+
+// Machine returns information about the machine called name.
+func (r *Registry) Machine(name string) (MachineInfo, bool) {
+	m, ok := r.get(name)
+	if !ok {
+		return MachineInfo{}, false
+	}
+	return m.info(name), true
+}
+
+// Machines returns information about every machine in the Registry, sorted by name.
+func (r *Registry) Machines() []MachineInfo {
+	r.mu.Lock()
+	out := make([]MachineInfo, 0, len(r.machines))
+	for name, m := range r.machines {
+		out = append(out, m.info(name))
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(out, func(a, b MachineInfo) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+func (m *registered) info(name string) MachineInfo {
+	return MachineInfo{
+		Name:     name,
+		Running:  m.running.Load(),
+		Runs:     m.runs.Load(),
+		Failures: m.failures.Load(),
+		Graph:    m.graph,
+	}
+}
+
+// WithRegistry counts the runs of the machine in r. If the machine is not registered in r, it is
+// registered without a Graph.
+func WithRegistry[T any](r *Registry) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if r == nil {
+			return req, errors.New("WithRegistry() cannot be passed a nil *Registry")
+		}
+		return WithObserver[T](registryObserver[T]{m: r.getOrRegister(req.conf.name)})(req)
+	}
+}
+
+// registryObserver is an Observer that counts the runs of a machine in a Registry.
+type registryObserver[T any] struct {
+	m *registered
+}
+
+func (o registryObserver[T]) OnStart(string, Request[T]) {
+	o.m.running.Add(1)
+}
+
+func (registryObserver[T]) OnTransition(context.Context, Transition[T]) {}
+
+func (o registryObserver[T]) OnEnd(_ string, req Request[T], _ time.Duration) {
+	o.m.running.Add(-1)
+	o.m.runs.Add(1)
+	if req.Err != nil {
+		o.m.failures.Add(1)
+	}
+}
+
+/*
+Handler returns an http.Handler that exposes the machines in the Registry. Paths are:
+
+  - "/" returns the MachineInfo of every machine as JSON.
+  - "/{name}" returns the MachineInfo of the machine called name as JSON.
+  - "/{name}/dot" returns the Graph of the machine in the Graphviz DOT language.
+  - "/{name}/mermaid" returns the Graph of the machine as a Mermaid state diagram.
+
+Use http.StripPrefix() to serve it under a path such as "/debug/statemachines".
+*/
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, r.Machines())
+	})
+	mux.HandleFunc("GET /{name}", func(w http.ResponseWriter, req *http.Request) {
+		m, ok := r.Machine(req.PathValue("name"))
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		writeJSON(w, m)
+	})
+	mux.HandleFunc("GET /{name}/{format}", func(w http.ResponseWriter, req *http.Request) {
+		var render func(*Graph) string
+		switch req.PathValue("format") {
+		case "dot":
+			render = (*Graph).DOT
+		case "mermaid":
+			render = (*Graph).Mermaid
+		}
+		m, ok := r.Machine(req.PathValue("name"))
+		if !ok || m.Graph == nil || render == nil {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(render(m.Graph)))
+	})
+	return mux
+}
+
+// writeJSON writes v to w as JSON.
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
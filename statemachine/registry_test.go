@@ -0,0 +1,171 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	g := NewGraph("steer").SetStart("steer").AddTransition("steer", "addTen", "addErr").AddTerminal("addTen")
+	reg := NewRegistry()
+	if err := reg.Register("steer", g); err != nil {
+		t.Fatalf("TestRegistry: Register(): got err == %s, want err == nil", err)
+	}
+	if err := reg.Register("steer", nil); err == nil {
+		t.Errorf("TestRegistry: Register() twice: got err == nil, want err != nil")
+	}
+	if err := reg.Register("", nil); err == nil {
+		t.Errorf("TestRegistry: Register() empty name: got err == nil, want err != nil")
+	}
+
+	for _, num := range []int{1, 2, math.MaxInt} {
+		req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: num}}
+		Run("steer", req, WithRegistry[data](reg))
+	}
+	req := Request[data]{Ctx: context.Background(), Next: addTen}
+	if _, err := Run("unregistered", req, WithRegistry[data](reg)); err != nil {
+		t.Fatalf("TestRegistry: Run(unregistered): got err == %s, want err == nil", err)
+	}
+	if _, err := Run("test", req, WithRegistry[data](nil)); err == nil {
+		t.Errorf("TestRegistry: WithRegistry(nil): got err == nil, want err != nil")
+	}
+
+	want := []MachineInfo{
+		{Name: "steer", Runs: 3, Failures: 1, Graph: g},
+		{Name: "unregistered", Runs: 1},
+	}
+	if diff := pretty.Compare(want, reg.Machines()); diff != "" {
+		t.Errorf("TestRegistry: Machines(): -want/+got:\n%s", diff)
+	}
+	if _, ok := reg.Machine("missing"); ok {
+		t.Errorf("TestRegistry: Machine(missing): got ok == true, want ok == false")
+	}
+}
+
+func TestRegistryRunning(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	block := func(req Request[data]) Request[data] {
+		close(started)
+		<-release
+		return req
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Run("block", Request[data]{Ctx: context.Background(), Next: block}, WithRegistry[data](reg))
+	}()
+
+	<-started
+	if m, _ := reg.Machine("block"); m.Running != 1 {
+		t.Errorf("TestRegistryRunning: got Running == %d, want 1", m.Running)
+	}
+	close(release)
+	<-done
+	if m, _ := reg.Machine("block"); m.Running != 0 || m.Runs != 1 {
+		t.Errorf("TestRegistryRunning: got Running == %d, Runs == %d, want 0, 1", m.Running, m.Runs)
+	}
+}
+
+func TestRegistryParallel(t *testing.T) {
+	t.Parallel()
+
+	reg := NewRegistry()
+	for i := 0; i < 20; i++ {
+		req := Request[data]{Ctx: context.Background(), Next: ParallelAll(sum, addTen, addErr), Data: data{Num: 1}}
+		if _, err := Run("parallel", req, WithRegistry[data](reg)); err != nil {
+			t.Fatalf("TestRegistryParallel: got err == %s, want err == nil", err)
+		}
+	}
+
+	want := []MachineInfo{{Name: "parallel", Runs: 20}}
+	if diff := pretty.Compare(want, reg.Machines()); diff != "" {
+		t.Errorf("TestRegistryParallel: Machines(): -want/+got:\n%s", diff)
+	}
+}
+
+func TestRegistryHandler(t *testing.T) {
+	t.Parallel()
+
+	g := NewGraph("steer").SetStart("steer").AddTransition("steer", "addTen").AddTerminal("addTen")
+	reg := NewRegistry()
+	reg.Register("steer", g)
+	reg.Register("nograph", nil)
+
+	srv := httptest.NewServer(http.StripPrefix("/debug/statemachines", reg.Handler()))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		want     string
+	}{
+		{name: "List", path: "/", wantCode: http.StatusOK},
+		{name: "Machine", path: "/steer", wantCode: http.StatusOK},
+		{name: "DOT", path: "/steer/dot", wantCode: http.StatusOK, want: g.DOT()},
+		{name: "Mermaid", path: "/steer/mermaid", wantCode: http.StatusOK, want: g.Mermaid()},
+		{name: "Unknown format", path: "/steer/png", wantCode: http.StatusNotFound},
+		{name: "Unknown machine", path: "/missing", wantCode: http.StatusNotFound},
+		{name: "No graph", path: "/nograph/dot", wantCode: http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(srv.URL + "/debug/statemachines" + test.path)
+		if err != nil {
+			t.Fatalf("TestRegistryHandler(%s): got err == %s, want err == nil", test.name, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.wantCode {
+			t.Errorf("TestRegistryHandler(%s): got status %d, want %d", test.name, resp.StatusCode, test.wantCode)
+			continue
+		}
+		if test.want != "" && string(b) != test.want {
+			t.Errorf("TestRegistryHandler(%s): got %q, want %q", test.name, b, test.want)
+		}
+
+		switch test.name {
+		case "List":
+			var got []map[string]any
+			if err := json.Unmarshal(b, &got); err != nil || len(got) != 2 {
+				t.Errorf("TestRegistryHandler(%s): got %s, want 2 machines", test.name, b)
+			}
+		case "Machine":
+			var got struct {
+				Name  string
+				Graph struct {
+					Start       string
+					Transitions map[string][]string
+					Terminals   []string
+				}
+			}
+			if err := json.Unmarshal(b, &got); err != nil {
+				t.Fatalf("TestRegistryHandler(%s): could not decode %s: %s", test.name, b, err)
+			}
+			if got.Name != "steer" || got.Graph.Start != "steer" || strings.Join(got.Graph.Transitions["steer"], ",") != "addTen" || strings.Join(got.Graph.Terminals, ",") != "addTen" {
+				t.Errorf("TestRegistryHandler(%s): got %s", test.name, b)
+			}
+		}
+	}
+
+	// Looking up a machine does not register it.
+	if got := len(reg.Machines()); got != 2 {
+		t.Errorf("TestRegistryHandler: got %d machines after requests, want 2", got)
+	}
+}
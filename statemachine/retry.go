@@ -0,0 +1,76 @@
+package statemachine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+// RetryError is the Request.Err set by a State created with Retry() when the State did not succeed.
+type RetryError struct {
+	// State is the name of the State that was retried.
+	State string
+	// Record is the Record of the last attempt. Record.Err is the error from the last attempt.
+	Record exponential.Record
+	// Err is the error returned by Backoff.Retry().
+	Err error
+}
+
+// Error implements error.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("state %s failed after %d attempts: %s", e.State, e.Record.Attempt, e.Err)
+}
+
+// Unwrap returns the error returned by Backoff.Retry().
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+/*
+Retry returns a State that executes state using b.Retry(), so that transient failures inside state are
+retried according to the Backoff's Policy before the machine gives up. An attempt fails when state
+returns a Request with Err set. Return an error wrapping exponential.ErrPermanent to stop retrying.
+
+Every attempt receives the Request that was passed to the returned State, with Request.Ctx set to
+the Context for the attempt, so changes a failed attempt makes to Request.Data are not seen by the next
+attempt (unless Data contains pointers). If all attempts fail, Request.Err is set to a *RetryError.
+options are passed to b.Retry().
+
+Example:
+
+	boff, err := exponential.New()
+	if err != nil {
+		// Handle error
+	}
+
+	func (m *machine) Start(req statemachine.Request[Data]) statemachine.Request[Data] {
+		req.Next = statemachine.Retry(m.boff, m.CreateVM)
+		return req
+	}
+*/
+func Retry[T any](b *exponential.Backoff, state State[T], options ...exponential.RetryOption) State[T] {
+	stateName := methodName(state)
+
+	return func(req Request[T]) Request[T] {
+		var (
+			out Request[T]
+			rec exponential.Record
+		)
+		op := func(ctx context.Context, r exponential.Record) error {
+			in := req
+			in.Ctx = ctx
+			out = state(in)
+			out.Ctx = req.Ctx
+			rec = r
+			rec.Err = out.Err
+			return out.Err
+		}
+
+		if err := b.Retry(req.Ctx, op, options...); err != nil {
+			req.Err = &RetryError{State: stateName, Record: rec, Err: err}
+			return req
+		}
+		return out
+	}
+}
@@ -0,0 +1,89 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	errTransient := errors.New("transient")
+
+	// flaky returns a State that fails the first failures times it is called.
+	flaky := func(failures int, err error) State[data] {
+		calls := 0
+		return func(req Request[data]) Request[data] {
+			calls++
+			req.Data.Num += 100
+			if calls <= failures {
+				req.Err = err
+				return req
+			}
+			req.Next = addTen
+			return req
+		}
+	}
+
+	tests := []struct {
+		name         string
+		state        State[data]
+		wantNum      int
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:    "Success first attempt",
+			state:   flaky(0, errTransient),
+			wantNum: 111,
+		},
+		{
+			name:    "Success after failures",
+			state:   flaky(3, errTransient),
+			wantNum: 111,
+		},
+		{
+			name:         "Permanent error",
+			state:        flaky(10, fmt.Errorf("bad: %w", exponential.ErrPermanent)),
+			wantNum:      1,
+			wantAttempts: 1,
+			wantErr:      exponential.ErrPermanent,
+		},
+	}
+
+	for _, test := range tests {
+		b, err := exponential.New(exponential.WithTesting())
+		if err != nil {
+			panic(err)
+		}
+
+		req := Request[data]{Ctx: context.Background(), Next: Retry(b, test.state), Data: data{Num: 1}}
+		got, err := Run("test", req)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestRetry(%s): got err == %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestRetry(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+		if err == nil {
+			continue
+		}
+
+		var rerr *RetryError
+		if !errors.As(err, &rerr) {
+			t.Errorf("TestRetry(%s): got err type %T, want *RetryError", test.name, err)
+			continue
+		}
+		if rerr.Record.Attempt != test.wantAttempts {
+			t.Errorf("TestRetry(%s): got Record.Attempt == %d, want %d", test.name, rerr.Record.Attempt, test.wantAttempts)
+		}
+		if rerr.Record.Err == nil {
+			t.Errorf("TestRetry(%s): got Record.Err == nil, want err != nil", test.name)
+		}
+	}
+}
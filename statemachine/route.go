@@ -0,0 +1,90 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrNoCase is set as Request.Err by a Switch() State when the key has no case and there is no default State.
+var ErrNoCase = errors.New("no case for key")
+
+/*
+If returns a State that routes to then if pred returns true for Request.Data and to otherwise if it
+returns false. A nil then or otherwise stops the machine. This replaces routing States that only check
+a condition:
+
+	func Start(req statemachine.Request[Data]) statemachine.Request[Data] {
+		req.Next = statemachine.If(hasDisk, AttachDisk, CreateDisk)
+		return req
+	}
+
+	func hasDisk(d Data) bool {
+		return d.Disk != ""
+	}
+
+When tracing, a "route" event is added to the State's span with the name of pred as "condition" and the
+name of the State routed to as "next".
+*/
+func If[T any](pred func(data T) bool, then, otherwise State[T]) State[T] {
+	cond := methodName(pred)
+
+	return func(req Request[T]) Request[T] {
+		next := otherwise
+		if pred(req.Data) {
+			next = then
+		}
+		return route(req, cond, next)
+	}
+}
+
+/*
+Switch returns a State that routes to the State in cases for the key returned by key for Request.Data.
+If there is no case for the key, it routes to def. If def is nil, Request.Err is set to an error wrapping
+ErrNoCase. A case with a nil State stops the machine.
+
+	func Start(req statemachine.Request[Data]) statemachine.Request[Data] {
+		req.Next = statemachine.Switch(
+			vmSize,
+			map[Size]statemachine.State[Data]{
+				Small: CreateSmall,
+				Large: CreateLarge,
+			},
+			nil,
+		)
+		return req
+	}
+
+When tracing, a "route" event is added to the State's span with the name of key as "condition",
+the key as "key" and the name of the State routed to as "next".
+*/
+func Switch[T any, K comparable](key func(data T) K, cases map[K]State[T], def State[T]) State[T] {
+	cond := methodName(key)
+
+	return func(req Request[T]) Request[T] {
+		k := key(req.Data)
+		next, ok := cases[k]
+		if !ok {
+			if def == nil {
+				req.Err = fmt.Errorf("Switch(%s): %w %v", cond, ErrNoCase, k)
+				return req
+			}
+			next = def
+		}
+		return route(req, cond, next, attribute.String("key", fmt.Sprint(k)))
+	}
+}
+
+// route sets req.Next to next and records the routing decision made by cond in the span.
+func route[T any](req Request[T], cond string, next State[T], attrs ...attribute.KeyValue) Request[T] {
+	req.Next = next
+
+	nextName := "<stop>"
+	if next != nil {
+		nextName = req.conf.displayName(methodName(next))
+	}
+	attrs = append(attrs, attribute.String("condition", cond), attribute.String("next", nextName))
+	req.addEvent("route", attrs...)
+	return req
+}
@@ -0,0 +1,153 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func isPositive(d data) bool {
+	return d.Num > 0
+}
+
+func sign(d data) string {
+	switch {
+	case d.Num > 0:
+		return "positive"
+	case d.Num < 0:
+		return "negative"
+	}
+	return "zero"
+}
+
+func TestIf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		num     int
+		state   State[data]
+		wantNum int
+	}{
+		{
+			name:    "Then",
+			num:     1,
+			state:   If(isPositive, addTen, nil),
+			wantNum: 11,
+		},
+		{
+			name:    "Otherwise",
+			num:     -1,
+			state:   If(isPositive, nil, addTen),
+			wantNum: 9,
+		},
+		{
+			name:    "Stop",
+			num:     -1,
+			state:   If(isPositive, addTen, nil),
+			wantNum: -1,
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: test.state, Data: data{Num: test.num}}
+		got, err := Run("test", req)
+		if err != nil {
+			t.Errorf("TestIf(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestIf(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]State[data]{
+		"positive": addTen,
+		"zero":     nil,
+	}
+
+	tests := []struct {
+		name    string
+		num     int
+		def     State[data]
+		wantNum int
+		wantErr error
+	}{
+		{
+			name:    "Case",
+			num:     1,
+			wantNum: 11,
+		},
+		{
+			name:    "Nil case stops",
+			num:     0,
+			wantNum: 0,
+		},
+		{
+			name:    "Default",
+			num:     -1,
+			def:     addTen,
+			wantNum: 9,
+		},
+		{
+			name:    "No case",
+			num:     -1,
+			wantErr: ErrNoCase,
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: Switch(sign, cases, test.def), Data: data{Num: test.num}}
+		got, err := Run("test", req)
+		if !errors.Is(err, test.wantErr) || (err != nil && test.wantErr == nil) {
+			t.Errorf("TestSwitch(%s): got err == %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestSwitch(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+	}
+}
+
+func TestRouteEvent(t *testing.T) {
+	t.Parallel()
+
+	rec, ctx := newFakeRecorder()
+	cases := map[string]State[data]{"positive": If(isPositive, addTen, nil)}
+	req := Request[data]{Ctx: ctx, Next: Switch(sign, cases, nil), Data: data{Num: 1}}
+	if _, err := Run("test", req, WithStateName[data](addTen, "AddTen")); err != nil {
+		t.Fatalf("TestRouteEvent: got err == %s, want err == nil", err)
+	}
+
+	var got []map[string]string
+	for _, s := range rec.spans {
+		for _, e := range s.events {
+			if e.name == "route" {
+				got = append(got, e.attrs)
+			}
+		}
+	}
+	want := []map[string]string{
+		{
+			"condition": "github.com/gostdlib/ops/statemachine.sign",
+			"key":       "positive",
+			"next":      "github.com/gostdlib/ops/statemachine.If[...].func1",
+		},
+		{
+			"condition": "github.com/gostdlib/ops/statemachine.isPositive",
+			"next":      "AddTen",
+		},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestRouteEvent: -want/+got:\n%s", diff)
+	}
+}
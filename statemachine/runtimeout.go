@@ -0,0 +1,50 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRunTimeout is returned by Run() when the time set with WithRunTimeout() expires. The returned error
+// wraps ErrRunTimeout, names the state that was executing when the time expired and wraps any error
+// that state returned.
+var ErrRunTimeout = errors.New("statemachine run timeout")
+
+// WithRunTimeout limits the time the entire machine may run for to d. Run() derives a Context from
+// Request.Ctx with a deadline d from now that is passed to every State. When a State returns after the
+// deadline, Run() stops with an error wrapping ErrRunTimeout that names that State. context.Cause() on the
+// Context passed to States returns ErrRunTimeout once the deadline has passed.
+// State timeouts don't protect against a machine that executes many slow states that are each under their
+// limit, this does. Request.Ctx is set back to the Context passed to Run() before finalizers are run.
+func WithRunTimeout[T any](d time.Duration) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if d <= 0 {
+			return req, fmt.Errorf("WithRunTimeout(%v) must be > 0", d)
+		}
+		req.conf.runTimeout = d
+		return req, nil
+	}
+}
+
+// runContext returns ctx with the deadline set by WithRunTimeout(). If there is no run timeout, ctx is returned.
+// c may be nil.
+func (c *runConfig[T]) runContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c == nil || c.runTimeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, c.runTimeout, ErrRunTimeout)
+}
+
+// checkRunTimeout returns an error if the run timeout expired while stateName was executing. err is the error
+// the state returned, if any. c may be nil.
+func (c *runConfig[T]) checkRunTimeout(ctx context.Context, stateName string, err error) error {
+	if c == nil || c.runTimeout == 0 || context.Cause(ctx) != ErrRunTimeout {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v expired while executing state %s: %w", ErrRunTimeout, c.runTimeout, stateName, err)
+	}
+	return fmt.Errorf("%w: %v expired while executing state %s", ErrRunTimeout, c.runTimeout, stateName)
+}
@@ -0,0 +1,89 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// crawl is a State that sleeps for 10ms and routes to itself until Data.Num reaches 0.
+func crawl(req Request[data]) Request[data] {
+	time.Sleep(10 * time.Millisecond)
+	if req.Data.Num > 0 {
+		req.Data.Num--
+		req.Next = crawl
+	}
+	return req
+}
+
+func TestWithRunTimeout(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		num     int
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "Error: timeout <= 0",
+			timeout: 0,
+			wantErr: true,
+		},
+		{
+			name:    "Finishes within timeout",
+			num:     1,
+			timeout: 10 * time.Second,
+		},
+		{
+			name:    "Timeout expires",
+			num:     1000,
+			timeout: 50 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		ctx := context.Background()
+		req := Request[data]{Ctx: ctx, Next: crawl, Data: data{Num: test.num}}
+		got, err := Run("test", req, WithRunTimeout[data](test.timeout))
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestWithRunTimeout(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestWithRunTimeout(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		if got.Ctx != ctx {
+			t.Errorf("TestWithRunTimeout(%s): got Request.Ctx that was not the Context passed to Run()", test.name)
+		}
+	}
+}
+
+func TestWithRunTimeoutError(t *testing.T) {
+	t.Parallel()
+
+	var cause error
+	errState := errors.New("state error")
+	slowErr := func(req Request[data]) Request[data] {
+		<-req.Ctx.Done()
+		cause = context.Cause(req.Ctx)
+		req.Err = errState
+		return req
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: slowErr}
+	_, err := Run("test", req, WithRunTimeout[data](10*time.Millisecond))
+	if !errors.Is(err, ErrRunTimeout) || !errors.Is(err, errState) {
+		t.Errorf("TestWithRunTimeoutError: got err == %v, want it to wrap ErrRunTimeout and the state error", err)
+	}
+	if !strings.Contains(err.Error(), "while executing state github.com/gostdlib/ops/statemachine.TestWithRunTimeoutError") {
+		t.Errorf("TestWithRunTimeoutError: got err == %v, want it to name the executing state", err)
+	}
+	if cause != ErrRunTimeout {
+		t.Errorf("TestWithRunTimeoutError: got context.Cause() == %v, want ErrRunTimeout", cause)
+	}
+}
@@ -0,0 +1,180 @@
+package statemachine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a Scheduler runs a machine.
+type Schedule interface {
+	// Next returns the next time to run after t. A zero time means there are no more runs.
+	Next(t time.Time) time.Time
+}
+
+// every is a Schedule that runs at a fixed interval.
+type every time.Duration
+
+// Every returns a Schedule that runs every d. d must be > 0 or there are no runs.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+// Next implements Schedule.Next().
+func (e every) Next(t time.Time) time.Time {
+	if e <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(e))
+}
+
+// cronSchedule is a Schedule parsed from a cron expression. Each field is a bit set of the allowed values.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domAll and dowAll are set when the day of month or day of week field allows every value, such as
+	// with "*", "*/1" or "1-31".
+	domAll, dowAll bool
+}
+
+// cronAliases are the supported shorthands for cron expressions.
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron returns a Schedule for a standard 5 field cron expression: minute, hour, day of month, month and
+// day of week. Fields support "*", values, ranges ("1-5"), lists ("1,15") and steps ("*/15", "0-30/10").
+// Day of week is 0-7, where 0 and 7 are Sunday. If both day of month and day of week are set, a day matching
+// either runs, as with cron. The shorthands @yearly, @annually, @monthly, @weekly, @daily, @midnight and
+// @hourly are supported. Times are in the location of the time passed to Next().
+func Cron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if alias, ok := cronAliases[expr]; ok {
+		expr = alias
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, has %d", expr, len(fields))
+	}
+
+	c := &cronSchedule{}
+	bounds := []struct {
+		set      *uint64
+		min, max int
+	}{
+		{&c.minute, 0, 59},
+		{&c.hour, 0, 23},
+		{&c.dom, 1, 31},
+		{&c.month, 1, 12},
+		{&c.dow, 0, 7},
+	}
+	for i, b := range bounds {
+		set, err := parseCronField(fields[i], b.min, b.max)
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		*b.set = set
+	}
+	// Sunday can be 0 or 7.
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAll = c.dom == cronBits(1, 31)
+	c.dowAll = c.dow&cronBits(0, 6) == cronBits(0, 6)
+	return c, nil
+}
+
+// cronBits returns a bit set with every value between min and max.
+func cronBits(min, max int) uint64 {
+	return (1<<(max+1) - 1) &^ (1<<min - 1)
+}
+
+// parseCronField parses a cron field with values between min and max into a bit set.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			rng = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("field %q has an invalid step", field)
+			}
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(a)
+			hi, err2 = strconv.Atoi(b)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("field %q has an invalid range", field)
+			}
+		default:
+			v, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("field %q has an invalid value", field)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("field %q must be between %d and %d", field, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Next implements Schedule.Next().
+func (c *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+
+	// Every valid expression matches within 5 years, such as Feb 29th.
+	for limit := t.AddDate(5, 0, 0); t.Before(limit); {
+		switch {
+		case c.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports if the day of t matches the day of month and day of week fields.
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<t.Weekday()) != 0
+	switch {
+	case c.domAll && c.dowAll:
+		return true
+	case c.domAll:
+		return dow
+	case c.dowAll:
+		return dom
+	}
+	return dom || dow
+}
@@ -0,0 +1,124 @@
+package statemachine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got, want := Every(time.Minute).Next(now), now.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("TestEvery: got %v, want %v", got, want)
+	}
+	if got := Every(0).Next(now); !got.IsZero() {
+		t.Errorf("TestEvery: Every(0): got %v, want zero time", got)
+	}
+}
+
+func TestCron(t *testing.T) {
+	t.Parallel()
+
+	// 2024-01-01 is a Monday.
+	from := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "Error: too few fields", expr: "* * * *", wantErr: true},
+		{name: "Error: out of range", expr: "60 * * * *", wantErr: true},
+		{name: "Error: bad range", expr: "5-1 * * * *", wantErr: true},
+		{name: "Error: bad step", expr: "*/0 * * * *", wantErr: true},
+		{name: "Error: not a number", expr: "a * * * *", wantErr: true},
+		{
+			name: "Every minute",
+			expr: "* * * * *",
+			want: time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC),
+		},
+		{
+			name: "Step",
+			expr: "*/15 * * * *",
+			want: time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			name: "Value with step",
+			expr: "10/20 * * * *",
+			want: time.Date(2024, 1, 1, 10, 50, 0, 0, time.UTC),
+		},
+		{
+			name: "List and range",
+			expr: "0,5 8-9 * * *",
+			want: time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Day of week",
+			expr: "0 9 * * 5",
+			want: time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Sunday as 7",
+			expr: "0 0 * * 7",
+			want: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Day of month or day of week",
+			expr: "0 0 15 * 3",
+			want: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Day of month step is every day",
+			expr: "0 0 */1 * 5",
+			want: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Day of month range is every day",
+			expr: "0 0 1-31 * 5",
+			want: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Day of week range is every day",
+			expr: "0 0 15 * 0-6",
+			want: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Month",
+			expr: "0 0 1 3 *",
+			want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Leap day",
+			expr: "0 0 29 2 *",
+			want: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Alias",
+			expr: "@daily",
+			want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Never matches",
+			expr: "0 0 31 2 *",
+		},
+	}
+
+	for _, test := range tests {
+		s, err := Cron(test.expr)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestCron(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestCron(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if got := s.Next(from); !got.Equal(test.want) {
+			t.Errorf("TestCron(%s): got Next() == %v, want %v", test.name, got, test.want)
+		}
+	}
+}
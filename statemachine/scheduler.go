@@ -0,0 +1,239 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+)
+
+// Overlap decides what a Scheduler does when a run is due while the previous run has not finished.
+type Overlap uint8
+
+const (
+	// OverlapSkip skips the run that is due. This is the default.
+	OverlapSkip Overlap = 1
+	// OverlapQueue starts the run that is due when the previous run finishes. At most one run is queued,
+	// runs that are due while one is queued are skipped.
+	OverlapQueue Overlap = 2
+	// OverlapCancel cancels the Context of the previous run, waits for it to finish and starts the run that is due.
+	OverlapCancel Overlap = 3
+)
+
+// SchedulerOption is an option for NewScheduler().
+type SchedulerOption[T any] func(*Scheduler[T]) error
+
+// WithOverlap sets what happens when a run is due while the previous run has not finished.
+// Defaults to OverlapSkip.
+func WithOverlap[T any](o Overlap) SchedulerOption[T] {
+	return func(s *Scheduler[T]) error {
+		switch o {
+		case OverlapSkip, OverlapQueue, OverlapCancel:
+		default:
+			return fmt.Errorf("WithOverlap(%d) is not a valid Overlap", o)
+		}
+		s.overlap = o
+		return nil
+	}
+}
+
+// WithJitter delays each run by a random duration in [0, d). This spreads out machines on many hosts
+// that use the same Schedule.
+func WithJitter[T any](d time.Duration) SchedulerOption[T] {
+	return func(s *Scheduler[T]) error {
+		if d < 0 {
+			return fmt.Errorf("WithJitter(%v) must be >= 0", d)
+		}
+		s.jitter = d
+		return nil
+	}
+}
+
+// WithSchedulerClock sets the Clock used to wait for runs. This is used in tests.
+func WithSchedulerClock[T any](c exponential.Clock) SchedulerOption[T] {
+	return func(s *Scheduler[T]) error {
+		if c == nil {
+			return errors.New("WithSchedulerClock() cannot be passed a nil Clock")
+		}
+		s.clock = c
+		return nil
+	}
+}
+
+// WithSchedulerRunOptions sets the Options passed to Run() for every run.
+func WithSchedulerRunOptions[T any](options ...Option[T]) SchedulerOption[T] {
+	return func(s *Scheduler[T]) error {
+		s.options = append(s.options, options...)
+		return nil
+	}
+}
+
+/*
+Scheduler runs a machine on a Schedule, such as a periodic reconciliation loop. Create with NewScheduler().
+
+Example that reconciles every 5 minutes:
+
+	s, err := statemachine.NewScheduler(
+		"reconcile",
+		statemachine.Every(5*time.Minute),
+		func(ctx context.Context) statemachine.Request[Data] {
+			return statemachine.Request[Data]{Ctx: ctx, Next: Reconcile, Data: Data{client: client}}
+		},
+	)
+	if err != nil {
+		// Do something
+	}
+
+	results, err := s.Start(ctx)
+	if err != nil {
+		// Do something
+	}
+	for r := range results {
+		if r.Err != nil {
+			log.Println(r.Err)
+		}
+	}
+*/
+type Scheduler[T any] struct {
+	name    string
+	sched   Schedule
+	newReq  func(ctx context.Context) Request[T]
+	overlap Overlap
+	jitter  time.Duration
+	clock   exponential.Clock
+	options []Option[T]
+
+	started atomic.Bool
+}
+
+// NewScheduler creates a Scheduler that runs the machine called name when sched says. newReq is called for
+// each run to create the Request passed to Run(). It must use ctx as Request.Ctx or a Context derived from it,
+// as ctx is canceled when the Scheduler stops or by OverlapCancel.
+func NewScheduler[T any](name string, sched Schedule, newReq func(ctx context.Context) Request[T], options ...SchedulerOption[T]) (*Scheduler[T], error) {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return nil, nameEmptyErr
+	case sched == nil:
+		return nil, errors.New("NewScheduler() cannot be passed a nil Schedule")
+	case newReq == nil:
+		return nil, errors.New("NewScheduler() cannot be passed a nil newReq func")
+	}
+
+	s := &Scheduler[T]{name: name, sched: sched, newReq: newReq, overlap: OverlapSkip, clock: realClock{}}
+	for _, o := range options {
+		if err := o(s); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Start starts running the machine on the Schedule until ctx is done. The Result of every run is sent on the
+// returned channel, which must be read or runs will block. The channel is closed after ctx is done, or the
+// Schedule has no more runs, and the last run has finished. Start can only be called once.
+func (s *Scheduler[T]) Start(ctx context.Context) (<-chan Result[T], error) {
+	if !s.started.CompareAndSwap(false, true) {
+		return nil, errors.New("Scheduler.Start() can only be called once")
+	}
+	next := s.sched.Next(s.clock.Now())
+	if next.IsZero() {
+		return nil, errors.New("Schedule has no runs")
+	}
+
+	out := make(chan Result[T])
+	go s.loop(ctx, next, out)
+	return out, nil
+}
+
+// loop starts runs at next and every time after it that the Schedule returns, until ctx is done.
+func (s *Scheduler[T]) loop(ctx context.Context, next time.Time, out chan<- Result[T]) {
+	defer close(out)
+
+	var (
+		// running is closed when the current run finishes. It is nil when there is no run.
+		running   chan struct{}
+		cancelRun context.CancelFunc
+		queued    bool
+	)
+	start := func() {
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		running, cancelRun = done, cancel
+
+		go func() {
+			defer close(done)
+			defer cancel()
+
+			req, err := Run(s.name, s.newReq(runCtx), s.options...)
+			out <- Result[T]{Request: req, Err: err}
+		}()
+	}
+
+	var (
+		timer exponential.Timer
+		tick  <-chan time.Time
+	)
+	arm := func() {
+		if next.IsZero() {
+			timer, tick = nil, nil
+			return
+		}
+		timer = s.clock.NewTimer(s.clock.Until(next) + s.jitterDuration())
+		tick = timer.C()
+	}
+	arm()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			if running != nil {
+				<-running
+			}
+			return
+		case <-tick:
+			// Schedule from the time the run was due, unless runs were missed.
+			now := s.clock.Now()
+			if next = s.sched.Next(next); !next.IsZero() && next.Before(now) {
+				next = s.sched.Next(now)
+			}
+			arm()
+
+			switch {
+			case running == nil:
+				start()
+			case s.overlap == OverlapQueue:
+				queued = true
+			case s.overlap == OverlapCancel:
+				cancelRun()
+				<-running
+				start()
+			}
+		case <-running:
+			running, cancelRun = nil, nil
+			if queued {
+				queued = false
+				start()
+			}
+		}
+
+		if tick == nil && running == nil {
+			return
+		}
+	}
+}
+
+// jitterDuration returns a random duration in [0, s.jitter).
+func (s *Scheduler[T]) jitterDuration() time.Duration {
+	if s.jitter <= 0 {
+		return 0
+	}
+	return rand.N(s.jitter)
+}
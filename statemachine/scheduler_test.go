@@ -0,0 +1,240 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// held is a machine whose run State signals when it is entered and waits to be released or canceled.
+type held struct {
+	enter   chan struct{}
+	release chan struct{}
+}
+
+func newHeld() *held {
+	return &held{enter: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (h *held) run(req Request[data]) Request[data] {
+	h.enter <- struct{}{}
+	select {
+	case <-h.release:
+		req.Data.Num++
+	case <-req.Ctx.Done():
+		req.Err = req.Ctx.Err()
+	}
+	return req
+}
+
+func (h *held) newReq(ctx context.Context) Request[data] {
+	return Request[data]{Ctx: ctx, Next: h.run}
+}
+
+// recv receives from ch or fails the test.
+func recv[E any](t *testing.T, ch <-chan E) E {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting on channel")
+	}
+	panic("unreachable")
+}
+
+// none fails the test if ch receives within 20ms.
+func none[E any](t *testing.T, ch <-chan E) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatalf("got unexpected receive on channel")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestNewScheduler(t *testing.T) {
+	t.Parallel()
+
+	h := newHeld()
+	tests := []struct {
+		name    string
+		sname   string
+		sched   Schedule
+		newReq  func(context.Context) Request[data]
+		options []SchedulerOption[data]
+		wantErr bool
+	}{
+		{name: "Error: empty name", sched: Every(time.Second), newReq: h.newReq, wantErr: true},
+		{name: "Error: nil Schedule", sname: "test", newReq: h.newReq, wantErr: true},
+		{name: "Error: nil newReq", sname: "test", sched: Every(time.Second), wantErr: true},
+		{
+			name:    "Error: bad Overlap",
+			sname:   "test",
+			sched:   Every(time.Second),
+			newReq:  h.newReq,
+			options: []SchedulerOption[data]{WithOverlap[data](0)},
+			wantErr: true,
+		},
+		{
+			name:    "Error: negative jitter",
+			sname:   "test",
+			sched:   Every(time.Second),
+			newReq:  h.newReq,
+			options: []SchedulerOption[data]{WithJitter[data](-1)},
+			wantErr: true,
+		},
+		{
+			name:    "Error: nil Clock",
+			sname:   "test",
+			sched:   Every(time.Second),
+			newReq:  h.newReq,
+			options: []SchedulerOption[data]{WithSchedulerClock[data](nil)},
+			wantErr: true,
+		},
+		{
+			name:   "Success",
+			sname:  "test",
+			sched:  Every(time.Second),
+			newReq: h.newReq,
+			options: []SchedulerOption[data]{
+				WithOverlap[data](OverlapQueue),
+				WithJitter[data](time.Second),
+				WithSchedulerRunOptions(WithMaxTransitions[data](10)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewScheduler(test.sname, test.sched, test.newReq, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNewScheduler(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNewScheduler(%s): got err == %s, want err == nil", test.name, err)
+		}
+	}
+}
+
+func TestSchedulerStart(t *testing.T) {
+	t.Parallel()
+
+	h := newHeld()
+	s, err := NewScheduler("test", Every(0), h.newReq)
+	if err != nil {
+		t.Fatalf("TestSchedulerStart: got err == %s, want err == nil", err)
+	}
+	if _, err := s.Start(context.Background()); err == nil {
+		t.Errorf("TestSchedulerStart: Schedule with no runs: got err == nil, want err != nil")
+	}
+	if _, err := s.Start(context.Background()); err == nil {
+		t.Errorf("TestSchedulerStart: second Start(): got err == nil, want err != nil")
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		overlap Overlap
+	}{
+		{name: "Skip", overlap: OverlapSkip},
+		{name: "Queue", overlap: OverlapQueue},
+		{name: "Cancel", overlap: OverlapCancel},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			h := newHeld()
+			clock := newFakeClock()
+			s, err := NewScheduler(
+				"test",
+				Every(time.Minute),
+				h.newReq,
+				WithOverlap[data](test.overlap),
+				WithSchedulerClock[data](clock),
+			)
+			if err != nil {
+				t.Fatalf("got err == %s, want err == nil", err)
+			}
+			results, err := s.Start(ctx)
+			if err != nil {
+				t.Fatalf("Start(): got err == %s, want err == nil", err)
+			}
+
+			if d := recv(t, clock.created); d != time.Minute {
+				t.Fatalf("got timer for %v, want %v", d, time.Minute)
+			}
+			clock.advance(time.Minute)
+			recv(t, h.enter)
+
+			// The second run is due while the first is running.
+			recv(t, clock.created)
+			clock.advance(time.Minute)
+			recv(t, clock.created)
+
+			switch test.overlap {
+			case OverlapSkip:
+				h.release <- struct{}{}
+				if r := recv(t, results); r.Err != nil {
+					t.Fatalf("first run: got err == %s, want err == nil", r.Err)
+				}
+				none(t, h.enter)
+			case OverlapQueue:
+				h.release <- struct{}{}
+				if r := recv(t, results); r.Err != nil {
+					t.Fatalf("first run: got err == %s, want err == nil", r.Err)
+				}
+				recv(t, h.enter)
+				h.release <- struct{}{}
+				recv(t, results)
+			case OverlapCancel:
+				if r := recv(t, results); !errors.Is(r.Err, context.Canceled) {
+					t.Fatalf("first run: got err == %v, want context.Canceled", r.Err)
+				}
+				recv(t, h.enter)
+				h.release <- struct{}{}
+				recv(t, results)
+			}
+
+			cancel()
+			if _, ok := <-results; ok {
+				t.Errorf("got a Result after the Scheduler was stopped, want the channel closed")
+			}
+		})
+	}
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := newHeld()
+	clock := newFakeClock()
+	s, err := NewScheduler("test", Every(time.Minute), h.newReq, WithJitter[data](10*time.Second), WithSchedulerClock[data](clock))
+	if err != nil {
+		t.Fatalf("TestSchedulerJitter: got err == %s, want err == nil", err)
+	}
+	results, err := s.Start(ctx)
+	if err != nil {
+		t.Fatalf("TestSchedulerJitter: Start(): got err == %s, want err == nil", err)
+	}
+
+	d := recv(t, clock.created)
+	if d < time.Minute || d >= time.Minute+10*time.Second {
+		t.Errorf("TestSchedulerJitter: got timer for %v, want [1m, 1m10s)", d)
+	}
+	cancel()
+	for range results {
+	}
+}
@@ -0,0 +1,47 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+)
+
+/*
+WithSetup calls f with the Request before the first State is executed, after all other Options have been
+applied. f can inject dependencies such as clients, loggers and config into Request.Data, so Data
+can stay a plain struct and tests can inject fakes the same way:
+
+	func withClient(c Client) statemachine.Option[Data] {
+		return statemachine.WithSetup(func(req statemachine.Request[Data]) (statemachine.Request[Data], error) {
+			req.Data.client = c
+			return req, nil
+		})
+	}
+
+WithSetup can be passed multiple times, each f is called in the order passed. If f returns an error, Run()
+returns it without executing any State. Step() also calls f.
+*/
+func WithSetup[T any](f func(req Request[T]) (Request[T], error)) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if f == nil {
+			return req, errors.New("WithSetup() cannot be passed a nil func")
+		}
+		req.conf.setups = append(req.conf.setups, f)
+		return req, nil
+	}
+}
+
+// setup calls the funcs passed to WithSetup() with req. c may be nil.
+func (c *runConfig[T]) setup(req Request[T]) (Request[T], error) {
+	if c == nil {
+		return req, nil
+	}
+	for i, f := range c.setups {
+		var err error
+		req, err = f(req)
+		if err != nil {
+			req.Next = nil
+			return req, fmt.Errorf("WithSetup() func %d failed: %w", i, err)
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,67 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithSetup(t *testing.T) {
+	t.Parallel()
+
+	errSetup := errors.New("setup")
+	set := func(n int) func(Request[data]) (Request[data], error) {
+		return func(req Request[data]) (Request[data], error) {
+			req.Data.Num = req.Data.Num*10 + n
+			return req, nil
+		}
+	}
+	fail := func(req Request[data]) (Request[data], error) {
+		return req, errSetup
+	}
+
+	tests := []struct {
+		name    string
+		options []Option[data]
+		wantNum int
+		wantErr error
+	}{
+		{
+			name:    "Error: nil func",
+			options: []Option[data]{WithSetup[data](nil)},
+			wantErr: errors.New("any"),
+		},
+		{
+			name:    "Error: setup fails",
+			options: []Option[data]{WithSetup(set(1)), WithSetup(fail)},
+			wantErr: errSetup,
+		},
+		{
+			name:    "Setups run in order before the first state",
+			options: []Option[data]{WithSetup(set(1)), WithSetup(set(2))},
+			// Data.Num is 12 when steer is executed, so it routes to addTen.
+			wantNum: 22,
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: steer}
+		got, err := Run("test", req, test.options...)
+		switch {
+		case err == nil && test.wantErr != nil:
+			t.Errorf("TestWithSetup(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && test.wantErr == nil:
+			t.Errorf("TestWithSetup(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			if test.wantErr == errSetup && !errors.Is(err, errSetup) {
+				t.Errorf("TestWithSetup(%s): got err == %s, want it to wrap %s", test.name, err, errSetup)
+			}
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestWithSetup(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+	}
+}
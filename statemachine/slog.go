@@ -0,0 +1,64 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// WithSlog logs a record to l at level for every State executed, with the machine name, state name,
+// duration and any error, and a record when Run() finishes with the machine name, duration and any error.
+// Records with an error are logged at slog.LevelError if level is lower.
+func WithSlog[T any](l *slog.Logger, level slog.Level) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if l == nil {
+			return req, errors.New("WithSlog() cannot be passed a nil *slog.Logger")
+		}
+		return WithObserver[T](slogObserver[T]{l: l, level: level})(req)
+	}
+}
+
+// slogObserver is an Observer that logs to a *slog.Logger.
+type slogObserver[T any] struct {
+	l     *slog.Logger
+	level slog.Level
+}
+
+func (slogObserver[T]) OnStart(string, Request[T]) {}
+
+func (o slogObserver[T]) OnTransition(ctx context.Context, t Transition[T]) {
+	if t.Kind != Exited {
+		return
+	}
+	o.log(
+		ctx,
+		t.Err,
+		"statemachine state executed",
+		slog.String("machine", t.Machine),
+		slog.String("state", t.State),
+		slog.Duration("duration", t.Duration),
+	)
+}
+
+func (o slogObserver[T]) OnEnd(machine string, req Request[T], elapsed time.Duration) {
+	o.log(
+		req.Ctx,
+		req.Err,
+		"statemachine run finished",
+		slog.String("machine", machine),
+		slog.Duration("duration", elapsed),
+	)
+}
+
+// log logs msg with attrs. If err is not nil, it is added and the level is raised to slog.LevelError.
+func (o slogObserver[T]) log(ctx context.Context, err error, msg string, attrs ...slog.Attr) {
+	lvl := o.level
+	if err != nil {
+		if lvl < slog.LevelError {
+			lvl = slog.LevelError
+		}
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	o.l.LogAttrs(ctx, lvl, msg, attrs...)
+}
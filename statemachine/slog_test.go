@@ -0,0 +1,65 @@
+package statemachine
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/diff"
+)
+
+func TestWithSlog(t *testing.T) {
+	t.Parallel()
+
+	// noTime removes attributes that change between runs.
+	noTime := func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey || a.Key == "duration" {
+			return slog.Attr{}
+		}
+		if a.Key == "state" {
+			return slog.String("state", shortName(a.Value.String()))
+		}
+		return a
+	}
+
+	tests := []struct {
+		name string
+		num  int
+		want string
+	}{
+		{
+			name: "Success",
+			num:  1,
+			want: `
+level=INFO msg="statemachine state executed" machine=machine state=statemachine.steer
+level=INFO msg="statemachine state executed" machine=machine state=statemachine.addTen
+level=INFO msg="statemachine run finished" machine=machine
+`,
+		},
+		{
+			name: "Error",
+			num:  math.MaxInt,
+			want: `
+level=INFO msg="statemachine state executed" machine=machine state=statemachine.steer
+level=ERROR msg="statemachine state executed" machine=machine state=statemachine.addErr error=addErr
+level=ERROR msg="statemachine run finished" machine=machine error="statemachine(machine): state github.com/gostdlib/ops/statemachine.addErr: addErr"
+`,
+		},
+	}
+
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		l := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{ReplaceAttr: noTime}))
+
+		req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: test.num}}
+		Run("machine", req, WithSlog[data](l, slog.LevelInfo))
+
+		want := strings.TrimPrefix(test.want, "\n")
+		if got := buf.String(); got != want {
+			t.Errorf("TestWithSlog(%s): -want/+got:\n%s", test.name, diff.Diff(want, got))
+		}
+	}
+}
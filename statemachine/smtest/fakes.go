@@ -0,0 +1,272 @@
+package smtest
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/gostdlib/ops/retry/exponential"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Clock is a fake exponential.Clock for use with statemachine.WithClock(). Time only moves when Advance()
+// is called, or when a Timer is created if SetAutoAdvance(true) was called. Create with NewClock().
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	auto    bool
+	timers  []*timer
+	created []time.Duration
+}
+
+// NewClock creates a new Clock set to start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// SetAutoAdvance sets if the Clock moves forward to the time a Timer fires when the Timer is created.
+// This lets a State that waits, such as statemachine.Wait(), be tested without advancing the Clock from
+// another goroutine.
+func (c *Clock) SetAutoAdvance(b bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auto = b
+}
+
+// Now implements exponential.Clock.Now().
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Until implements exponential.Clock.Until().
+func (c *Clock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+// NewTimer implements exponential.Clock.NewTimer().
+func (c *Clock) NewTimer(d time.Duration) exponential.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1), active: true}
+	c.timers = append(c.timers, t)
+	c.created = append(c.created, d)
+	if c.auto {
+		c.advance(d)
+	}
+	return t
+}
+
+// Advance moves the Clock forward by d, firing any Timers that expire.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance(d)
+}
+
+// advance implements Advance(). c.mu must be held.
+func (c *Clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.at.After(c.now) {
+			t.active = false
+			t.ch <- c.now
+		}
+	}
+}
+
+// Timers returns the durations of all Timers created by the Clock, in the order they were created.
+func (c *Clock) Timers() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.created...)
+}
+
+// timer is an exponential.Timer created by a Clock.
+type timer struct {
+	clock  *Clock
+	at     time.Time
+	ch     chan time.Time
+	active bool
+}
+
+// C implements exponential.Timer.C().
+func (t *timer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop implements exponential.Timer.Stop().
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	was := t.active
+	t.active = false
+	return was
+}
+
+// Reset implements exponential.Timer.Reset().
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	was := t.active
+	t.at, t.active = t.clock.now.Add(d), true
+	return was
+}
+
+// Event is an OTEL event recorded by a SpanRecorder.
+type Event struct {
+	// Name is the name of the event.
+	Name string
+	// Attrs are the attributes of the event, with values converted to strings.
+	Attrs map[string]string
+}
+
+// Span is an OTEL span recorded by a SpanRecorder.
+type Span struct {
+	// Name is the name of the span.
+	Name string
+	// Attrs are the attributes of the span, with values converted to strings.
+	Attrs map[string]string
+	// Events are the events recorded on the span, including errors, which are named "error".
+	Events []Event
+	// Error is true if the span status was set to error.
+	Error bool
+	// Ended is true if the span was ended.
+	Ended bool
+}
+
+// SpanRecorder records the OTEL spans created by States, without an OTEL SDK. Create with NewSpanRecorder()
+// and pass the Request a Context from SpanRecorder.Context().
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*recSpan
+}
+
+// NewSpanRecorder creates a new SpanRecorder.
+func NewSpanRecorder() *SpanRecorder {
+	return &SpanRecorder{}
+}
+
+// Context returns ctx with a recording span called "root". Spans created from the Context are recorded.
+func (r *SpanRecorder) Context(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, r.newSpan("root"))
+}
+
+// Spans returns the recorded Spans in the order they were created.
+func (r *SpanRecorder) Spans() []Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Span, 0, len(r.spans))
+	for _, s := range r.spans {
+		s.mu.Lock()
+		out = append(out, Span{Name: s.name, Attrs: maps.Clone(s.attrs), Events: append([]Event(nil), s.events...), Error: s.err, Ended: s.ended})
+		s.mu.Unlock()
+	}
+	return out
+}
+
+func (r *SpanRecorder) newSpan(name string) *recSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &recSpan{rec: r, name: name}
+	r.spans = append(r.spans, s)
+	return s
+}
+
+// recSpan is a recording trace.Span.
+type recSpan struct {
+	noop.Span
+
+	rec  *SpanRecorder
+	name string
+
+	mu     sync.Mutex
+	attrs  map[string]string
+	events []Event
+	err    bool
+	ended  bool
+}
+
+func (s *recSpan) IsRecording() bool {
+	return true
+}
+
+// sampled is the SpanContext of every recSpan, so Request.Data is recorded as it is for exported spans.
+var sampled = trace.NewSpanContext(trace.SpanContextConfig{TraceFlags: trace.FlagsSampled})
+
+func (s *recSpan) SpanContext() trace.SpanContext {
+	return sampled
+}
+
+func (s *recSpan) AddEvent(name string, options ...trace.EventOption) {
+	c := trace.NewEventConfig(options...)
+	attrs := map[string]string{}
+	for _, a := range c.Attributes() {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, Event{Name: name, Attrs: attrs})
+}
+
+func (s *recSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = map[string]string{}
+	}
+	for _, a := range kv {
+		s.attrs[string(a.Key)] = a.Value.Emit()
+	}
+}
+
+func (s *recSpan) RecordError(err error, options ...trace.EventOption) {
+	s.AddEvent("error", append(options, trace.WithAttributes(attribute.String("error", err.Error())))...)
+}
+
+func (s *recSpan) SetStatus(code codes.Code, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = code == codes.Error
+}
+
+func (s *recSpan) End(...trace.SpanEndOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+func (s *recSpan) TracerProvider() trace.TracerProvider {
+	return recProvider{rec: s.rec}
+}
+
+type recProvider struct {
+	noop.TracerProvider
+	rec *SpanRecorder
+}
+
+func (p recProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recTracer{rec: p.rec}
+}
+
+type recTracer struct {
+	noop.Tracer
+	rec *SpanRecorder
+}
+
+func (t recTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	s := t.rec.newSpan(name)
+	return trace.ContextWithSpan(ctx, s), s
+}
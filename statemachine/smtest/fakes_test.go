@@ -0,0 +1,96 @@
+package smtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gostdlib/ops/statemachine"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestClock(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(begin)
+
+	timer := c.NewTimer(time.Second)
+	c.Advance(500 * time.Millisecond)
+	select {
+	case <-timer.C():
+		t.Fatalf("TestClock: timer fired before its time")
+	default:
+	}
+	c.Advance(500 * time.Millisecond)
+	select {
+	case got := <-timer.C():
+		if want := begin.Add(time.Second); !got.Equal(want) {
+			t.Errorf("TestClock: timer fired at %v, want %v", got, want)
+		}
+	default:
+		t.Fatalf("TestClock: timer did not fire")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Errorf("TestClock: Reset() of a fired timer: got true, want false")
+	}
+	if !timer.Stop() {
+		t.Errorf("TestClock: Stop() of an active timer: got false, want true")
+	}
+	c.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Errorf("TestClock: stopped timer fired")
+	default:
+	}
+
+	if got := c.Until(c.Now().Add(time.Minute)); got != time.Minute {
+		t.Errorf("TestClock: Until(): got %v, want %v", got, time.Minute)
+	}
+	if diff := pretty.Compare([]time.Duration{time.Second}, c.Timers()); diff != "" {
+		t.Errorf("TestClock: Timers(): -want/+got:\n%s", diff)
+	}
+}
+
+func TestClockAutoAdvance(t *testing.T) {
+	t.Parallel()
+
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewClock(begin)
+	c.SetAutoAdvance(true)
+
+	Call(t, statemachine.Wait(time.Hour, end), data{}, statemachine.WithClock[data](c)).WantErr(nil).WantNext(end)
+
+	if got, want := c.Now(), begin.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("TestClockAutoAdvance: got Now() == %v, want %v", got, want)
+	}
+}
+
+func TestSpanRecorder(t *testing.T) {
+	t.Parallel()
+
+	rec := NewSpanRecorder()
+	req := statemachine.Request[data]{Ctx: rec.Context(context.Background()), Next: start, Data: data{Num: -1}}
+	CallRequest(t, req).WantErr(errNeg)
+
+	spans := rec.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("TestSpanRecorder: got %d spans, want 2", len(spans))
+	}
+	if spans[0].Name != "root" || spans[0].Ended {
+		t.Errorf("TestSpanRecorder: got root span %+v, want unended span named root", spans[0])
+	}
+	s := spans[1]
+	if !s.Ended || !s.Error {
+		t.Errorf("TestSpanRecorder: got state span Ended == %v, Error == %v, want both true", s.Ended, s.Error)
+	}
+	name := statemachine.StateName(start)
+	if s.Name != "State("+name+")" {
+		t.Errorf("TestSpanRecorder: got state span name %s, want State(%s)", s.Name, name)
+	}
+	if len(s.Events) != 2 || s.Events[0].Attrs["start"] == "" || s.Events[1].Attrs["end"] == "" {
+		t.Errorf("TestSpanRecorder: got events %+v, want start and end events", s.Events)
+	}
+}
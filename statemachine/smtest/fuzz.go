@@ -0,0 +1,165 @@
+package smtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gostdlib/ops/statemachine"
+)
+
+// Runner runs a machine. *statemachine.Machine implements Runner, use Start() for a machine that is a
+// start State.
+type Runner[T any] interface {
+	Run(name string, req statemachine.Request[T], options ...statemachine.Option[T]) (statemachine.Request[T], error)
+}
+
+// startRunner is a Runner that starts at a State.
+type startRunner[T any] struct {
+	state statemachine.State[T]
+}
+
+// Start returns a Runner that runs the machine starting at state.
+func Start[T any](state statemachine.State[T]) Runner[T] {
+	return startRunner[T]{state: state}
+}
+
+// Run implements Runner.Run().
+func (s startRunner[T]) Run(name string, req statemachine.Request[T], options ...statemachine.Option[T]) (statemachine.Request[T], error) {
+	req.Next = s.state
+	return statemachine.Run(name, req, options...)
+}
+
+// CheckOption is an option for Check() and Property().
+type CheckOption[T any] func(*check[T])
+
+// check is the configuration of a Check() call.
+type check[T any] struct {
+	maxTransitions int
+	invariants     []invariant[T]
+	noErrors       bool
+	options        []statemachine.Option[T]
+}
+
+type invariant[T any] struct {
+	name string
+	f    func(data T) error
+}
+
+// MaxTransitions sets the number of States the machine must finish within. Defaults to 1000.
+func MaxTransitions[T any](n int) CheckOption[T] {
+	return func(c *check[T]) {
+		c.maxTransitions = n
+	}
+}
+
+// Invariant adds f, which must return nil for Request.Data after every State. name describes the invariant
+// in failures.
+func Invariant[T any](name string, f func(data T) error) CheckOption[T] {
+	return func(c *check[T]) {
+		c.invariants = append(c.invariants, invariant[T]{name: name, f: f})
+	}
+}
+
+// NoErrors makes the machine returning an error a failure. By default only not finishing, or breaking an
+// Invariant, are failures.
+func NoErrors[T any]() CheckOption[T] {
+	return func(c *check[T]) {
+		c.noErrors = true
+	}
+}
+
+// RunOptions sets Options passed to Run().
+func RunOptions[T any](options ...statemachine.Option[T]) CheckOption[T] {
+	return func(c *check[T]) {
+		c.options = append(c.options, options...)
+	}
+}
+
+// errInvariant stops a machine that broke an Invariant.
+var errInvariant = errors.New("invariant broken")
+
+/*
+Check runs the machine with data and fails t if the machine does not finish within MaxTransitions(), if
+Request.Data breaks an Invariant() after any State, or if the machine returns an error and NoErrors() is set.
+The Request the machine returned is returned.
+
+Check works with Go's native fuzzing, with the fuzz arguments used to build data:
+
+	func FuzzMachine(f *testing.F) {
+		f.Add(1, "vm")
+		f.Fuzz(func(t *testing.T, disks int, name string) {
+			smtest.Check(
+				t,
+				smtest.Start(Start),
+				Data{Disks: disks, Name: name},
+				smtest.MaxTransitions[Data](50),
+				smtest.Invariant("disks >= 0", func(d Data) error {
+					if d.Disks < 0 {
+						return fmt.Errorf("Disks == %d", d.Disks)
+					}
+					return nil
+				}),
+			)
+		})
+	}
+*/
+func Check[T any](t testing.TB, r Runner[T], data T, options ...CheckOption[T]) statemachine.Request[T] {
+	t.Helper()
+
+	c := &check[T]{maxTransitions: 1000}
+	for _, o := range options {
+		o(c)
+	}
+
+	var broken string
+	verify := func(stateName string, req statemachine.Request[T], next statemachine.State[T]) statemachine.Request[T] {
+		req = next(req)
+		for _, inv := range c.invariants {
+			if err := inv.f(req.Data); err != nil {
+				broken = fmt.Sprintf("state %s broke invariant %q: %s", stateName, inv.name, err)
+				req.Err = errInvariant
+				return req
+			}
+		}
+		return req
+	}
+
+	runOpts := append(
+		[]statemachine.Option[T]{
+			statemachine.WithMaxTransitions[T](c.maxTransitions),
+			statemachine.WithMiddleware(verify),
+		},
+		c.options...,
+	)
+	req := statemachine.Request[T]{Ctx: context.Background(), Data: data}
+	got, err := r.Run("smtest", req, runOpts...)
+	switch {
+	case err == nil:
+	case errors.Is(err, errInvariant):
+		t.Errorf("Data %+v: %s", data, broken)
+	case errors.Is(err, statemachine.ErrMaxTransitions):
+		t.Errorf("Data %+v: machine did not finish within %d transitions: %s", data, c.maxTransitions, err)
+	case c.noErrors:
+		t.Errorf("Data %+v: got err == %s, want err == nil", data, err)
+	}
+	return got
+}
+
+// Property runs Check() n times with Data created by gen. gen is passed a random source seeded with seed,
+// so a failure can be reproduced by using the same seed. The seed is logged if t fails.
+func Property[T any](t testing.TB, seed uint64, n int, gen func(r *rand.Rand) T, r Runner[T], options ...CheckOption[T]) {
+	t.Helper()
+
+	failed := t.Failed()
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	for i := 0; i < n; i++ {
+		Check(t, r, gen(rnd), options...)
+		if !failed && t.Failed() {
+			t.Logf("Property() failed on run %d with seed %d", i, seed)
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package smtest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/gostdlib/ops/statemachine"
+)
+
+// countdown decrements Data.Num until it is 0. It never finishes for a negative Num.
+func countdown(req statemachine.Request[data]) statemachine.Request[data] {
+	if req.Data.Num == 0 {
+		return req
+	}
+	req.Data.Num--
+	req.Next = countdown
+	return req
+}
+
+func nonNegative(d data) error {
+	if d.Num < 0 {
+		return fmt.Errorf("Num == %d", d.Num)
+	}
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	m, err := statemachine.NewBuilder[data]().
+		State("start", start).
+		State("end", end).
+		Start("start").
+		Transition("start", "end").
+		Terminal("end").
+		Build()
+	if err != nil {
+		t.Fatalf("TestCheck: Build(): got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		name     string
+		r        Runner[data]
+		num      int
+		options  []CheckOption[data]
+		wantErrs int
+	}{
+		{
+			name: "Finishes",
+			r:    Start(countdown),
+			num:  5,
+		},
+		{
+			name:    "Machine",
+			r:       m,
+			num:     1,
+			options: []CheckOption[data]{NoErrors[data]()},
+		},
+		{
+			name:     "Does not finish",
+			r:        Start(countdown),
+			num:      -1,
+			options:  []CheckOption[data]{MaxTransitions[data](10)},
+			wantErrs: 1,
+		},
+		{
+			name:     "Breaks invariant",
+			r:        Start(countdown),
+			num:      -1,
+			options:  []CheckOption[data]{Invariant("non-negative", nonNegative)},
+			wantErrs: 1,
+		},
+		{
+			name: "Error allowed",
+			r:    Start(start),
+			num:  -1,
+		},
+		{
+			name:     "Error not allowed",
+			r:        Start(start),
+			num:      -1,
+			options:  []CheckOption[data]{NoErrors[data]()},
+			wantErrs: 1,
+		},
+	}
+
+	for _, test := range tests {
+		ft := &fakeT{}
+		Check(ft, test.r, data{Num: test.num}, test.options...)
+		if len(ft.errs) != test.wantErrs {
+			t.Errorf("TestCheck(%s): got %d failures %v, want %d", test.name, len(ft.errs), ft.errs, test.wantErrs)
+		}
+	}
+}
+
+func TestCheckRunOptions(t *testing.T) {
+	t.Parallel()
+
+	errDenied := errors.New("denied")
+	deny := func(stateName string, req statemachine.Request[data], next statemachine.State[data]) statemachine.Request[data] {
+		req.Err = errDenied
+		return req
+	}
+
+	got := Check(t, Start(countdown), data{Num: 1}, RunOptions(statemachine.WithMiddleware(deny)))
+	if !errors.Is(got.Err, errDenied) {
+		t.Errorf("TestCheckRunOptions: got Err == %v, want %v", got.Err, errDenied)
+	}
+}
+
+func TestProperty(t *testing.T) {
+	t.Parallel()
+
+	gen := func(r *rand.Rand) data {
+		return data{Num: r.IntN(20) - 10}
+	}
+
+	ft := &fakeT{}
+	Property(ft, 1, 100, gen, Start(countdown), MaxTransitions[data](50))
+	if len(ft.errs) != 1 {
+		t.Errorf("TestProperty: got %d failures, want 1 as it stops on the first failure", len(ft.errs))
+	}
+
+	gen = func(r *rand.Rand) data {
+		return data{Num: r.IntN(10)}
+	}
+	Property(t, 1, 100, gen, Start(countdown), Invariant("non-negative", nonNegative))
+}
+
+func FuzzCountdown(f *testing.F) {
+	f.Add(uint8(0))
+	f.Add(uint8(200))
+	f.Fuzz(func(t *testing.T, n uint8) {
+		Check(t, Start(countdown), data{Num: int(n)}, MaxTransitions[data](300), Invariant("non-negative", nonNegative))
+	})
+}
@@ -0,0 +1,124 @@
+/*
+Package smtest provides helpers for testing a single statemachine.State.
+
+Call() executes a State, as statemachine.Run() would, and returns a *Got that has assertions for the
+State that is routed to next, the error and the changes made to Request.Data:
+
+	func TestSteer(t *testing.T) {
+		smtest.Call(t, Steer, Data{Num: 1}).
+			WantErr(nil).
+			WantNext(AddTen).
+			WantData(Data{Num: 1})
+	}
+
+A Clock is provided to fake time for States such as statemachine.Wait(), and a SpanRecorder to check
+the OTEL events a State records.
+
+Check() and Property() test a whole machine with many Data values, such as with Go's native fuzzing,
+asserting that it always finishes and that its Data stays valid.
+*/
+package smtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gostdlib/ops/statemachine"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// Got is the result of executing a State with Call() or CallRequest().
+type Got[T any] struct {
+	t testing.TB
+
+	// Before is the Request.Data that was passed to the State. If Data holds pointers, the values they
+	// point to may have been changed by the State.
+	Before T
+	// Request is the Request returned by the State.
+	Request statemachine.Request[T]
+	// Err is the error returned by statemachine.Step(). This is Request.Err, unless the Request or
+	// Options were invalid.
+	Err error
+}
+
+// Call executes state with a Request holding data and context.Background(). options are passed to
+// statemachine.Step().
+func Call[T any](t testing.TB, state statemachine.State[T], data T, options ...statemachine.Option[T]) *Got[T] {
+	t.Helper()
+
+	if state == nil {
+		t.Fatalf("smtest.Call(): state cannot be nil")
+	}
+	return CallRequest(t, statemachine.Request[T]{Ctx: context.Background(), Data: data, Next: state}, options...)
+}
+
+// CallRequest executes req.Next with req. This is used instead of Call() when the Request needs a
+// specific Context, such as one from SpanRecorder.Context(). options are passed to statemachine.Step().
+func CallRequest[T any](t testing.TB, req statemachine.Request[T], options ...statemachine.Option[T]) *Got[T] {
+	t.Helper()
+
+	g := &Got[T]{t: t, Before: req.Data}
+	g.Request, g.Err = statemachine.Step("smtest", req, options...)
+	return g
+}
+
+// WantNext asserts that the State routed to want. A nil want asserts that the State stopped the machine.
+// States are compared by statemachine.StateName().
+func (g *Got[T]) WantNext(want statemachine.State[T]) *Got[T] {
+	g.t.Helper()
+
+	switch {
+	case want == nil && g.Request.Next == nil:
+	case want == nil:
+		g.t.Errorf("Next: got %s, want nil", statemachine.StateName(g.Request.Next))
+	case g.Request.Next == nil:
+		g.t.Errorf("Next: got nil, want %s", statemachine.StateName(want))
+	default:
+		got, w := statemachine.StateName(g.Request.Next), statemachine.StateName(want)
+		if got != w {
+			g.t.Errorf("Next: got %s, want %s", got, w)
+		}
+	}
+	return g
+}
+
+// WantErr asserts that the error wraps want, using errors.Is(). A nil want asserts that there was no error.
+func (g *Got[T]) WantErr(want error) *Got[T] {
+	g.t.Helper()
+
+	switch {
+	case want == nil && g.Err != nil:
+		g.t.Errorf("Err: got %s, want nil", g.Err)
+	case !errors.Is(g.Err, want):
+		g.t.Errorf("Err: got %v, want %v", g.Err, want)
+	}
+	return g
+}
+
+// WantAnyErr asserts that there was an error.
+func (g *Got[T]) WantAnyErr() *Got[T] {
+	g.t.Helper()
+
+	if g.Err == nil {
+		g.t.Errorf("Err: got nil, want an error")
+	}
+	return g
+}
+
+// WantData asserts that Request.Data equals want, including unexported fields.
+func (g *Got[T]) WantData(want T) *Got[T] {
+	g.t.Helper()
+
+	if diff := pretty.Compare(want, g.Request.Data); diff != "" {
+		g.t.Errorf("Data: -want/+got:\n%s", diff)
+	}
+	return g
+}
+
+// DataChanges returns the changes the State made to Request.Data as a diff (-before/+after), or the empty
+// string if it made no changes.
+func (g *Got[T]) DataChanges() string {
+	return pretty.Compare(g.Before, g.Request.Data)
+}
@@ -0,0 +1,163 @@
+package smtest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gostdlib/ops/statemachine"
+)
+
+type data struct {
+	Num int
+}
+
+var errNeg = errors.New("negative")
+
+func start(req statemachine.Request[data]) statemachine.Request[data] {
+	switch {
+	case req.Data.Num < 0:
+		req.Err = fmt.Errorf("start: %w", errNeg)
+	case req.Data.Num == 0:
+		req.Next = nil
+	default:
+		req.Data.Num++
+		req.Next = end
+	}
+	return req
+}
+
+func end(req statemachine.Request[data]) statemachine.Request[data] {
+	req.Next = nil
+	return req
+}
+
+// fakeT records failures instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errs []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatalf(format string, args ...any) {
+	f.errs = append(f.errs, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Failed() bool {
+	return len(f.errs) > 0
+}
+
+func (f *fakeT) Logf(format string, args ...any) {}
+
+func TestCall(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		num      int
+		check    func(g *Got[data])
+		wantErrs int
+	}{
+		{
+			name: "Success",
+			num:  1,
+			check: func(g *Got[data]) {
+				g.WantErr(nil).WantNext(end).WantData(data{Num: 2})
+			},
+		},
+		{
+			name: "Stop",
+			num:  0,
+			check: func(g *Got[data]) {
+				g.WantErr(nil).WantNext(nil).WantData(data{})
+			},
+		},
+		{
+			name: "Error",
+			num:  -1,
+			check: func(g *Got[data]) {
+				g.WantErr(errNeg).WantAnyErr()
+			},
+		},
+		{
+			name: "Wrong next",
+			num:  1,
+			check: func(g *Got[data]) {
+				g.WantNext(start).WantNext(nil)
+			},
+			wantErrs: 2,
+		},
+		{
+			name: "Unexpected nil next",
+			num:  0,
+			check: func(g *Got[data]) {
+				g.WantNext(end)
+			},
+			wantErrs: 1,
+		},
+		{
+			name: "Wrong error",
+			num:  -1,
+			check: func(g *Got[data]) {
+				g.WantErr(nil).WantErr(errors.New("other"))
+			},
+			wantErrs: 2,
+		},
+		{
+			name: "Missing error",
+			num:  1,
+			check: func(g *Got[data]) {
+				g.WantErr(errNeg).WantAnyErr()
+			},
+			wantErrs: 2,
+		},
+		{
+			name: "Wrong data",
+			num:  1,
+			check: func(g *Got[data]) {
+				g.WantData(data{Num: 1})
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, test := range tests {
+		ft := &fakeT{}
+		test.check(Call(ft, start, data{Num: test.num}))
+		if len(ft.errs) != test.wantErrs {
+			t.Errorf("TestCall(%s): got %d failures %v, want %d", test.name, len(ft.errs), ft.errs, test.wantErrs)
+		}
+	}
+}
+
+func TestDataChanges(t *testing.T) {
+	t.Parallel()
+
+	g := Call(t, start, data{Num: 1})
+	if g.Before.Num != 1 {
+		t.Errorf("TestDataChanges: got Before.Num == %d, want 1", g.Before.Num)
+	}
+	if g.DataChanges() == "" {
+		t.Errorf("TestDataChanges: got no changes, want changes")
+	}
+	if diff := Call(t, end, data{Num: 1}).DataChanges(); diff != "" {
+		t.Errorf("TestDataChanges: got changes %s, want none", diff)
+	}
+}
+
+func TestCallOptions(t *testing.T) {
+	t.Parallel()
+
+	errDenied := errors.New("denied")
+	deny := func(stateName string, req statemachine.Request[data], next statemachine.State[data]) statemachine.Request[data] {
+		req.Err = errDenied
+		return req
+	}
+
+	Call(t, start, data{Num: 1}, statemachine.WithMiddleware(deny)).WantErr(errDenied).WantData(data{Num: 1})
+}
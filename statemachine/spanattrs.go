@@ -0,0 +1,54 @@
+package statemachine
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithSpanAttributes sets attrs on the OTEL span of the machine and the span of every State, such as a
+// tenant or request ID. This allows the spans of a machine to be filtered by business dimensions in a
+// tracing backend. WithSpanAttributes can be passed multiple times, each call adds to the attributes.
+func WithSpanAttributes[T any](attrs ...attribute.KeyValue) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		for _, a := range attrs {
+			if !a.Valid() {
+				return req, errors.New("WithSpanAttributes() cannot be passed an invalid attribute")
+			}
+		}
+		req.conf.spanAttrs = append(req.conf.spanAttrs, attrs...)
+		return req, nil
+	}
+}
+
+// WithBaggage sets the OTEL baggage members in Request.Ctx passed to Run() as attributes on the OTEL span
+// of the machine and the span of every State. The attribute key is the member key. If keys are passed,
+// only those members are set. Baggage is propagated to other services, but tracing backends only index
+// span attributes, so this allows spans to be filtered by values set by a caller, such as a tenant.
+func WithBaggage[T any](keys ...string) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		b := baggage.FromContext(req.Ctx)
+		if len(keys) == 0 {
+			for _, m := range b.Members() {
+				req.conf.spanAttrs = append(req.conf.spanAttrs, attribute.String(m.Key(), m.Value()))
+			}
+			return req, nil
+		}
+		for _, k := range keys {
+			if m := b.Member(k); m.Key() != "" {
+				req.conf.spanAttrs = append(req.conf.spanAttrs, attribute.String(k, m.Value()))
+			}
+		}
+		return req, nil
+	}
+}
+
+// setSpanAttributes sets the attributes from WithSpanAttributes() and WithBaggage() on sp. c may be nil.
+func (c *runConfig[T]) setSpanAttributes(sp trace.Span) {
+	if c == nil || len(c.spanAttrs) == 0 {
+		return
+	}
+	sp.SetAttributes(c.spanAttrs...)
+}
@@ -0,0 +1,70 @@
+package statemachine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestSpanAttributes(t *testing.T) {
+	t.Parallel()
+
+	tenant, _ := baggage.NewMember("tenant", "acme")
+	region, _ := baggage.NewMember("region", "west")
+	bag, _ := baggage.New(tenant, region)
+
+	tests := []struct {
+		name    string
+		options []Option[data]
+		want    map[string]string
+	}{
+		{
+			name: "No attributes",
+		},
+		{
+			name:    "WithSpanAttributes",
+			options: []Option[data]{WithSpanAttributes[data](attribute.String("request", "1"))},
+			want:    map[string]string{"request": "1"},
+		},
+		{
+			name:    "WithBaggage all members",
+			options: []Option[data]{WithBaggage[data]()},
+			want:    map[string]string{"tenant": "acme", "region": "west"},
+		},
+		{
+			name: "WithBaggage keys and WithSpanAttributes",
+			options: []Option[data]{
+				WithBaggage[data]("tenant", "missing"),
+				WithSpanAttributes[data](attribute.Int("shard", 2)),
+			},
+			want: map[string]string{"tenant": "acme", "shard": "2"},
+		},
+	}
+
+	for _, test := range tests {
+		rec, ctx := newFakeRecorder()
+		ctx = baggage.ContextWithBaggage(ctx, bag)
+		req := Request[data]{Ctx: ctx, Next: steer, Data: data{Num: 1}}
+		if _, err := Run("test", req, test.options...); err != nil {
+			t.Fatalf("TestSpanAttributes(%s): got err == %s, want err == nil", test.name, err)
+		}
+
+		for _, name := range []string{"statemachine(test)", "State(" + StateName(steer) + ")", "State(" + StateName(addTen) + ")"} {
+			sp := rec.span(name)
+			if sp == nil {
+				t.Fatalf("TestSpanAttributes(%s): no span %s", test.name, name)
+			}
+			if diff := pretty.Compare(test.want, sp.attrs); diff != "" {
+				t.Errorf("TestSpanAttributes(%s): span %s: -want/+got:\n%s", test.name, name, diff)
+			}
+		}
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: steer}
+	if _, err := Run("test", req, WithSpanAttributes[data](attribute.KeyValue{})); err == nil {
+		t.Errorf("TestSpanAttributes: invalid attribute: got err == nil, want err != nil")
+	}
+}
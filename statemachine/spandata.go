@@ -0,0 +1,208 @@
+package statemachine
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithSpanDataFunc sets f to convert Request.Data to the value that is JSON encoded into the "data"
+// attribute of OTEL span events. This controls exactly what is recorded, such as removing secrets or
+// large payloads from Data. If f returns nil, "null" is recorded.
+//
+// By default, Request.Data is recorded with the value of any struct field tagged with `redact:"true"`
+// replaced with "[REDACTED]":
+//
+//	type Data struct {
+//		User     string
+//		Password string `redact:"true"`
+//	}
+func WithSpanDataFunc[T any](f func(T) any) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if f == nil {
+			return req, errors.New("WithSpanDataFunc() cannot be passed a nil func")
+		}
+		req.conf.spanData = f
+		return req, nil
+	}
+}
+
+// WithSpanDataSampling records Request.Data in OTEL span events in only rate of the runs, where rate is
+// > 0 and <= 1. Marshaling large Data at the start and end of the machine and around every State can be
+// expensive. Runs that are not chosen record span events without Data. This can be combined with
+// WithSpanDataOnError() to also record Data for every run that fails.
+//
+// Regardless of these options, Data is never marshaled for a span that is not sampled, as it will not be exported.
+func WithSpanDataSampling[T any](rate float64) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if rate <= 0 || rate > 1 {
+			return req, fmt.Errorf("WithSpanDataSampling(%v) must be > 0 and <= 1", rate)
+		}
+		req.conf.dataRate = rate
+		return req, nil
+	}
+}
+
+// WithSpanDataOnError records Request.Data in OTEL span events only if the run fails. Data is recorded
+// once, in the span event for the end of the machine, so the span events for the start of the machine and
+// for each State do not have Data. With WithSpanDataSampling(), runs that are chosen by the sample rate
+// record Data as normal.
+func WithSpanDataOnError[T any]() Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		req.conf.dataOnError = true
+		return req, nil
+	}
+}
+
+// dataMode is when Request.Data is recorded in span events for a run.
+type dataMode uint8
+
+const (
+	// dataAlways records Request.Data in every span event that has it.
+	dataAlways dataMode = iota
+	// dataNever does not record Request.Data.
+	dataNever
+	// dataOnError records Request.Data at the end of the machine if it failed.
+	dataOnError
+)
+
+// dataMode returns when Request.Data is recorded in span events for a run recorded in sp. c may be nil.
+func (c *runConfig[T]) dataMode(sp trace.Span) dataMode {
+	switch {
+	case !sp.SpanContext().IsSampled():
+		return dataNever
+	case c == nil:
+		return dataAlways
+	case c.dataRate > 0 && rand.Float64() < c.dataRate:
+		return dataAlways
+	case c.dataOnError:
+		return dataOnError
+	case c.dataRate > 0:
+		return dataNever
+	}
+	return dataAlways
+}
+
+// redacted replaces the value of fields tagged with `redact:"true"`.
+const redacted = "[REDACTED]"
+
+var (
+	jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// hasRedactCache caches hasRedact() by reflect.Type.
+var hasRedactCache sync.Map
+
+// redact returns v with the value of struct fields tagged `redact:"true"` replaced with "[REDACTED]".
+// If v's type has no such fields, v is returned. Otherwise structs that contain redacted fields, directly
+// or through pointers, slices, arrays and maps, are converted to a map[string]any keyed by the JSON field name.
+func redact(v any) any {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !hasRedact(rv.Type()) {
+		return v
+	}
+	return redactValue(rv)
+}
+
+// redactValue implements redact() for a value whose type has redacted fields.
+func redactValue(v reflect.Value) any {
+	if !hasRedact(v.Type()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redactValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = redactValue(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		out := map[string]any{}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			switch name {
+			case "-":
+				continue
+			case "":
+				name = f.Name
+			}
+			if f.Tag.Get("redact") == "true" {
+				out[name] = redacted
+				continue
+			}
+			out[name] = redactValue(v.Field(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// hasRedact reports if t has a struct field tagged `redact:"true"` that is reachable through
+// struct fields, pointers, slices, arrays and maps. Types that implement json.Marshaler or
+// encoding.TextMarshaler encode themselves, so are not searched.
+func hasRedact(t reflect.Type) bool {
+	if v, ok := hasRedactCache.Load(t); ok {
+		return v.(bool)
+	}
+	has := searchRedact(t, map[reflect.Type]bool{})
+	hasRedactCache.Store(t, has)
+	return has
+}
+
+// searchRedact implements hasRedact() without the cache. visiting holds the types being searched,
+// which stops recursive types from looping.
+func searchRedact(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if visiting[t] || t.Implements(jsonMarshaler) || t.Implements(textMarshaler) {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return searchRedact(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if f.Tag.Get("redact") == "true" || searchRedact(f.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
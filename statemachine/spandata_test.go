@@ -0,0 +1,378 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// fakeRecorder records the fakeSpans created from it.
+type fakeRecorder struct {
+	// unsampled makes spans that are recording but not sampled, so they would not be exported.
+	unsampled bool
+
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+// newFakeRecorder returns a fakeRecorder and a Context holding a recording root span.
+func newFakeRecorder() (*fakeRecorder, context.Context) {
+	rec := &fakeRecorder{}
+	return rec, trace.ContextWithSpan(context.Background(), rec.newSpan("root"))
+}
+
+func (r *fakeRecorder) newSpan(name string) *fakeSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &fakeSpan{rec: r, name: name}
+	r.spans = append(r.spans, s)
+	return s
+}
+
+// span returns the span called name, or nil if it does not exist.
+func (r *fakeRecorder) span(name string) *fakeSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.spans {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// fakeEvent is an event recorded on a fakeSpan.
+type fakeEvent struct {
+	name  string
+	attrs map[string]string
+}
+
+// fakeSpan is a recording trace.Span that records what is done to it.
+type fakeSpan struct {
+	noop.Span
+
+	rec  *fakeRecorder
+	name string
+
+	mu     sync.Mutex
+	attrs  map[string]string
+	events []fakeEvent
+	status codes.Code
+	ended  bool
+}
+
+func (s *fakeSpan) IsRecording() bool {
+	return true
+}
+
+func (s *fakeSpan) SpanContext() trace.SpanContext {
+	if s.rec.unsampled {
+		return trace.SpanContext{}
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{TraceFlags: trace.FlagsSampled})
+}
+
+func (s *fakeSpan) AddEvent(name string, options ...trace.EventOption) {
+	c := trace.NewEventConfig(options...)
+	attrs := map[string]string{}
+	for _, a := range c.Attributes() {
+		attrs[string(a.Key)] = a.Value.Emit()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, fakeEvent{name: name, attrs: attrs})
+}
+
+func (s *fakeSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = map[string]string{}
+	}
+	for _, a := range kv {
+		s.attrs[string(a.Key)] = a.Value.Emit()
+	}
+}
+
+func (s *fakeSpan) RecordError(err error, options ...trace.EventOption) {
+	s.AddEvent("error: "+err.Error(), options...)
+}
+
+func (s *fakeSpan) SetStatus(code codes.Code, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+func (s *fakeSpan) End(...trace.SpanEndOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = true
+}
+
+func (s *fakeSpan) TracerProvider() trace.TracerProvider {
+	return fakeProvider{rec: s.rec}
+}
+
+// event returns the first event called name, or nil if it does not exist.
+func (s *fakeSpan) event(name string) *fakeEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.events {
+		if s.events[i].name == name {
+			return &s.events[i]
+		}
+	}
+	return nil
+}
+
+type fakeProvider struct {
+	noop.TracerProvider
+	rec *fakeRecorder
+}
+
+func (p fakeProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return fakeTracer{rec: p.rec}
+}
+
+type fakeTracer struct {
+	noop.Tracer
+	rec *fakeRecorder
+}
+
+func (t fakeTracer) Start(ctx context.Context, name string, _ ...trace.SpanStartOption) (context.Context, trace.Span) {
+	s := t.rec.newSpan(name)
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// secretData is Data with a field that must be redacted.
+type secretData struct {
+	User     string
+	Password string `redact:"true"`
+	Num      int    `json:"num"`
+}
+
+func secretState(req Request[secretData]) Request[secretData] {
+	req.Data.Num++
+	return req
+}
+
+func TestSpanData(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []Option[secretData]
+		want    string
+	}{
+		{
+			name: "Redacted by default",
+			want: `{"Password":"[REDACTED]","User":"user","num":1}`,
+		},
+		{
+			name: "WithSpanDataFunc",
+			options: []Option[secretData]{
+				WithSpanDataFunc(func(d secretData) any { return d.User }),
+			},
+			want: `"user"`,
+		},
+	}
+
+	for _, test := range tests {
+		rec, ctx := newFakeRecorder()
+		req := Request[secretData]{Ctx: ctx, Next: secretState, Data: secretData{User: "user", Password: "secret"}}
+		got, err := Run("test", req, test.options...)
+		if err != nil {
+			t.Fatalf("TestSpanData(%s): got err == %s, want err == nil", test.name, err)
+		}
+		if got.Ctx != ctx {
+			t.Errorf("TestSpanData(%s): Request.Ctx was not restored", test.name)
+		}
+
+		run := rec.span("statemachine(test)")
+		if run == nil {
+			t.Fatalf("TestSpanData(%s): no span for the run", test.name)
+		}
+		if !run.ended {
+			t.Errorf("TestSpanData(%s): run span was not ended", test.name)
+		}
+		end := run.event("statemachine processing end")
+		if end == nil {
+			t.Fatalf("TestSpanData(%s): no end event", test.name)
+		}
+		if end.attrs["data"] != test.want {
+			t.Errorf("TestSpanData(%s): got data %s, want %s", test.name, end.attrs["data"], test.want)
+		}
+
+		st := rec.span("State(" + StateName(secretState) + ")")
+		if st == nil || !st.ended {
+			t.Errorf("TestSpanData(%s): state span missing or not ended", test.name)
+		}
+	}
+}
+
+func secretErr(req Request[secretData]) Request[secretData] {
+	req.Err = errors.New("secretErr")
+	return req
+}
+
+func TestSpanDataSampling(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		options   []Option[secretData]
+		unsampled bool
+		state     State[secretData]
+		wantStart bool
+		wantEnd   bool
+		wantDiff  bool
+	}{
+		{
+			name:      "Default records data",
+			state:     secretState,
+			wantStart: true,
+			wantEnd:   true,
+			wantDiff:  true,
+		},
+		{
+			name:      "Span not sampled",
+			unsampled: true,
+			state:     secretState,
+		},
+		{
+			name:      "Sampling rate of 1",
+			options:   []Option[secretData]{WithSpanDataSampling[secretData](1)},
+			state:     secretState,
+			wantStart: true,
+			wantEnd:   true,
+			wantDiff:  true,
+		},
+		{
+			name:    "OnError with success",
+			options: []Option[secretData]{WithSpanDataOnError[secretData]()},
+			state:   secretState,
+		},
+		{
+			name:    "OnError with error",
+			options: []Option[secretData]{WithSpanDataOnError[secretData]()},
+			state:   secretErr,
+			wantEnd: true,
+		},
+	}
+
+	for _, test := range tests {
+		rec, ctx := newFakeRecorder()
+		rec.unsampled = test.unsampled
+		req := Request[secretData]{Ctx: ctx, Next: test.state, Data: secretData{User: "user", Password: "secret"}}
+		Run("test", req, test.options...)
+
+		run := rec.span("statemachine(test)")
+		if run == nil {
+			t.Fatalf("TestSpanDataSampling(%s): no span for the run", test.name)
+		}
+		_, gotStart := run.event("statemachine processing start").attrs["data"]
+		if gotStart != test.wantStart {
+			t.Errorf("TestSpanDataSampling(%s): start event has data: got %v, want %v", test.name, gotStart, test.wantStart)
+		}
+		_, gotEnd := run.event("statemachine processing end").attrs["data"]
+		if gotEnd != test.wantEnd {
+			t.Errorf("TestSpanDataSampling(%s): end event has data: got %v, want %v", test.name, gotEnd, test.wantEnd)
+		}
+
+		stateName := StateName(test.state)
+		st := rec.span("State(" + stateName + ")")
+		if st == nil {
+			t.Fatalf("TestSpanDataSampling(%s): no span for the state", test.name)
+		}
+		gotDiff := false
+		for _, e := range st.events {
+			if _, ok := e.attrs["data_diff"]; ok {
+				gotDiff = true
+			}
+		}
+		if gotDiff != test.wantDiff {
+			t.Errorf("TestSpanDataSampling(%s): state has data_diff: got %v, want %v", test.name, gotDiff, test.wantDiff)
+		}
+	}
+
+	for _, rate := range []float64{0, -1, 1.5} {
+		req := Request[secretData]{Ctx: context.Background(), Next: secretState}
+		if _, err := Run("test", req, WithSpanDataSampling[secretData](rate)); err == nil {
+			t.Errorf("TestSpanDataSampling: WithSpanDataSampling(%v): got err == nil, want err != nil", rate)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	type inner struct {
+		Token string `redact:"true"`
+		Keep  string
+	}
+	type outer struct {
+		Name   string
+		Inner  *inner
+		List   []inner
+		ByName map[string]inner
+		Skip   string `json:"-"`
+		hidden string
+	}
+	type plain struct {
+		Name string
+	}
+
+	tests := []struct {
+		name string
+		v    any
+		want any
+	}{
+		{name: "nil", v: nil, want: nil},
+		{name: "No redaction", v: plain{Name: "a"}, want: plain{Name: "a"}},
+		{
+			name: "Nested",
+			v: outer{
+				Name:   "a",
+				Inner:  &inner{Token: "t", Keep: "k"},
+				List:   []inner{{Token: "t", Keep: "k"}},
+				ByName: map[string]inner{"x": {Token: "t", Keep: "k"}},
+				Skip:   "s",
+				hidden: "h",
+			},
+			want: map[string]any{
+				"Name":   "a",
+				"Inner":  map[string]any{"Token": redacted, "Keep": "k"},
+				"List":   []any{map[string]any{"Token": redacted, "Keep": "k"}},
+				"ByName": map[string]any{"x": map[string]any{"Token": redacted, "Keep": "k"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := redact(test.v)
+		if diff := pretty.Compare(test.want, got); diff != "" {
+			t.Errorf("TestRedact(%s): -want/+got:\n%s", test.name, diff)
+		}
+		b, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("TestRedact(%s): json.Marshal(): got err == %s", test.name, err)
+		}
+		if strings.Contains(string(b), `"t"`) {
+			t.Errorf("TestRedact(%s): secret was not redacted: %s", test.name, b)
+		}
+	}
+}
@@ -151,13 +151,17 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 	"unsafe"
 
 	"github.com/gostdlib/internals/otel/span"
+	"github.com/gostdlib/ops/retry/exponential"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // State is a function that takes a Request and returns a Request. If the returned Request has a nil Next, the state machine stops.
@@ -178,18 +182,18 @@ var seenStagesPool = sync.Pool{
 // lookup performance is negligible. This is not thread-safe (which is not needed).
 type seenStages []string
 
-// seen returns true if the stage has been seen before. If it has not been seen,
-// it adds it to the list of seen stages.
-func (s *seenStages) seen(stage string) bool {
+// visit adds stage to the list of seen stages and returns the number of times it
+// had been seen before.
+func (s *seenStages) visit(stage string) int {
+	n := 0
 	for _, st := range *s {
 		if st == stage {
-			return true
+			n++
 		}
 	}
 
-	n := append(*s, stage)
-	*s = n
-	return false
+	*s = append(*s, stage)
+	return n
 }
 
 // callTrace returns a string of the stages that have been called.
@@ -206,8 +210,7 @@ func (s *seenStages) callTrace() string {
 
 // reset resets the seenStages object to be reused.
 func (s *seenStages) reset() *seenStages {
-	n := (*s)[:0]
-	s = &n
+	*s = (*s)[:0]
 	return s
 }
 
@@ -230,9 +233,28 @@ type Request[T any] struct {
 	// Must be set to the initial state to execute before calling Run().
 	Next State[T]
 
+	// Status is the outcome of the machine. A State may set it, such as to Skipped, and Run() sets it
+	// when the machine stops. See Status for details.
+	Status Status
+
 	// seenStages tracks what stages have been called in this Request. This is used to
 	// detect cyclic errors. If nil, cyclic errors are not checked.
 	seenStages *seenStages
+
+	// machine is the name of the machine passed to Run().
+	machine string
+
+	// dataMode is when Request.Data is recorded in span events for this run.
+	dataMode dataMode
+
+	// conf is the configuration set by Options passed to Run(). If nil, no Options were passed.
+	conf *runConfig[T]
+
+	// deferred are the finalizer states to run when the machine stops, in the order they were added.
+	deferred []State[T]
+
+	// compensations are the Compensations to run if the machine fails, in the order they were added.
+	compensations []Compensation[T]
 }
 
 func (r Request[T]) otelStart() Request[T] {
@@ -240,17 +262,12 @@ func (r Request[T]) otelStart() Request[T] {
 		return r
 	}
 
-	j, err := json.Marshal(r.Data)
-	if err != nil {
-		j = []byte(fmt.Sprintf("Error marshaling data: %s", err.Error()))
-	}
-
 	r.startTime = time.Now()
-	r.span.Event(
-		"statemachine processing start",
-		"data", *(*string)(unsafe.Pointer(&j)),
-		"start", r.startTime,
-	)
+	attrs := []attribute.KeyValue{attribute.String("start", r.startTime.Format(time.RFC3339Nano))}
+	if r.dataMode == dataAlways {
+		attrs = append(attrs, attribute.String("data", r.spanData()))
+	}
+	r.span.Span.AddEvent("statemachine processing start", trace.WithAttributes(attrs...))
 	return r
 }
 
@@ -276,6 +293,15 @@ func (r Request[T]) Event(name string, keyValues ...any) error {
 	return r.span.Event(name, keyValues...)
 }
 
+// addEvent records an OTEL event with attrs into the Request span. This is a no-op if the Request
+// is not recording.
+func (r Request[T]) addEvent(name string, attrs ...attribute.KeyValue) {
+	if r.span.Span == nil || !r.span.Span.IsRecording() {
+		return
+	}
+	r.span.Span.AddEvent(name, trace.WithAttributes(attrs...))
+}
+
 func (r Request[T]) otelEnd() {
 	if r.span.Span == nil || !r.span.Span.IsRecording() {
 		return
@@ -283,24 +309,103 @@ func (r Request[T]) otelEnd() {
 	if r.Err != nil {
 		r.span.Status(codes.Error, r.Err.Error())
 	}
-	j, err := json.Marshal(r.Data)
+	end := time.Now()
+	attrs := []attribute.KeyValue{
+		attribute.String("end", end.Format(time.RFC3339Nano)),
+		attribute.Int64("elapsed_ns", int64(end.Sub(r.startTime))),
+	}
+	if r.dataMode == dataAlways || (r.dataMode == dataOnError && r.Err != nil) {
+		attrs = append(attrs, attribute.String("data", r.spanData()))
+	}
+	r.span.Span.AddEvent("statemachine processing end", trace.WithAttributes(attrs...))
+	r.span.End()
+}
+
+// spanData returns Request.Data as recorded in span events. This is the JSON encoding of the value from
+// the WithSpanDataFunc() function or, if not set, of Request.Data with fields tagged `redact:"true"` redacted.
+func (r Request[T]) spanData() string {
+	var v any
+	if r.conf != nil && r.conf.spanData != nil {
+		v = r.conf.spanData(r.Data)
+	} else {
+		v = redact(r.Data)
+	}
+
+	j, err := json.Marshal(v)
 	if err != nil {
 		j = []byte(fmt.Sprintf("Error marshaling data: %s", err.Error()))
 	}
-	end := time.Now()
-	r.span.Event(
-		"statemachine processing end",
-		"data", *(*string)(unsafe.Pointer(&j)),
-		"end", end,
-		"elapsed_ns", end.Sub(r.startTime),
-	)
-	r.span.End()
+	return *(*string)(unsafe.Pointer(&j))
 }
 
 // Option is an option for the Run() function.
-// This is currently unused, but exists for future expansion.
 type Option[T any] func(Request[T]) (Request[T], error)
 
+// runConfig holds the configuration set by Options for a single Run() call.
+type runConfig[T any] struct {
+	// name is the name of the machine passed to Run().
+	name string
+	// middleware wraps the execution of every State, in the order they were added.
+	middleware []Middleware[T]
+	// observers observe the machine, in the order they were added.
+	observers []Observer[T]
+	// cycles indicates that cyclic state detection is on.
+	cycles bool
+	// revisits is the number of times a state may be revisited when cycles is set.
+	revisits int
+	// maxTransitions is the maximum number of states that may be executed. 0 is unlimited.
+	maxTransitions int
+	// stateTimeout is the maximum time a state may execute for. 0 is unlimited.
+	stateTimeout time.Duration
+	// stateTimeouts are timeouts for specific states by name that override stateTimeout.
+	stateTimeouts map[string]time.Duration
+	// clock is the Clock set with WithClock(). If nil, the time package is used.
+	clock exponential.Clock
+	// recoverPanics indicates that panics in states are converted to errors.
+	recoverPanics bool
+	// names are custom state names set with WithStateName(), keyed by the name from methodName().
+	names map[string]string
+	// spanData converts Request.Data to the value recorded in span events. If nil, redact() is used.
+	spanData func(T) any
+	// dataRate is the fraction of runs that record Request.Data in span events. 0 records it in every run.
+	dataRate float64
+	// dataOnError indicates that Request.Data is recorded in span events when the run fails.
+	dataOnError bool
+	// spanAttrs are set on the span of the machine and of every state.
+	spanAttrs []attribute.KeyValue
+	// ctxCheck indicates that Request.Ctx is checked before each state is executed.
+	ctxCheck bool
+	// runTimeout is the maximum time the machine may run for. 0 is unlimited.
+	runTimeout time.Duration
+	// control pauses and stops the machine when it is run with RunAsync().
+	control *control
+	// setups are called with the Request before the first state, in the order they were added.
+	setups []func(Request[T]) (Request[T], error)
+	// branch indicates the machine is a branch of a Parallel() State. If it succeeds, its Compensations
+	// and finalizers are returned in the Request for the parent machine to run.
+	branch bool
+}
+
+// reset resets c to the zero value with name set, keeping allocated memory for reuse.
+func (c *runConfig[T]) reset(name string) *runConfig[T] {
+	clear(c.stateTimeouts)
+	clear(c.names)
+	clear(c.middleware)
+	clear(c.observers)
+	clear(c.setups)
+	clear(c.spanAttrs)
+	*c = runConfig[T]{
+		name:          name,
+		middleware:    c.middleware[:0],
+		observers:     c.observers[:0],
+		setups:        c.setups[:0],
+		spanAttrs:     c.spanAttrs[:0],
+		stateTimeouts: c.stateTimeouts,
+		names:         c.names,
+	}
+	return c
+}
+
 var (
 	nameEmptyErr = fmt.Errorf("name is empty")
 	ctxNilErr    = fmt.Errorf("Request.Ctx is nil")
@@ -312,6 +417,100 @@ var (
 // purpose of OTEL tracing. An error is returned if the state machine fails, name
 // is empty, the Request Ctx/Next is nil or the Err field is not nil.
 func Run[T any](name string, req Request[T], options ...Option[T]) (Request[T], error) {
+	return run(name, req, options, nil)
+}
+
+// run implements Run(). If conf is not nil, it is reset and used to hold the configuration instead of
+// allocating a new one. This lets a Pool reuse a runConfig for every Run() a worker executes.
+func run[T any](name string, req Request[T], options []Option[T], conf *runConfig[T]) (Request[T], error) {
+	req, err := prepare(name, req, options, conf)
+	if err != nil {
+		return req, err
+	}
+
+	start := time.Now()
+	parentCtx := req.Ctx
+	if span.Get(req.Ctx).Span.IsRecording() {
+		req.Ctx, req.span = span.New(req.Ctx, fmt.Sprintf("statemachine(%s)", name))
+		req.dataMode = req.conf.dataMode(req.span.Span)
+		req.conf.setSpanAttributes(req.span.Span)
+		req = req.otelStart()
+	}
+
+	if req.conf != nil && req.conf.cycles {
+		ss := seenStagesPool.Get().(*seenStages)
+		defer seenStagesPool.Put(ss)
+		req.seenStages = ss.reset()
+	}
+
+	machineCtx := req.Ctx
+	runCtx, cancel := req.conf.runContext(req.Ctx)
+	defer cancel()
+	req.Ctx = runCtx
+	req.conf.observeStart(req)
+
+	var stateName string
+	var recent recentStates
+	for transitions := 0; req.Next != nil; transitions++ {
+		if err := req.conf.checkTransitions(transitions, &recent); err != nil {
+			req.Err = err
+			break
+		}
+		if err := req.conf.checkContext(req.Ctx, stateName, req.Next); err != nil {
+			req.Err = err
+			break
+		}
+		if err := req.conf.checkControl(req.Ctx, req.Next); err != nil {
+			req.Err = err
+			break
+		}
+		state := req.Next
+		if req.conf == nil && req.span.Span == nil {
+			stateName, req = execUnnamed(req)
+		} else {
+			stateName, req = execState(req)
+		}
+		recent.add(stateName, state)
+		req.Err = req.conf.checkRunTimeout(runCtx, stateName, req.Err)
+		if req.Err != nil {
+			break
+		}
+	}
+	if runCtx != machineCtx {
+		req.Ctx = machineCtx
+	}
+	var callTrace []string
+	if req.Err != nil {
+		callTrace = recent.slice()
+		if req.seenStages != nil {
+			callTrace = slices.Clone(*req.seenStages)
+		}
+	}
+	req.seenStages = nil
+	var failed Status
+	if req.Err != nil || req.conf == nil || !req.conf.branch {
+		req, failed = runCompensations(req)
+		req = runFinalizers(req)
+	}
+	req.Status = finalStatus(req.Status, failed, req.Err)
+	elapsed := time.Since(start)
+	req.Err = newError(name, stateName, callTrace, elapsed, req.Err)
+	req.conf.observeEnd(req, elapsed)
+
+	if req.span.Span != nil {
+		if req.Err != nil {
+			req.span.Span.RecordError(req.Err, trace.WithAttributes(attribute.String("state", stateName)))
+		}
+		req.otelEnd()
+		req.Ctx, req.span, req.dataMode = parentCtx, span.Span{}, dataAlways
+	}
+	req.machine = ""
+	return req, req.Err
+}
+
+// prepare validates the arguments to Run() and applies options to req. If conf is not nil, it is reset
+// and used instead of allocating a new runConfig.
+func prepare[T any](name string, req Request[T], options []Option[T], conf *runConfig[T]) (Request[T], error) {
 	if strings.TrimSpace(name) == "" {
 		req.Next = nil
 		return req, nameEmptyErr
@@ -329,6 +528,16 @@ func Run[T any](name string, req Request[T], options ...Option[T]) (Request[T],
 		return req, reqErrNotNil
 	}
 
+	req.machine = name
+	req.conf = nil
+	req.deferred = nil
+	req.compensations = nil
+	if len(options) > 0 {
+		if conf == nil {
+			conf = &runConfig[T]{}
+		}
+		req.conf = conf.reset(name)
+	}
 	for _, o := range options {
 		var err error
 		req, err = o(req)
@@ -336,22 +545,39 @@ func Run[T any](name string, req Request[T], options ...Option[T]) (Request[T],
 			return req, err
 		}
 	}
+	return req.conf.setup(req)
+}
 
-	if req.span.Span != nil && req.span.Span.IsRecording() {
-		req.Ctx, req.span = span.New(req.Ctx, fmt.Sprintf("statemachine(%s)", name))
-		req.otelStart()
-		defer req.otelEnd()
+// Step executes only the req.Next State, as Run() would execute it with options, and returns the resulting
+// Request. The returned Request.Next is the State the machine would execute next. Finalizers and
+// Compensations are not run. If Request.Ctx has an OTEL span, a child span is created for the State.
+// This is used to test a single State, see the smtest package.
+func Step[T any](name string, req Request[T], options ...Option[T]) (Request[T], error) {
+	req, err := prepare(name, req, options, nil)
+	if err != nil {
+		return req, err
 	}
 
-	for req.Next != nil {
-		var stateName string
-		stateName, req = execState(req)
-		if req.Err != nil {
-			req.span.Error(req.Err, "state", stateName)
-			return req, req.Err
-		}
+	if sp := span.Get(req.Ctx); sp.Span.IsRecording() {
+		req.span = sp
+		req.dataMode = req.conf.dataMode(sp.Span)
 	}
-	return req, nil
+	_, req = execState(req)
+	req.span, req.machine, req.dataMode = span.Span{}, "", dataAlways
+	return req, req.Err
+}
+
+// execUnnamed executes Request.Next state like execState() when Run() was passed no Options and the Request
+// is not recording. Nothing uses the name of the state in that case unless it fails, so it is only looked up
+// when the state returns an error and is empty otherwise.
+func execUnnamed[T any](req Request[T]) (string, Request[T]) {
+	state := req.Next
+	req.Next = nil
+	req = state(req)
+	if req.Err != nil {
+		return methodName(state), req
+	}
+	return "", req
 }
 
 var execReqNextNil = fmt.Errorf("bug: execState received Request.Next == nil")
@@ -364,28 +590,50 @@ func execState[T any](req Request[T]) (string, Request[T]) {
 	}
 
 	state := req.Next
-	stateName := methodName(state)
+	symbol := methodName(state)
+	stateName := req.conf.displayName(symbol)
 
-	if req.span.Span != nil && req.span.Span.IsRecording() {
-		parentCtx := req.Ctx
-		parentSpan := req.span
-		defer func() {
-			req.Ctx = parentCtx
-			req.span = parentSpan
-		}()
+	if req.seenStages != nil {
+		if n := req.seenStages.visit(stateName); n > req.conf.revisits {
+			req.Err = fmt.Errorf("%w: state %s was visited %d times: %s", ErrCycle, stateName, n+1, req.seenStages.callTrace())
+			return stateName, req
+		}
+	}
 
-		req.Ctx, req.span = span.New(req.Ctx, fmt.Sprintf("State(%s)", stateName))
+	req.Next = nil
+	if req.span.Span == nil || !req.span.Span.IsRecording() {
+		return stateName, req.conf.exec(symbol, stateName, state, req)
+	}
 
-		req.Event(stateName, "start", time.Now())
-		defer func() {
-			req.Event(stateName, "end", time.Now())
-		}()
+	parentCtx, parentSpan := req.Ctx, req.span
+	req.Ctx, req.span = span.New(req.Ctx, fmt.Sprintf("State(%s)", stateName))
+	req.conf.setSpanAttributes(req.span.Span)
+	req.addEvent(stateName, attribute.String("start", time.Now().Format(time.RFC3339Nano)))
+	var before string
+	if req.dataMode == dataAlways {
+		before = req.spanData()
 	}
 
-	req.Next = nil
-	return stateName, state(req)
+	req = req.conf.exec(symbol, stateName, state, req)
+
+	attrs := []attribute.KeyValue{attribute.String("end", time.Now().Format(time.RFC3339Nano))}
+	if req.dataMode == dataAlways {
+		if diff := diffJSON(before, req.spanData()); diff != "" {
+			attrs = append(attrs, attribute.String("data_diff", diff))
+		}
+	}
+	req.addEvent(stateName, attrs...)
+	if req.Err != nil {
+		req.span.Status(codes.Error, req.Err.Error())
+	}
+	req.span.End()
+	req.Ctx, req.span = parentCtx, parentSpan
+	return stateName, req
 }
 
+// methodNames caches the names returned by methodName(), keyed by function pointer.
+var methodNames sync.Map
+
 // methodName takes a function or a method and returns its name.
 func methodName(method any) string {
 	if method == nil {
@@ -394,7 +642,13 @@ func methodName(method any) string {
 	valueOf := reflect.ValueOf(method)
 	switch valueOf.Kind() {
 	case reflect.Func:
-		return strings.TrimSuffix(strings.TrimSuffix(runtime.FuncForPC(valueOf.Pointer()).Name(), "-fm"), "[...]")
+		pc := valueOf.Pointer()
+		if name, ok := methodNames.Load(pc); ok {
+			return name.(string)
+		}
+		name := strings.TrimSuffix(strings.TrimSuffix(runtime.FuncForPC(pc).Name(), "-fm"), "[...]")
+		methodNames.Store(pc, name)
+		return name
 	default:
 		return "<not a function>"
 	}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
@@ -95,7 +98,7 @@ func TestRun(t *testing.T) {
 				Next: steer,
 				Data: data{Num: 1},
 			},
-			wantReq: Request[data]{Ctx: context.Background(), Data: data{Num: 11}},
+			wantReq: Request[data]{Ctx: context.Background(), Data: data{Num: 11}, Status: Succeeded},
 		},
 	}
 
@@ -113,6 +116,60 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestStep(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		req      Request[data]
+		wantNext State[data]
+		wantNum  int
+		wantErr  bool
+	}{
+		{
+			name:    "Error: Next is nil",
+			req:     Request[data]{Ctx: context.Background()},
+			wantErr: true,
+		},
+		{
+			name:     "Routes to next",
+			req:      Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}},
+			wantNext: addTen,
+			wantNum:  1,
+		},
+		{
+			name:    "Executes only one state",
+			req:     Request[data]{Ctx: context.Background(), Next: addTen, Data: data{Num: 1}},
+			wantNum: 11,
+		},
+		{
+			name:    "State error",
+			req:     Request[data]{Ctx: context.Background(), Next: addErr},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Step("test", test.req)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestStep(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestStep(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if methodName(got.Next) != methodName(test.wantNext) {
+			t.Errorf("TestStep(%s): got Next == %s, want %s", test.name, methodName(got.Next), methodName(test.wantNext))
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestStep(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+	}
+}
+
 func TestExecState(t *testing.T) {
 	t.Parallel()
 
@@ -195,6 +252,72 @@ func TestExecState(t *testing.T) {
 	}
 }
 
+func TestExecUnnamed(t *testing.T) {
+	t.Parallel()
+
+	parentCtx := context.Background()
+
+	tests := []struct {
+		name          string
+		req           Request[data]
+		wantStateName string
+		wantRequest   Request[data]
+	}{
+		{
+			name:        "Success has no name",
+			req:         Request[data]{Ctx: parentCtx, Next: steer, Data: data{Num: 1}},
+			wantRequest: Request[data]{Ctx: parentCtx, Data: data{Num: 1}, Next: addTen},
+		},
+		{
+			name:          "Error has name",
+			req:           Request[data]{Ctx: parentCtx, Next: addErr, Data: data{Num: 1}},
+			wantStateName: "github.com/gostdlib/ops/statemachine.addErr",
+			wantRequest:   Request[data]{Ctx: parentCtx, Data: data{Num: 1}, Err: fmt.Errorf("addErr")},
+		},
+	}
+
+	for _, test := range tests {
+		gotStateName, gotRequest := execUnnamed(test.req)
+		if gotStateName != test.wantStateName {
+			t.Errorf("TestExecUnnamed(%s): stateName: got %q, want %q", test.name, gotStateName, test.wantStateName)
+		}
+		if diff := pretty.Compare(test.wantRequest, gotRequest); diff != "" {
+			t.Errorf("TestExecUnnamed(%s): Request: -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func BenchmarkMethodName(b *testing.B) {
+	b.Run("Cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			methodName(addTen)
+		}
+	})
+	b.Run("Uncached", func(b *testing.B) {
+		pc := reflect.ValueOf(addTen).Pointer()
+		for i := 0; i < b.N; i++ {
+			strings.TrimSuffix(strings.TrimSuffix(runtime.FuncForPC(pc).Name(), "-fm"), "[...]")
+		}
+	})
+}
+
+func BenchmarkRun(b *testing.B) {
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}}
+
+	b.Run("No options", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Run("bench", req)
+		}
+	})
+	b.Run("With options", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Run("bench", req, WithMaxTransitions[data](10))
+		}
+	})
+}
+
 func functionA() {
 	fmt.Println("Function A")
 }
@@ -225,5 +348,8 @@ func TestMethodName(t *testing.T) {
 		if got != test.want {
 			t.Errorf("TestMethodName(%s): got %q, want %q", test.name, got, test.want)
 		}
+		if got := methodName(test.fn); got != test.want {
+			t.Errorf("TestMethodName(%s): cached: got %q, want %q", test.name, got, test.want)
+		}
 	}
 }
@@ -0,0 +1,69 @@
+package statemachine
+
+// Status is the outcome of a machine, which lets a caller branch on how a workflow ended without
+// inventing sentinel errors. A State sets Request.Status to report an outcome such as Skipped. When
+// the machine stops, Run() sets Status to Succeeded if no State set it and the machine did not fail.
+// If the machine fails, Run() sets Status to Failed, Compensated or CompensationFailed, replacing any
+// Status set by a State. Status is set after finalizers run, so a finalizer that sets Request.Err makes
+// the Status Failed. Other values may be defined by the caller, such as:
+//
+//	const Deferred statemachine.Status = 100
+type Status uint8
+
+const (
+	// Succeeded indicates the machine finished without an error.
+	Succeeded Status = 1
+	// Skipped indicates the machine finished without an error but decided there was no work to do.
+	Skipped Status = 2
+	// Failed indicates the machine stopped with an error and had no Compensations to run.
+	Failed Status = 3
+	// Compensated indicates the machine stopped with an error and every Compensation succeeded.
+	Compensated Status = 4
+	// CompensationFailed indicates the machine stopped with an error and a Compensation failed.
+	CompensationFailed Status = 5
+)
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case Succeeded:
+		return "Succeeded"
+	case Skipped:
+		return "Skipped"
+	case Failed:
+		return "Failed"
+	case Compensated:
+		return "Compensated"
+	case CompensationFailed:
+		return "CompensationFailed"
+	}
+	return "Unknown"
+}
+
+// finalStatus returns the Status of a machine after its finalizers have run. status is Request.Status,
+// failed is the Status returned by runCompensations() and err is Request.Err. A machine that only failed
+// in a finalizer is Failed, as no Compensations were run for it.
+func finalStatus(status, failed Status, err error) Status {
+	switch {
+	case err == nil:
+		if status == 0 {
+			return Succeeded
+		}
+		return status
+	case failed != 0:
+		return failed
+	}
+	return Failed
+}
+
+// failedStatus returns the Status of a machine that failed. compensations is the number of Compensations
+// that were run and failed reports if any of them failed.
+func failedStatus(compensations int, failed bool) Status {
+	switch {
+	case failed:
+		return CompensationFailed
+	case compensations > 0:
+		return Compensated
+	}
+	return Failed
+}
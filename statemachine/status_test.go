@@ -0,0 +1,82 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func skip(req Request[data]) Request[data] {
+	req.Status = Skipped
+	return req
+}
+
+// compensated returns a State that registers a Compensation that returns undoErr and then fails.
+func compensated(undoErr error) State[data] {
+	return func(req Request[data]) Request[data] {
+		req.Compensate(func(context.Context, data) error { return undoErr })
+		req.Status = Succeeded
+		req.Err = errors.New("failed")
+		return req
+	}
+}
+
+// deferErr returns a State that defers a finalizer that sets Request.Err to err and then stops.
+func deferErr(err error) State[data] {
+	return func(req Request[data]) Request[data] {
+		req.Defer(func(req Request[data]) Request[data] {
+			req.Err = err
+			return req
+		})
+		req.Next = nil
+		return req
+	}
+}
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		start State[data]
+		num   int
+		want  Status
+	}{
+		{name: "Succeeded", start: steer, num: 1, want: Succeeded},
+		{name: "Skipped", start: skip, want: Skipped},
+		{name: "Failed", start: addErr, want: Failed},
+		{name: "Compensated", start: compensated(nil), want: Compensated},
+		{name: "CompensationFailed", start: compensated(errors.New("undo")), want: CompensationFailed},
+		{name: "Finalizer fails", start: deferErr(errors.New("finalizer")), want: Failed},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: test.start, Data: data{Num: test.num}}
+		got, _ := Run("test", req)
+		if got.Status != test.want {
+			t.Errorf("TestStatus(%s): got %v, want %v", test.name, got.Status, test.want)
+		}
+	}
+}
+
+func TestStatusString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		status Status
+		want   string
+	}{
+		{Succeeded, "Succeeded"},
+		{Skipped, "Skipped"},
+		{Failed, "Failed"},
+		{Compensated, "Compensated"},
+		{CompensationFailed, "CompensationFailed"},
+		{0, "Unknown"},
+	}
+
+	for _, test := range tests {
+		if got := test.status.String(); got != test.want {
+			t.Errorf("TestStatusString(%d): got %q, want %q", test.status, got, test.want)
+		}
+	}
+}
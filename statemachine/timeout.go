@@ -0,0 +1,93 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrStateTimeout is the error wrapped by Request.Err when a state runs longer than its timeout.
+var ErrStateTimeout = errors.New("state timeout")
+
+// WithStateTimeout bounds the time every state may execute for to d. Each state is given a Request.Ctx
+// that is cancelled after d. If the state has not returned by then, Run() stops with a Request.Err that
+// names the state and wraps ErrStateTimeout.
+//
+// The result of a state that overruns its timeout is discarded, even if it returns shortly after. A state
+// that does not honor Request.Ctx continues running in the background until it returns, so it must not
+// modify data shared with the rest of the program after its timeout. Use WithStateTimeoutFor() to set a
+// different timeout for a specific state.
+func WithStateTimeout[T any](d time.Duration) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if d <= 0 {
+			return req, fmt.Errorf("WithStateTimeout(%v) must be > 0", d)
+		}
+		req.conf.stateTimeout = d
+		return req, nil
+	}
+}
+
+// WithStateTimeoutFor sets the timeout for state to d, overriding any WithStateTimeout() for that state.
+// A d of 0 means the state has no timeout. See WithStateTimeout() for details.
+func WithStateTimeoutFor[T any](state State[T], d time.Duration) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if state == nil {
+			return req, errors.New("WithStateTimeoutFor() cannot be passed a nil State")
+		}
+		if d < 0 {
+			return req, fmt.Errorf("WithStateTimeoutFor(%v) must be >= 0", d)
+		}
+		if req.conf.stateTimeouts == nil {
+			req.conf.stateTimeouts = map[string]time.Duration{}
+		}
+		req.conf.stateTimeouts[methodName(state)] = d
+		return req, nil
+	}
+}
+
+// timeout returns the timeout for the state with symbol, its name from methodName(). 0 means no timeout.
+func (c *runConfig[T]) timeout(symbol string) time.Duration {
+	if d, ok := c.stateTimeouts[symbol]; ok {
+		return d
+	}
+	return c.stateTimeout
+}
+
+// withTimeout returns a State that executes state with a Request.Ctx that times out after d.
+func withTimeout[T any](stateName string, state State[T], d time.Duration) State[T] {
+	return func(req Request[T]) Request[T] {
+		parent := req.Ctx
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+
+		ch := make(chan Request[T], 1)
+		// Clip the slices so that Defer() or Compensate() in a state that overruns cannot write to the
+		// backing arrays of req. They are only kept if the state returns in time.
+		sreq := req
+		sreq.Ctx = ctx
+		sreq.deferred = slices.Clip(sreq.deferred)
+		sreq.compensations = slices.Clip(sreq.compensations)
+		go func() {
+			ch <- state(sreq)
+		}()
+
+		select {
+		case out := <-ch:
+			if ctx.Err() == nil {
+				out.Ctx = parent
+				return out
+			}
+		case <-ctx.Done():
+		}
+
+		// The state overran, so its result is discarded.
+		if err := parent.Err(); err != nil {
+			req.Err = fmt.Errorf("state %s: %w", stateName, err)
+		} else {
+			req.Err = fmt.Errorf("state %s exceeded its timeout of %v: %w", stateName, d, ErrStateTimeout)
+		}
+		return req
+	}
+}
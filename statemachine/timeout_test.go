@@ -0,0 +1,126 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// block is a State that waits for Request.Ctx to be done.
+func block(req Request[data]) Request[data] {
+	<-req.Ctx.Done()
+	req.Data.Num = -1
+	return req
+}
+
+// hang is a State that ignores Request.Ctx and does not return until release is closed.
+func hang(release chan struct{}) State[data] {
+	return func(req Request[data]) Request[data] {
+		<-release
+		return req
+	}
+}
+
+func TestWithStateTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	tests := []struct {
+		name    string
+		next    State[data]
+		options []Option[data]
+		wantErr error
+		wantNum int
+	}{
+		{
+			name:    "State finishes in time",
+			next:    steer,
+			options: []Option[data]{WithStateTimeout[data](time.Minute)},
+			wantNum: 11,
+		},
+		{
+			name:    "State honors context",
+			next:    block,
+			options: []Option[data]{WithStateTimeout[data](10 * time.Millisecond)},
+			wantErr: ErrStateTimeout,
+			wantNum: 1,
+		},
+		{
+			name:    "State ignores context",
+			next:    hang(release),
+			options: []Option[data]{WithStateTimeout[data](10 * time.Millisecond)},
+			wantErr: ErrStateTimeout,
+			wantNum: 1,
+		},
+		{
+			name: "Override for a state",
+			next: block,
+			options: []Option[data]{
+				WithStateTimeout[data](time.Minute),
+				WithStateTimeoutFor[data](block, 10*time.Millisecond),
+			},
+			wantErr: ErrStateTimeout,
+			wantNum: 1,
+		},
+		{
+			name: "Override removes timeout",
+			next: steer,
+			options: []Option[data]{
+				WithStateTimeout[data](time.Nanosecond),
+				WithStateTimeoutFor[data](steer, 0),
+				WithStateTimeoutFor[data](addTen, 0),
+			},
+			wantNum: 11,
+		},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: test.next, Data: data{Num: 1}}
+		got, err := Run("test", req, test.options...)
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestWithStateTimeout(%s): got err == %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestWithStateTimeout(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+		if got.Ctx != req.Ctx {
+			t.Errorf("TestWithStateTimeout(%s): Request.Ctx was not restored", test.name)
+		}
+	}
+}
+
+func TestWithTimeoutOverrun(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	overrun := func(req Request[data]) Request[data] {
+		defer close(done)
+		<-release
+		req.Defer(steer)
+		req.Compensate(func(context.Context, data) error { return nil })
+		return req
+	}
+
+	req := Request[data]{Ctx: context.Background()}
+	req.deferred = make([]State[data], 0, 1)
+	req.compensations = make([]Compensation[data], 0, 1)
+
+	got := withTimeout("overrun", overrun, time.Millisecond)(req)
+	close(release)
+	<-done
+
+	if !errors.Is(got.Err, ErrStateTimeout) {
+		t.Fatalf("TestWithTimeoutOverrun: got err == %v, want %v", got.Err, ErrStateTimeout)
+	}
+	if len(got.deferred) != 0 || len(got.compensations) != 0 {
+		t.Errorf("TestWithTimeoutOverrun: got finalizers or Compensations from a state that overran")
+	}
+	if req.deferred[:1][0] != nil || req.compensations[:1][0] != nil {
+		t.Errorf("TestWithTimeoutOverrun: a state that overran wrote to the slices of the Request")
+	}
+}
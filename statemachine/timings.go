@@ -0,0 +1,83 @@
+package statemachine
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StateTiming is the time spent in a State during a run.
+type StateTiming struct {
+	// Count is the number of times the State was executed.
+	Count int
+	// Total is the cumulative time the State executed for.
+	Total time.Duration
+}
+
+// Timings is a summary of where a machine spent its time, filled in by WithTimings().
+type Timings struct {
+	// Total is how long the machine ran for.
+	Total time.Duration
+	// States are the timings of each State executed, keyed by State name.
+	States map[string]StateTiming
+}
+
+// String returns the timings as a table, with the States that took the most time first.
+func (t *Timings) String() string {
+	names := make([]string, 0, len(t.States))
+	for n := range t.States {
+		names = append(names, n)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := cmp.Compare(t.States[b].Total, t.States[a].Total); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "total: %v\n", t.Total)
+	for _, n := range names {
+		st := t.States[n]
+		fmt.Fprintf(out, "%s: count %d, total %v\n", n, st.Count, st.Total)
+	}
+	return out.String()
+}
+
+// WithTimings records in t the total time the machine ran for and the number of times each State was
+// executed and the time spent in it, replacing anything t held. This shows a performance regression in a
+// specific State without tracing. A Timings must not be used by concurrent Run() calls.
+func WithTimings[T any](t *Timings) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if t == nil {
+			return req, errors.New("WithTimings() cannot be passed a nil *Timings")
+		}
+		*t = Timings{States: map[string]StateTiming{}}
+		return WithObserver[T](timingsObserver[T]{t: t})(req)
+	}
+}
+
+// timingsObserver is an Observer that records Timings.
+type timingsObserver[T any] struct {
+	t *Timings
+}
+
+func (timingsObserver[T]) OnStart(string, Request[T]) {}
+
+func (o timingsObserver[T]) OnTransition(_ context.Context, tr Transition[T]) {
+	if tr.Kind != Exited {
+		return
+	}
+	st := o.t.States[tr.State]
+	st.Count++
+	st.Total += tr.Duration
+	o.t.States[tr.State] = st
+}
+
+func (o timingsObserver[T]) OnEnd(_ string, _ Request[T], elapsed time.Duration) {
+	o.t.Total = elapsed
+}
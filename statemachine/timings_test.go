@@ -0,0 +1,53 @@
+package statemachine
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithTimings(t *testing.T) {
+	t.Parallel()
+
+	tm := &Timings{States: map[string]StateTiming{"old": {Count: 1}}}
+	req := Request[data]{Ctx: context.Background(), Next: crawl, Data: data{Num: 2}}
+	if _, err := Run("test", req, WithTimings[data](tm)); err != nil {
+		t.Fatalf("TestWithTimings: got err == %s, want err == nil", err)
+	}
+
+	if len(tm.States) != 1 {
+		t.Fatalf("TestWithTimings: got States == %+v, want only crawl", tm.States)
+	}
+	st := tm.States["github.com/gostdlib/ops/statemachine.crawl"]
+	if st.Count != 3 {
+		t.Errorf("TestWithTimings: got Count == %d, want 3", st.Count)
+	}
+	// crawl sleeps for 10ms each time.
+	if st.Total < 30*time.Millisecond {
+		t.Errorf("TestWithTimings: got state Total == %v, want >= 30ms", st.Total)
+	}
+	if tm.Total < st.Total {
+		t.Errorf("TestWithTimings: got Total == %v, want >= state total %v", tm.Total, st.Total)
+	}
+
+	if _, err := Run("test", req, WithTimings[data](nil)); err == nil {
+		t.Errorf("TestWithTimings: WithTimings(nil): got err == nil, want err != nil")
+	}
+}
+
+func TestTimingsString(t *testing.T) {
+	t.Parallel()
+
+	tm := &Timings{
+		Total: 10 * time.Second,
+		States: map[string]StateTiming{
+			"fast": {Count: 3, Total: time.Second},
+			"slow": {Count: 1, Total: 8 * time.Second},
+			"also": {Count: 1, Total: time.Second},
+		},
+	}
+	want := "total: 10s\nslow: count 1, total 8s\nalso: count 1, total 1s\nfast: count 3, total 1s\n"
+	if got := tm.String(); got != want {
+		t.Errorf("TestTimingsString: got %q, want %q", got, want)
+	}
+}
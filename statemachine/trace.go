@@ -0,0 +1,144 @@
+package statemachine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TraceStep records the execution of a single State.
+type TraceStep struct {
+	// State is the name of the State.
+	State string `json:"state"`
+	// Input is Request.Data before the State executed, encoded as JSON.
+	Input json.RawMessage `json:"input"`
+	// Output is Request.Data after the State executed, encoded as JSON.
+	Output json.RawMessage `json:"output"`
+	// Err is the text of the error the State returned, if any.
+	Err string `json:"err,omitempty"`
+	// Duration is how long the State took to execute.
+	Duration time.Duration `json:"duration"`
+}
+
+// Trace is an ordered record of the States a machine executed. It is filled in by WithTraceRecorder()
+// and can be stored as JSON to debug the path a machine took or to be replayed with Replay().
+type Trace struct {
+	// Machine is the name of the machine passed to Run().
+	Machine string `json:"machine"`
+	// Steps are the States executed, in order.
+	Steps []TraceStep `json:"steps"`
+}
+
+// WithTraceRecorder records every State executed by Run() in tr, replacing anything tr held. Request.Data
+// is snapshotted by encoding it as JSON before and after each State, so T must be JSON encodable. If it
+// cannot be encoded, the machine stops with an error. A Trace must not be used by concurrent Run() calls.
+func WithTraceRecorder[T any](tr *Trace) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if tr == nil {
+			return req, errors.New("WithTraceRecorder() cannot be passed a nil *Trace")
+		}
+		tr.Machine = req.conf.name
+		tr.Steps = nil
+
+		mw := func(stateName string, req Request[T], next State[T]) Request[T] {
+			in, err := json.Marshal(req.Data)
+			if err != nil {
+				req.Err = fmt.Errorf("trace recorder could not encode Request.Data before state %s: %w", stateName, err)
+				return req
+			}
+
+			start := time.Now()
+			req = next(req)
+			step := TraceStep{State: stateName, Input: in, Duration: time.Since(start)}
+			if req.Err != nil {
+				step.Err = req.Err.Error()
+			}
+			step.Output, err = json.Marshal(req.Data)
+			tr.Steps = append(tr.Steps, step)
+			if err != nil && req.Err == nil {
+				req.Err = fmt.Errorf("trace recorder could not encode Request.Data after state %s: %w", stateName, err)
+			}
+			return req
+		}
+
+		req.conf.middleware = append(req.conf.middleware, mw)
+		return req, nil
+	}
+}
+
+// Divergence describes the first step where a replayed Trace differs from the recorded Trace.
+type Divergence struct {
+	// Step is the index of the step in the Trace.
+	Step int
+	// Field is what differed: "state", "output", "err" or "steps" if one Trace has more steps.
+	Field string
+	// Want is the recorded value.
+	Want string
+	// Got is the replayed value.
+	Got string
+}
+
+// String implements fmt.Stringer.
+func (d *Divergence) String() string {
+	return fmt.Sprintf("step %d: %s: got %s, want %s", d.Step, d.Field, d.Got, d.Want)
+}
+
+/*
+Replay runs a machine that was recorded in tr again. Request.Data is decoded from the input of the first
+step in tr and the machine is started at start with ctx. This is used to check a recorded run against new
+State code, such as a fix for a bug the recording reproduces.
+
+The new Trace is returned with the first Divergence from tr, which is nil if the machine executed the same
+States, producing the same Data and errors. Durations are not compared. The returned error is from
+decoding the input or invalid Options, not from the machine, which is recorded in the new Trace.
+*/
+func Replay[T any](ctx context.Context, tr *Trace, start State[T], options ...Option[T]) (*Trace, *Divergence, error) {
+	if tr == nil || len(tr.Steps) == 0 {
+		return nil, nil, errors.New("Replay() requires a Trace with at least one step")
+	}
+
+	var d T
+	if err := json.Unmarshal(tr.Steps[0].Input, &d); err != nil {
+		return nil, nil, fmt.Errorf("Replay() could not decode the input of the first step: %w", err)
+	}
+
+	got := &Trace{}
+	options = append(options[:len(options):len(options)], WithTraceRecorder[T](got))
+	_, err := Run(tr.Machine, Request[T]{Ctx: ctx, Next: start, Data: d}, options...)
+	if err != nil && len(got.Steps) == 0 {
+		return nil, nil, err
+	}
+	return got, tr.diverge(got), nil
+}
+
+// diverge returns the first Divergence of got from t, or nil if there is none.
+func (t *Trace) diverge(got *Trace) *Divergence {
+	for i := 0; i < len(t.Steps) && i < len(got.Steps); i++ {
+		w, g := t.Steps[i], got.Steps[i]
+		switch {
+		case w.State != g.State:
+			return &Divergence{Step: i, Field: "state", Want: w.State, Got: g.State}
+		case !jsonEqual(w.Output, g.Output):
+			return &Divergence{Step: i, Field: "output", Want: string(w.Output), Got: string(g.Output)}
+		case w.Err != g.Err:
+			return &Divergence{Step: i, Field: "err", Want: w.Err, Got: g.Err}
+		}
+	}
+	if len(t.Steps) != len(got.Steps) {
+		n := min(len(t.Steps), len(got.Steps))
+		return &Divergence{Step: n, Field: "steps", Want: fmt.Sprint(len(t.Steps)), Got: fmt.Sprint(len(got.Steps))}
+	}
+	return nil
+}
+
+// jsonEqual reports if a and b are the same JSON, ignoring whitespace.
+func jsonEqual(a, b json.RawMessage) bool {
+	ca, cb := &bytes.Buffer{}, &bytes.Buffer{}
+	if json.Compact(ca, a) != nil || json.Compact(cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
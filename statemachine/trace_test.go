@@ -0,0 +1,194 @@
+package statemachine
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// addTwenty is a "fixed" version of addTen.
+func addTwenty(req Request[data]) Request[data] {
+	req.Data.Num += 20
+	req.Next = nil
+	return req
+}
+
+// steerTwenty is a "fixed" version of steer.
+func steerTwenty(req Request[data]) Request[data] {
+	req = steer(req)
+	if req.Next != nil {
+		req.Next = addTwenty
+	}
+	return req
+}
+
+func TestWithTraceRecorder(t *testing.T) {
+	t.Parallel()
+
+	tr := &Trace{Steps: []TraceStep{{State: "old"}}}
+	req := Request[data]{Ctx: context.Background(), Next: steer, Data: data{Num: 1}}
+	if _, err := Run("test", req, WithTraceRecorder[data](tr)); err != nil {
+		t.Fatalf("TestWithTraceRecorder: got err == %s, want err == nil", err)
+	}
+
+	b, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatalf("TestWithTraceRecorder: json.Marshal(): got err == %s, want err == nil", err)
+	}
+	got := &Trace{}
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatalf("TestWithTraceRecorder: json.Unmarshal(): got err == %s, want err == nil", err)
+	}
+	for i := range got.Steps {
+		if got.Steps[i].Duration <= 0 {
+			t.Errorf("TestWithTraceRecorder: step %d: got Duration == %v, want > 0", i, got.Steps[i].Duration)
+		}
+		got.Steps[i].Duration = 0
+	}
+
+	want := &Trace{
+		Machine: "test",
+		Steps: []TraceStep{
+			{State: "github.com/gostdlib/ops/statemachine.steer", Input: json.RawMessage(`{"Num":1}`), Output: json.RawMessage(`{"Num":1}`)},
+			{State: "github.com/gostdlib/ops/statemachine.addTen", Input: json.RawMessage(`{"Num":1}`), Output: json.RawMessage(`{"Num":11}`)},
+		},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("TestWithTraceRecorder: -want/+got:\n%s", diff)
+	}
+
+	req = Request[data]{Ctx: context.Background(), Next: addErr}
+	if _, err := Run("test", req, WithTraceRecorder[data](tr)); err == nil {
+		t.Fatalf("TestWithTraceRecorder: got err == nil, want err != nil")
+	}
+	if len(tr.Steps) != 1 || tr.Steps[0].Err != "addErr" {
+		t.Errorf("TestWithTraceRecorder: got Steps == %+v, want 1 step with Err == addErr", tr.Steps)
+	}
+
+	if _, err := Run("test", req, WithTraceRecorder[data](nil)); err == nil {
+		t.Errorf("TestWithTraceRecorder: WithTraceRecorder(nil): got err == nil, want err != nil")
+	}
+}
+
+func TestWithTraceRecorderEncodeErr(t *testing.T) {
+	t.Parallel()
+
+	type bad struct {
+		C chan int
+	}
+	state := func(req Request[bad]) Request[bad] {
+		return req
+	}
+
+	req := Request[bad]{Ctx: context.Background(), Next: state}
+	if _, err := Run("test", req, WithTraceRecorder[bad](&Trace{})); err == nil {
+		t.Errorf("TestWithTraceRecorderEncodeErr: got err == nil, want err != nil")
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	const (
+		steerName  = "github.com/gostdlib/ops/statemachine.steer"
+		addTenName = "github.com/gostdlib/ops/statemachine.addTen"
+	)
+
+	tr := &Trace{}
+	req := Request[data]{Ctx: ctx, Next: steer, Data: data{Num: 1}}
+	if _, err := Run("test", req, WithTraceRecorder[data](tr)); err != nil {
+		t.Fatalf("TestReplay: got err == %s, want err == nil", err)
+	}
+
+	tests := []struct {
+		name    string
+		tr      *Trace
+		start   State[data]
+		options []Option[data]
+		want    *Divergence
+		wantErr bool
+	}{
+		{
+			name:    "Error: empty Trace",
+			tr:      &Trace{},
+			start:   steer,
+			wantErr: true,
+		},
+		{
+			name:    "Error: bad input",
+			tr:      &Trace{Machine: "test", Steps: []TraceStep{{Input: json.RawMessage(`[]`)}}},
+			start:   steer,
+			wantErr: true,
+		},
+		{
+			name:  "Same code",
+			tr:    tr,
+			start: steer,
+		},
+		{
+			name:  "Different output",
+			tr:    tr,
+			start: steerTwenty,
+			options: []Option[data]{
+				WithStateName[data](steerTwenty, steerName),
+				WithStateName[data](addTwenty, addTenName),
+			},
+			want: &Divergence{Step: 1, Field: "output", Want: `{"Num":11}`, Got: `{"Num":21}`},
+		},
+		{
+			name:  "Different state",
+			tr:    tr,
+			start: steerTwenty,
+			options: []Option[data]{
+				WithStateName[data](steerTwenty, steerName),
+			},
+			want: &Divergence{Step: 1, Field: "state", Want: addTenName, Got: "github.com/gostdlib/ops/statemachine.addTwenty"},
+		},
+		{
+			name:  "Different first output",
+			tr:    tr,
+			start: addTen,
+			options: []Option[data]{
+				WithStateName[data](addTen, steerName),
+			},
+			want: &Divergence{Step: 0, Field: "output", Want: `{"Num":1}`, Got: `{"Num":11}`},
+		},
+	}
+
+	for _, test := range tests {
+		got, div, err := Replay(ctx, test.tr, test.start, test.options...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestReplay(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestReplay(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err != nil:
+			continue
+		}
+		if got == nil || len(got.Steps) == 0 {
+			t.Errorf("TestReplay(%s): got empty Trace, want steps", test.name)
+		}
+		if diff := pretty.Compare(test.want, div); diff != "" {
+			t.Errorf("TestReplay(%s): Divergence -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestTraceDivergeSteps(t *testing.T) {
+	t.Parallel()
+
+	a := &Trace{Steps: []TraceStep{{State: "a"}, {State: "b"}}}
+	b := &Trace{Steps: []TraceStep{{State: "a"}}}
+	want := &Divergence{Step: 1, Field: "steps", Want: "2", Got: "1"}
+	if diff := pretty.Compare(want, a.diverge(b)); diff != "" {
+		t.Errorf("TestTraceDivergeSteps: -want/+got:\n%s", diff)
+	}
+	if got := want.String(); got != "step 1: steps: got 1, want 2" {
+		t.Errorf("TestTraceDivergeSteps: String(): got %q", got)
+	}
+}
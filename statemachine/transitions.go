@@ -0,0 +1,71 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrMaxTransitions is returned by Run() when a machine tries to execute more states than were allowed
+// with WithMaxTransitions(). The returned error wraps ErrMaxTransitions and names the last states executed.
+var ErrMaxTransitions = errors.New("maximum state transitions exceeded")
+
+// WithMaxTransitions stops Run() with an error wrapping ErrMaxTransitions if the machine tries to execute
+// more than n states. This protects against runaway machines, such as a bug in a machine that legitimately
+// revisits states where cyclic state detection can't be used.
+func WithMaxTransitions[T any](n int) Option[T] {
+	return func(req Request[T]) (Request[T], error) {
+		if n < 1 {
+			return req, fmt.Errorf("WithMaxTransitions(%d) must be > 0", n)
+		}
+		req.conf.maxTransitions = n
+		return req, nil
+	}
+}
+
+// checkTransitions returns an error if executing another state after transitions states would exceed
+// the maximum number of transitions. c may be nil.
+func (c *runConfig[T]) checkTransitions(transitions int, recent *recentStates) error {
+	if c == nil || c.maxTransitions == 0 || transitions < c.maxTransitions {
+		return nil
+	}
+	return fmt.Errorf("%w: stopped after %d states, last states executed: %s", ErrMaxTransitions, transitions, recent)
+}
+
+// recentStates records the names of the most recently executed states.
+type recentStates struct {
+	names  [5]string
+	states [5]any
+	n      int
+}
+
+// add records that state was executed with name. If name is empty, because it was not looked up,
+// the name from methodName() is used when it is needed.
+func (r *recentStates) add(name string, state any) {
+	r.names[r.n%len(r.names)] = name
+	r.states[r.n%len(r.states)] = state
+	r.n++
+}
+
+// slice returns the recorded state names from oldest to newest.
+func (r *recentStates) slice() []string {
+	start := 0
+	if r.n > len(r.names) {
+		start = r.n - len(r.names)
+	}
+
+	out := make([]string, 0, r.n-start)
+	for i := start; i < r.n; i++ {
+		name := r.names[i%len(r.names)]
+		if name == "" {
+			name = methodName(r.states[i%len(r.states)])
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// String returns the recorded state names from oldest to newest.
+func (r *recentStates) String() string {
+	return strings.Join(r.slice(), " -> ")
+}
@@ -0,0 +1,70 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithMaxTransitions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		num     int
+		max     int
+		wantErr error
+		wantNum int
+	}{
+		{name: "Under the limit", num: 2, max: 5, wantNum: 0},
+		// loop with Num == 4 executes loop 5 times.
+		{name: "At the limit", num: 4, max: 5, wantNum: 0},
+		{name: "Over the limit", num: 10, max: 5, wantErr: ErrMaxTransitions, wantNum: 5},
+	}
+
+	for _, test := range tests {
+		req := Request[data]{Ctx: context.Background(), Next: loop, Data: data{Num: test.num}}
+		got, err := Run("test", req, WithMaxTransitions[data](test.max))
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("TestWithMaxTransitions(%s): got err == %v, want %v", test.name, err, test.wantErr)
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestWithMaxTransitions(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+		if err != nil && !strings.Contains(err.Error(), "statemachine.loop -> ") {
+			t.Errorf("TestWithMaxTransitions(%s): got err == %s, want it to name the last states", test.name, err)
+		}
+	}
+
+	req := Request[data]{Ctx: context.Background(), Next: loop}
+	if _, err := Run("test", req, WithMaxTransitions[data](0)); err == nil {
+		t.Errorf("TestWithMaxTransitions: WithMaxTransitions(0): got err == nil, want err != nil")
+	}
+}
+
+func TestRecentStates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{name: "Empty", want: ""},
+		{name: "Partial", names: []string{"a", "b"}, want: "a -> b"},
+		{name: "Wrapped", names: []string{"a", "b", "c", "d", "e", "f", "g"}, want: "c -> d -> e -> f -> g"},
+		{name: "Not looked up", names: []string{"a", ""}, want: "a -> github.com/gostdlib/ops/statemachine.loop"},
+	}
+
+	for _, test := range tests {
+		r := &recentStates{}
+		for _, n := range test.names {
+			r.add(n, State[data](loop))
+		}
+		if got := r.String(); got != test.want {
+			t.Errorf("TestRecentStates(%s): got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package statemachine
+
+import (
+	"errors"
+	"strings"
+)
+
+// ValidationError is returned by Graph.Validate() and Machine.Validate() when a machine has routing bugs.
+// Each field lists the states with that problem.
+type ValidationError struct {
+	// Unreachable are states that cannot be reached from the start.
+	Unreachable []string
+	// NoExit are states that have no transitions and are not terminal, so a machine in them can only stop
+	// with an error.
+	NoExit []string
+	// NoTerminalPath are reachable states from which no terminal state can be reached, so a machine in them
+	// can never finish successfully.
+	NoTerminalPath []string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	var parts []string
+	add := func(problem string, states []string) {
+		if len(states) > 0 {
+			parts = append(parts, problem+": "+strings.Join(states, ", "))
+		}
+	}
+	add("unreachable states", e.Unreachable)
+	add("states with no exit", e.NoExit)
+	add("states with no path to a terminal state", e.NoTerminalPath)
+	return "invalid machine: " + strings.Join(parts, "; ")
+}
+
+// Validate checks the Graph for unreachable states, states with no exit and states with no path to a terminal
+// state. Problems are returned as a *ValidationError. This is best called at startup on a declared Graph,
+// such as from Machine.Validate(), to turn routing bugs into startup errors instead of production hangs.
+func (g *Graph) Validate() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.start == "" {
+		return errors.New("invalid machine: Graph has no start state")
+	}
+
+	reachable := map[string]bool{g.start: true}
+	queue := []string{g.start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, t := range g.edges[n] {
+			if !reachable[t] {
+				reachable[t] = true
+				queue = append(queue, t)
+			}
+		}
+	}
+
+	// finishes holds the states that can reach a terminal state, found by walking the edges backwards.
+	reverse := map[string][]string{}
+	for _, n := range g.nodes {
+		for _, t := range g.edges[n] {
+			reverse[t] = append(reverse[t], n)
+		}
+	}
+	finishes := map[string]bool{}
+	queue = queue[:0]
+	for _, n := range g.nodes {
+		if g.terminals[n] {
+			finishes[n] = true
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, f := range reverse[n] {
+			if !finishes[f] {
+				finishes[f] = true
+				queue = append(queue, f)
+			}
+		}
+	}
+
+	verr := &ValidationError{}
+	for _, n := range g.nodes {
+		switch {
+		case !reachable[n]:
+			verr.Unreachable = append(verr.Unreachable, n)
+		case len(g.edges[n]) == 0 && !g.terminals[n]:
+			verr.NoExit = append(verr.NoExit, n)
+		case !finishes[n]:
+			verr.NoTerminalPath = append(verr.NoTerminalPath, n)
+		}
+	}
+	if verr.Unreachable == nil && verr.NoExit == nil && verr.NoTerminalPath == nil {
+		return nil
+	}
+	return verr
+}
+
+// Validate checks the Machine with Graph.Validate().
+func (m *Machine[T]) Validate() error {
+	return m.Graph("validate").Validate()
+}
@@ -0,0 +1,86 @@
+package statemachine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		g       *Graph
+		want    *ValidationError
+		wantErr bool
+	}{
+		{
+			name: "Valid",
+			g:    NewGraph("g").SetStart("a").AddTransition("a", "b", "c").AddTransition("b", "a").AddTerminal("c"),
+		},
+		{
+			name:    "No start",
+			g:       NewGraph("g").AddTransition("a", "b"),
+			wantErr: true,
+		},
+		{
+			name:    "Unreachable",
+			g:       NewGraph("g").SetStart("a").AddTerminal("a").AddTransition("b", "a"),
+			want:    &ValidationError{Unreachable: []string{"b"}},
+			wantErr: true,
+		},
+		{
+			name:    "No exit",
+			g:       NewGraph("g").SetStart("a").AddTransition("a", "b", "c").AddTerminal("c"),
+			want:    &ValidationError{NoExit: []string{"b"}},
+			wantErr: true,
+		},
+		{
+			name:    "No terminal path",
+			g:       NewGraph("g").SetStart("a").AddTransition("a", "b", "c").AddTransition("b", "d").AddTransition("d", "b").AddTerminal("c"),
+			want:    &ValidationError{NoTerminalPath: []string{"b", "d"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		err := test.g.Validate()
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestValidate(%s): got err == nil, want err != nil", test.name)
+			continue
+		case err != nil && !test.wantErr:
+			t.Errorf("TestValidate(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		case err == nil || test.want == nil:
+			continue
+		}
+
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("TestValidate(%s): got err type %T, want *ValidationError", test.name, err)
+			continue
+		}
+		if diff := pretty.Compare(test.want, verr); diff != "" {
+			t.Errorf("TestValidate(%s): -want/+got:\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestMachineValidate(t *testing.T) {
+	t.Parallel()
+
+	m, err := NewBuilder[data]().
+		State("steer", steer).
+		State("addTen", addTen).
+		Transition("steer", "addTen").
+		Build()
+	if err != nil {
+		panic(err)
+	}
+	if err := m.Validate(); err == nil {
+		t.Errorf("TestMachineValidate: got err == nil, want err != nil")
+	}
+}
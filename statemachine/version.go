@@ -0,0 +1,103 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrMigration is returned by RunResumable() when a Checkpoint cannot be mapped onto the current machine
+// definition, such as when it names a State that no longer exists or was saved by an unknown version.
+var ErrMigration = errors.New("checkpoint cannot be migrated to the machine definition")
+
+// Migration maps a Checkpoint saved by version From of a machine definition to version From+1.
+type Migration[T any] struct {
+	// From is the version this Migration applies to.
+	From int
+	// States maps the names of States in version From to their names in version From+1. A name that is
+	// not in States is unchanged. Map a removed State to the State that replaces it.
+	States map[string]string
+	// Data, if set, converts the Data saved by version From to what version From+1 expects.
+	Data func(data T) (T, error)
+}
+
+// versioned is a Checkpointer that versions Checkpoints.
+type versioned[T any] struct {
+	cp         Checkpointer[T]
+	version    int
+	migrations map[int]Migration[T]
+}
+
+/*
+NewVersionedCheckpointer returns a Checkpointer that saves Checkpoints with cp, stamped with version.
+This is used with RunResumable() so a Checkpoint saved by an older deployment of a machine is mapped onto
+the current definition or fails with an error wrapping ErrMigration, instead of resuming at the wrong State.
+
+Increase version when the machine definition changes in a way that affects saved Checkpoints, such as
+renaming or removing a State, and add a Migration from the previous version. A Checkpoint is migrated
+by applying every Migration from its version to version in order. Checkpoints saved without versioning
+have version 0. Loading a Checkpoint from a newer version, or one with a missing Migration, fails.
+
+	cp, err := statemachine.NewVersionedCheckpointer[Data](
+		store,
+		2,
+		statemachine.Migration[Data]{From: 0},
+		statemachine.Migration[Data]{
+			From:   1,
+			States: map[string]string{"example.com/vm.CreateVM": "example.com/vm.ProvisionVM"},
+		},
+	)
+*/
+func NewVersionedCheckpointer[T any](cp Checkpointer[T], version int, migrations ...Migration[T]) (Checkpointer[T], error) {
+	if cp == nil {
+		return nil, errors.New("NewVersionedCheckpointer() cannot be passed a nil Checkpointer")
+	}
+	if version < 0 {
+		return nil, fmt.Errorf("NewVersionedCheckpointer() version(%d) must be >= 0", version)
+	}
+
+	v := versioned[T]{cp: cp, version: version, migrations: make(map[int]Migration[T], len(migrations))}
+	for _, m := range migrations {
+		if m.From < 0 || m.From >= version {
+			return nil, fmt.Errorf("NewVersionedCheckpointer() Migration.From(%d) must be >= 0 and < version(%d)", m.From, version)
+		}
+		if _, ok := v.migrations[m.From]; ok {
+			return nil, fmt.Errorf("NewVersionedCheckpointer() has multiple Migrations from version %d", m.From)
+		}
+		v.migrations[m.From] = m
+	}
+	return v, nil
+}
+
+// Save implements Checkpointer.Save().
+func (v versioned[T]) Save(ctx context.Context, id string, cp Checkpoint[T]) error {
+	cp.Version = v.version
+	return v.cp.Save(ctx, id, cp)
+}
+
+// Load implements Checkpointer.Load(). The Checkpoint is migrated to the current version.
+func (v versioned[T]) Load(ctx context.Context, id string) (Checkpoint[T], error) {
+	cp, err := v.cp.Load(ctx, id)
+	if err != nil {
+		return cp, err
+	}
+	if cp.Version > v.version {
+		return cp, fmt.Errorf("%w: checkpoint for %s was saved by version %d, which is newer than version %d", ErrMigration, id, cp.Version, v.version)
+	}
+
+	for ; cp.Version < v.version; cp.Version++ {
+		m, ok := v.migrations[cp.Version]
+		if !ok {
+			return cp, fmt.Errorf("%w: checkpoint for %s is version %d and there is no Migration from it", ErrMigration, id, cp.Version)
+		}
+		if n, ok := m.States[cp.State]; ok && cp.State != "" {
+			cp.State = n
+		}
+		if m.Data != nil {
+			if cp.Data, err = m.Data(cp.Data); err != nil {
+				return cp, fmt.Errorf("%w: checkpoint for %s could not migrate Data from version %d: %w", ErrMigration, id, cp.Version, err)
+			}
+		}
+	}
+	return cp, nil
+}
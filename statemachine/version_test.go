@@ -0,0 +1,176 @@
+package statemachine
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewVersionedCheckpointer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		cp         Checkpointer[data]
+		version    int
+		migrations []Migration[data]
+		wantErr    bool
+	}{
+		{
+			name:    "Error: nil Checkpointer",
+			version: 1,
+			wantErr: true,
+		},
+		{
+			name:    "Error: negative version",
+			cp:      NewMemCheckpointer[data](),
+			version: -1,
+			wantErr: true,
+		},
+		{
+			name:       "Error: Migration from current version",
+			cp:         NewMemCheckpointer[data](),
+			version:    1,
+			migrations: []Migration[data]{{From: 1}},
+			wantErr:    true,
+		},
+		{
+			name:       "Error: duplicate Migration",
+			cp:         NewMemCheckpointer[data](),
+			version:    2,
+			migrations: []Migration[data]{{From: 1}, {From: 1}},
+			wantErr:    true,
+		},
+		{
+			name:       "Success",
+			cp:         NewMemCheckpointer[data](),
+			version:    2,
+			migrations: []Migration[data]{{From: 0}, {From: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewVersionedCheckpointer(test.cp, test.version, test.migrations...)
+		switch {
+		case err == nil && test.wantErr:
+			t.Errorf("TestNewVersionedCheckpointer(%s): got err == nil, want err != nil", test.name)
+		case err != nil && !test.wantErr:
+			t.Errorf("TestNewVersionedCheckpointer(%s): got err == %s, want err == nil", test.name, err)
+		}
+	}
+}
+
+func TestVersionedResume(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := &resumable{}
+	states := []State[data]{m.step1, m.step2, m.step3}
+	step2 := methodName(m.step2)
+
+	migrations := []Migration[data]{
+		{From: 0},
+		{
+			From:   1,
+			States: map[string]string{"old.step2": step2},
+			Data: func(d data) (data, error) {
+				d.Num += 1000
+				return d, nil
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		saved   Checkpoint[data]
+		version int
+		wantNum int
+		wantErr error
+	}{
+		{
+			name:    "Migrates state and data",
+			saved:   Checkpoint[data]{Machine: "test", Version: 1, State: "old.step2", Data: data{Num: 1}},
+			version: 2,
+			wantNum: 1 + 1000 + 10 + 100,
+		},
+		{
+			name:    "Unversioned checkpoint",
+			saved:   Checkpoint[data]{Machine: "test", State: step2, Data: data{Num: 1}},
+			version: 2,
+			wantNum: 1 + 1000 + 10 + 100,
+		},
+		{
+			name:    "Same version",
+			saved:   Checkpoint[data]{Machine: "test", Version: 2, State: step2, Data: data{Num: 1}},
+			version: 2,
+			wantNum: 1 + 10 + 100,
+		},
+		{
+			name:    "Error: newer version",
+			saved:   Checkpoint[data]{Machine: "test", Version: 3, State: step2},
+			version: 2,
+			wantErr: ErrMigration,
+		},
+		{
+			name:    "Error: no Migration",
+			saved:   Checkpoint[data]{Machine: "test", Version: 0, State: step2},
+			version: 3,
+			wantErr: ErrMigration,
+		},
+		{
+			name:    "Error: unknown state",
+			saved:   Checkpoint[data]{Machine: "test", Version: 2, State: "old.step2"},
+			version: 2,
+			wantErr: ErrMigration,
+		},
+	}
+
+	for _, test := range tests {
+		mem := NewMemCheckpointer[data]()
+		if err := mem.Save(ctx, "id", test.saved); err != nil {
+			t.Fatalf("TestVersionedResume(%s): Save(): got err == %s, want err == nil", test.name, err)
+		}
+		cp, err := NewVersionedCheckpointer[data](mem, test.version, migrations...)
+		if err != nil {
+			t.Fatalf("TestVersionedResume(%s): NewVersionedCheckpointer(): got err == %s, want err == nil", test.name, err)
+		}
+
+		got, err := RunResumable("test", "id", Request[data]{Ctx: ctx, Next: m.step1}, cp, states)
+		switch {
+		case test.wantErr != nil:
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("TestVersionedResume(%s): got err == %v, want %v", test.name, err, test.wantErr)
+			}
+			continue
+		case err != nil:
+			t.Errorf("TestVersionedResume(%s): got err == %s, want err == nil", test.name, err)
+			continue
+		}
+		if got.Data.Num != test.wantNum {
+			t.Errorf("TestVersionedResume(%s): got Data.Num == %d, want %d", test.name, got.Data.Num, test.wantNum)
+		}
+
+		saved, err := mem.Load(ctx, "id")
+		if err != nil {
+			t.Fatalf("TestVersionedResume(%s): Load(): got err == %s, want err == nil", test.name, err)
+		}
+		if saved.Version != test.version || saved.Machine != "test" {
+			t.Errorf("TestVersionedResume(%s): got saved Version == %d, Machine == %s, want %d, test", test.name, saved.Version, saved.Machine, test.version)
+		}
+	}
+}
+
+func TestResumeOtherMachine(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m := &resumable{}
+	mem := NewMemCheckpointer[data]()
+	if err := mem.Save(ctx, "id", Checkpoint[data]{Machine: "other", State: methodName(m.step2)}); err != nil {
+		t.Fatalf("TestResumeOtherMachine: Save(): got err == %s, want err == nil", err)
+	}
+	_, err := RunResumable("test", "id", Request[data]{Ctx: ctx, Next: m.step1}, mem, []State[data]{m.step1, m.step2, m.step3})
+	if err == nil {
+		t.Errorf("TestResumeOtherMachine: got err == nil, want err != nil")
+	}
+}